@@ -189,6 +189,29 @@ func NewAccessControlHandler[T any](
 	}
 }
 
+// rejects connections from ips banned by banManager.
+// executes handler and registers a failure for the connection's ip if it returns an error.
+// wrap accept and authentication handlers with this to ban repeat offenders.
+func NewBanHandler[T any](
+	banManager *tools.BanManager,
+	handler HandlerWithError[T],
+) HandlerWithError[T] {
+	return func(connection systemge.Connection[T]) error {
+		ip, _, err := net.SplitHostPort(connection.GetAddress())
+		if err != nil {
+			return err
+		}
+		if banManager.IsBanned(ip) {
+			return errors.New("banned")
+		}
+		if err := handler(connection); err != nil {
+			banManager.RegisterFailure(ip)
+			return err
+		}
+		return nil
+	}
+}
+
 // executes getCurrentPassword with connection as argument, reads message from connection, unmarshals it and compares with current password.
 // optionally writes requestMessage to connection before attempting to read password.
 // returns nil error if passwords match.
@@ -232,6 +255,7 @@ func NewSingleReadAsyncHandler[T any](
 	stopChannel <-chan struct{},
 ) HandlerWithError[T] {
 	return func(connection systemge.Connection[T]) error {
+		var readErr error // set before the channel of ChannelCall is closed
 		select {
 		case <-stopChannel:
 			connection.SetReadDeadline(1)
@@ -242,10 +266,13 @@ func NewSingleReadAsyncHandler[T any](
 			// ending routine due to connection close
 			return errors.New("connection was closed")
 
-		case data, ok := <-helpers.ChannelCall(func() (T, error) { return connection.Read(readerConfig.ReadTimeoutNs) }):
+		case data, ok := <-helpers.ChannelCall(func() (T, error) {
+			data, err := connection.Read(readerConfig.ReadTimeoutNs)
+			readErr = err
+			return data, err
+		}):
 			if !ok {
-				// do smthg with the error
-				return errors.New("error reading data")
+				return errors.New("error reading data: " + readErr.Error())
 			}
 			readHandler(data, connection)
 			connection.Close()
@@ -261,6 +288,7 @@ func NewSingleReadSyncHandler[T any](
 	stopChannel <-chan struct{},
 ) HandlerWithError[T] {
 	return func(connection systemge.Connection[T]) error {
+		var readErr error // set before the channel of ChannelCall is closed
 		select {
 		case <-stopChannel:
 			connection.SetReadDeadline(1)
@@ -271,10 +299,13 @@ func NewSingleReadSyncHandler[T any](
 			// ending routine due to connection close
 			return errors.New("connection was closed")
 
-		case data, ok := <-helpers.ChannelCall(func() (T, error) { return connection.Read(readerConfig.ReadTimeoutNs) }):
+		case data, ok := <-helpers.ChannelCall(func() (T, error) {
+			data, err := connection.Read(readerConfig.ReadTimeoutNs)
+			readErr = err
+			return data, err
+		}):
 			if !ok {
-				// do smthg with the error
-				return errors.New("error reading data")
+				return errors.New("error reading data: " + readErr.Error())
 			}
 			result, err := readHandler(data, connection)
 			connection.Close()
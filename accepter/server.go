@@ -12,12 +12,21 @@ import (
 	"github.com/neutralusername/systemge/tools"
 )
 
+// emits the following events if an event handler is set (the first option is the default):
+//   - EVENT_ACCEPT_SUCCEEDED: Continue (handle the connection), Skip (close the connection), Cancel (stop the routine. the unhandled connection is closed)
+//   - EVENT_ACCEPT_FAILED: Continue, Retry (accept again. only offered while the listener is started), Cancel (stop the routine), Panic
+//   - EVENT_ACCEPT_HANDLER_FAILED: Continue (close the connection), Cancel (keep the connection open), Panic
+//   - EVENT_CONNECTION_LIFETIME_EXPIRED: Continue (close the connection), Cancel (keep the connection open)
+//   - EVENT_ROUTINE_STOPPED: Continue (the reason is "routine stopped" or "listener stopped")
 type Accepter[T any] struct {
 	listener      systemge.Listener[T]
 	acceptRoutine *tools.Routine
 
 	AcceptHandler HandlerWithError[T]
 
+	logger       *tools.Logger
+	eventHandler *tools.Handler
+
 	// metrics
 
 	SucceededAccepts atomic.Uint64
@@ -51,9 +60,15 @@ func New[T any](
 
 	handleAccept := func(connection systemge.Connection[T]) {
 		if err := server.AcceptHandler(connection); err != nil {
-			connection.Close()
-			// do smthg with the error
+			server.logger.Warn("accept handler failed", "address", connection.GetAddress(), "error", err)
 			server.FailedAccepts.Add(1)
+			if server.eventHandler.Emit(
+				tools.EVENT_ACCEPT_HANDLER_FAILED,
+				systemge.NewEventContext(server.listener, connection, err),
+				tools.Continue, tools.Cancel, tools.Panic,
+			) != tools.Cancel {
+				connection.Close()
+			}
 			return
 		}
 		server.SucceededAccepts.Add(1)
@@ -61,38 +76,86 @@ func New[T any](
 
 	acceptRoutine, err := tools.NewRoutine(
 		func(stopChannel <-chan struct{}) {
-			select {
-			case <-stopChannel:
-				listener.SetAcceptDeadline(1)
-				// routine was stopped
-				server.FailedAccepts.Add(1)
-				return
-
-			case <-listener.GetStopChannel():
-				server.acceptRoutine.Stop()
-				// listener was stopped
-				server.FailedAccepts.Add(1)
-				return
-
-			case connection, ok := <-helpers.ChannelCall(func() (systemge.Connection[T], error) { return listener.Accept(accepterConfig.AcceptTimeoutNs) }):
-				if !ok {
-					// do smthg with the error
+			for {
+				var acceptErr error // set before the channel of ChannelCall is closed
+				select {
+				case <-stopChannel:
+					listener.SetAcceptDeadline(1)
+					// routine was stopped
 					server.FailedAccepts.Add(1)
+					server.emitRoutineStopped("routine stopped")
+					return
+
+				case <-listener.GetStopChannel():
+					// stopping blocks until this call returns
+					go server.acceptRoutine.Stop()
+					// listener was stopped
+					server.FailedAccepts.Add(1)
+					server.emitRoutineStopped("listener stopped")
+					return
+
+				case connection, ok := <-helpers.ChannelCall(func() (systemge.Connection[T], error) {
+					connection, err := listener.Accept(accepterConfig.AcceptTimeoutNs)
+					acceptErr = err
+					return connection, err
+				}):
+					if !ok {
+						server.logger.Warn("accept failed", "listener", listener.GetName(), "error", acceptErr)
+						server.FailedAccepts.Add(1)
+						options := []int8{tools.Cancel, tools.Panic}
+						if listener.GetStatus() == status.Started { // retrying a stopped listener would fail immediately again
+							options = append(options, tools.Retry)
+						}
+						switch server.eventHandler.Emit(
+							tools.EVENT_ACCEPT_FAILED,
+							systemge.NewEventContext(listener, nil, acceptErr),
+							tools.Continue, options...,
+						) {
+						case tools.Retry:
+							continue
+						case tools.Cancel:
+							go server.acceptRoutine.Stop()
+						}
+						return
+					}
+
+					switch server.eventHandler.Emit(
+						tools.EVENT_ACCEPT_SUCCEEDED,
+						systemge.NewEventContext(listener, connection, nil),
+						tools.Continue, tools.Skip, tools.Cancel,
+					) {
+					case tools.Skip:
+						connection.Close()
+						server.FailedAccepts.Add(1)
+						return
+					case tools.Cancel:
+						connection.Close()
+						server.FailedAccepts.Add(1)
+						go server.acceptRoutine.Stop()
+						return
+					}
+
+					if accepterConfig.ConnectionLifetimeNs > 0 {
+						tools.NewTimeout(
+							accepterConfig.ConnectionLifetimeNs,
+							func() {
+								if server.eventHandler.Emit(
+									tools.EVENT_CONNECTION_LIFETIME_EXPIRED,
+									systemge.NewEventContext(listener, connection, nil),
+									tools.Continue, tools.Cancel,
+								) != tools.Cancel {
+									connection.Close()
+								}
+							},
+							false,
+						)
+					}
+					if !accepterConfig.HandleAcceptsConcurrently {
+						handleAccept(connection)
+					} else {
+						go handleAccept(connection)
+					}
 					return
-				}
-				if accepterConfig.ConnectionLifetimeNs > 0 {
-					tools.NewTimeout(
-						accepterConfig.ConnectionLifetimeNs,
-						func() {
-							connection.Close()
-						},
-						false,
-					)
-				}
-				if !accepterConfig.HandleAcceptsConcurrently {
-					handleAccept(connection)
-				} else {
-					go handleAccept(connection)
 				}
 			}
 		},
@@ -107,6 +170,26 @@ func New[T any](
 	return server, nil
 }
 
+// failed accepts and failed accept handlers are logged to logger. should be called before the routine is started.
+func (server *Accepter[T]) SetLogger(logger *tools.Logger) {
+	server.logger = logger
+}
+
+// the accepter emits its events to eventHandler. should be called before the routine is started.
+func (server *Accepter[T]) SetEventHandler(eventHandler *tools.Handler) {
+	server.eventHandler = eventHandler
+}
+
+func (server *Accepter[T]) emitRoutineStopped(reason string) {
+	context := systemge.NewEventContext[T](server.listener, nil, nil)
+	context["reason"] = reason
+	server.eventHandler.Emit(tools.EVENT_ROUTINE_STOPPED, context, tools.Continue)
+}
+
+func (server *Accepter[T]) GetListener() systemge.Listener[T] {
+	return server.listener
+}
+
 func (server *Accepter[T]) GetRoutine() *tools.Routine {
 	return server.acceptRoutine
 }
@@ -119,6 +202,7 @@ func (server *Accepter[T]) CheckMetrics() tools.MetricsTypes {
 			"failedAccepts":    server.FailedAccepts.Load(),
 		},
 	))
+	metricsTypes.Merge(server.acceptRoutine.CheckMetrics())
 	metricsTypes.Merge(server.listener.CheckMetrics())
 	return metricsTypes
 }
@@ -130,6 +214,7 @@ func (server *Accepter[T]) GetMetrics() tools.MetricsTypes {
 			"failedAccepts":    server.FailedAccepts.Swap(0),
 		},
 	))
+	metricsTypes.Merge(server.acceptRoutine.GetMetrics())
 	metricsTypes.Merge(server.listener.GetMetrics())
 	return metricsTypes
 }
@@ -0,0 +1,49 @@
+package alerting
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	STATE_INACTIVE = "inactive"
+	STATE_PENDING  = "pending"  // the condition is met but did not hold for ForNs yet
+	STATE_FIRING   = "firing"   // the condition held for ForNs
+	STATE_RESOLVED = "resolved" // the condition of a firing alert is no longer met (only used for notifications)
+)
+
+// the state of a rule at the time of an evaluation. sent to the sinks when a rule fires or resolves.
+type Alert struct {
+	Rule        string `json:"rule"`
+	State       string `json:"state"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+
+	Source      string  `json:"source"`
+	MetricsType string  `json:"metricsType"`
+	Key         string  `json:"key"`
+	Function    string  `json:"function"`
+	Operator    string  `json:"operator"`
+	Threshold   float64 `json:"threshold"`
+	Value       float64 `json:"value"` // the value of the last evaluation that met the condition (or the current value if resolved)
+
+	ActiveSince time.Time `json:"activeSince"` // time the condition was first met
+	FiredAt     time.Time `json:"firedAt"`     // zero if the alert never fired
+	ResolvedAt  time.Time `json:"resolvedAt"`  // zero unless resolved
+	Time        time.Time `json:"time"`        // time of the evaluation
+}
+
+// e.g. "rate(listener/accepter_server/failedAccepts) > 5".
+func (alert *Alert) GetCondition() string {
+	return alert.Function + "(" + alert.Source + "/" + alert.MetricsType + "/" + alert.Key + ") " + alert.Operator + " " + strconv.FormatFloat(alert.Threshold, 'f', -1, 64)
+}
+
+// e.g. "[FIRING] highFailureRate (critical)".
+func (alert *Alert) GetSummary() string {
+	summary := "[" + strings.ToUpper(alert.State) + "] " + alert.Rule
+	if alert.Severity != "" {
+		summary += " (" + alert.Severity + ")"
+	}
+	return summary
+}
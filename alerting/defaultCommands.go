@@ -0,0 +1,98 @@
+package alerting
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/status"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// commands:
+//   - start, stop, getStatus, checkMetrics, getMetrics
+//   - evaluate: evaluates all rules now and returns the alerts that were sent
+//   - getAlerts: returns the pending and firing alerts
+//   - getRules
+//   - addRule <rule json>
+//   - removeRule <name>
+func (engine *Engine) GetDefaultCommands() tools.CommandHandlers {
+	commands := tools.CommandHandlers{}
+	commands["start"] = func(args []string) (string, error) {
+		err := engine.Start()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["stop"] = func(args []string) (string, error) {
+		err := engine.Stop()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["getStatus"] = func(args []string) (string, error) {
+		return status.ToString(engine.GetStatus()), nil
+	}
+	commands["checkMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(engine.CheckMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(engine.GetMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["evaluate"] = func(args []string) (string, error) {
+		json, err := json.Marshal(engine.Evaluate())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getAlerts"] = func(args []string) (string, error) {
+		json, err := json.Marshal(engine.GetAlerts())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getRules"] = func(args []string) (string, error) {
+		json, err := json.Marshal(engine.GetRules())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["addRule"] = func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", errors.New("expected 1 argument (rule json)")
+		}
+		rule := configs.UnmarshalAlertRule(args[0])
+		if rule == nil {
+			return "", errors.New("failed to unmarshal rule")
+		}
+		err := engine.AddRule(rule)
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["removeRule"] = func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", errors.New("expected 1 argument (name)")
+		}
+		err := engine.RemoveRule(args[0])
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	return commands
+}
@@ -0,0 +1,353 @@
+package alerting
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/status"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// evaluates rules over the metrics of its sources every EvaluationIntervalNs and notifies its sinks
+// whenever a rule fires, is re-sent (ResendIntervalNs) or resolves.
+// a rule only notifies on state changes, so a condition that stays met does not produce duplicate alerts.
+// the notifications of a rule are sent in order, i.e. a sink never receives a resolved alert before the alert that fired.
+// a sink that exceeds NotifyTimeoutNs is counted as failed and may receive the next alert of the rule before it returns,
+// so sinks should not block longer than that (e.g. MailSink only queues its mails).
+type Engine struct {
+	name   string
+	config *configs.AlertingEngine
+
+	status      *status.Machine
+	statusMutex sync.Mutex // serializes Start and Stop
+	stopChannel chan struct{}
+	waitGroup   sync.WaitGroup
+
+	sourcesMutex sync.RWMutex
+	sources      map[string]func() tools.MetricsTypes
+
+	sinksMutex sync.RWMutex
+	sinks      map[string]Sink
+
+	rulesMutex sync.Mutex
+	rules      map[string]*ruleState
+
+	notifyWaitGroup sync.WaitGroup
+
+	// metrics
+
+	Evaluations         atomic.Uint64
+	AlertsFired         atomic.Uint64
+	AlertsResolved      atomic.Uint64
+	NotificationsSent   atomic.Uint64
+	NotificationsFailed atomic.Uint64
+}
+
+func New(name string, config *configs.AlertingEngine) (*Engine, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if config.EvaluationIntervalNs <= 0 {
+		return nil, errors.New("evaluationIntervalNs must be greater than 0")
+	}
+	if config.NotifyTimeoutNs <= 0 {
+		config.NotifyTimeoutNs = int64(30 * time.Second)
+	}
+	engine := &Engine{
+		name:    name,
+		config:  config,
+		status:  status.NewMachine(),
+		sources: make(map[string]func() tools.MetricsTypes),
+		sinks:   make(map[string]Sink),
+		rules:   make(map[string]*ruleState),
+	}
+	for _, rule := range config.Rules {
+		if err := engine.AddRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+// starts evaluating the rules every EvaluationIntervalNs.
+func (engine *Engine) Start() error {
+	engine.statusMutex.Lock()
+	defer engine.statusMutex.Unlock()
+
+	if err := engine.status.Transition(status.Starting, nil); err != nil {
+		return errors.New("alerting engine not stopped")
+	}
+	engine.stopChannel = make(chan struct{})
+	engine.status.Transition(status.Started, nil)
+
+	engine.waitGroup.Add(1)
+	go engine.evaluationRoutine(engine.stopChannel)
+	return nil
+}
+
+// stops evaluating and waits for pending notifications. the state of the rules is kept.
+func (engine *Engine) Stop() error {
+	engine.statusMutex.Lock()
+	defer engine.statusMutex.Unlock()
+
+	if !engine.status.Is(status.Started) {
+		return errors.New("alerting engine not started")
+	}
+	engine.status.Transition(status.Stopping, nil)
+	close(engine.stopChannel)
+	engine.waitGroup.Wait()
+	engine.notifyWaitGroup.Wait()
+	engine.status.Transition(status.Stopped, nil)
+	return nil
+}
+
+func (engine *Engine) GetStatus() int {
+	return engine.status.Get()
+}
+
+func (engine *Engine) GetStatusMachine() *status.Machine {
+	return engine.status
+}
+
+func (engine *Engine) GetName() string {
+	return engine.name
+}
+
+// checkMetrics should be a CheckMetrics method. GetMetrics would reset the counters of the source on every evaluation.
+func (engine *Engine) AddSource(name string, checkMetrics func() tools.MetricsTypes) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if checkMetrics == nil {
+		return errors.New("checkMetrics is nil")
+	}
+	engine.sourcesMutex.Lock()
+	defer engine.sourcesMutex.Unlock()
+	if _, ok := engine.sources[name]; ok {
+		return errors.New("source already exists")
+	}
+	engine.sources[name] = checkMetrics
+	return nil
+}
+
+// rules of the source no longer meet their condition and resolve on the next evaluation.
+func (engine *Engine) RemoveSource(name string) error {
+	engine.sourcesMutex.Lock()
+	defer engine.sourcesMutex.Unlock()
+	if _, ok := engine.sources[name]; !ok {
+		return errors.New("source does not exist")
+	}
+	delete(engine.sources, name)
+	return nil
+}
+
+func (engine *Engine) AddSink(name string, sink Sink) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if sink == nil {
+		return errors.New("sink is nil")
+	}
+	engine.sinksMutex.Lock()
+	defer engine.sinksMutex.Unlock()
+	if _, ok := engine.sinks[name]; ok {
+		return errors.New("sink already exists")
+	}
+	engine.sinks[name] = sink
+	return nil
+}
+
+func (engine *Engine) RemoveSink(name string) error {
+	engine.sinksMutex.Lock()
+	defer engine.sinksMutex.Unlock()
+	if _, ok := engine.sinks[name]; !ok {
+		return errors.New("sink does not exist")
+	}
+	delete(engine.sinks, name)
+	return nil
+}
+
+func (engine *Engine) AddRule(rule *configs.AlertRule) error {
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+	engine.rulesMutex.Lock()
+	defer engine.rulesMutex.Unlock()
+	if _, ok := engine.rules[rule.Name]; ok {
+		return errors.New("rule already exists")
+	}
+	engine.rules[rule.Name] = &ruleState{
+		rule:  rule,
+		state: STATE_INACTIVE,
+	}
+	return nil
+}
+
+// a firing rule is removed without a resolved notification.
+func (engine *Engine) RemoveRule(name string) error {
+	engine.rulesMutex.Lock()
+	defer engine.rulesMutex.Unlock()
+	if _, ok := engine.rules[name]; !ok {
+		return errors.New("rule does not exist")
+	}
+	delete(engine.rules, name)
+	return nil
+}
+
+// returns the rules sorted by name.
+func (engine *Engine) GetRules() []*configs.AlertRule {
+	engine.rulesMutex.Lock()
+	defer engine.rulesMutex.Unlock()
+	rules := make([]*configs.AlertRule, 0, len(engine.rules))
+	for _, ruleState := range engine.rules {
+		rules = append(rules, ruleState.rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules
+}
+
+// returns the pending and firing alerts sorted by rule name.
+func (engine *Engine) GetAlerts() []*Alert {
+	engine.rulesMutex.Lock()
+	defer engine.rulesMutex.Unlock()
+	now := time.Now()
+	alerts := []*Alert{}
+	for _, ruleState := range engine.rules {
+		if ruleState.state == STATE_INACTIVE {
+			continue
+		}
+		alerts = append(alerts, ruleState.newAlert(ruleState.state, now))
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Rule < alerts[j].Rule
+	})
+	return alerts
+}
+
+// evaluates all rules now (in addition to the evaluations every EvaluationIntervalNs) and returns the alerts that were sent.
+// notifications are sent asynchronously.
+func (engine *Engine) Evaluate() []*Alert {
+	// the metrics are collected before the rules are locked, so slow sources do not block GetAlerts and GetRules
+	engine.rulesMutex.Lock()
+	sourceNames := map[string]struct{}{}
+	for _, ruleState := range engine.rules {
+		sourceNames[ruleState.rule.Source] = struct{}{}
+	}
+	engine.rulesMutex.Unlock()
+
+	engine.sourcesMutex.RLock()
+	sources := make(map[string]func() tools.MetricsTypes, len(sourceNames))
+	for name := range sourceNames {
+		if checkMetrics, ok := engine.sources[name]; ok {
+			sources[name] = checkMetrics
+		}
+	}
+	engine.sourcesMutex.RUnlock()
+
+	metricsTypes := make(map[string]tools.MetricsTypes, len(sources))
+	for name, checkMetrics := range sources {
+		metricsTypes[name] = checkMetrics()
+	}
+
+	now := time.Now()
+	engine.rulesMutex.Lock()
+	alerts := []*Alert{}
+	notifications := []*notification{}
+	for _, ruleState := range engine.rules {
+		alert := ruleState.evaluate(metricsTypes[ruleState.rule.Source], now, engine.config.ResendIntervalNs)
+		if alert == nil {
+			continue
+		}
+		if alert.State == STATE_RESOLVED {
+			engine.AlertsResolved.Add(1)
+		} else if alert.Time.Equal(alert.FiredAt) {
+			engine.AlertsFired.Add(1)
+		}
+		alerts = append(alerts, alert)
+		notification := &notification{
+			alert:    alert,
+			previous: ruleState.notified,
+			done:     make(chan struct{}),
+		}
+		ruleState.notified = notification.done
+		notifications = append(notifications, notification)
+	}
+	engine.rulesMutex.Unlock()
+	engine.Evaluations.Add(1)
+
+	for _, notification := range notifications {
+		engine.notify(notification)
+	}
+	return alerts
+}
+
+// an alert that is sent once the previous notification of its rule was sent.
+type notification struct {
+	alert    *Alert
+	previous <-chan struct{} // nil for the first notification of a rule
+	done     chan struct{}
+}
+
+func (engine *Engine) notify(notification *notification) {
+	engine.sinksMutex.RLock()
+	sinks := make([]Sink, 0, len(engine.sinks))
+	for _, sink := range engine.sinks {
+		sinks = append(sinks, sink)
+	}
+	engine.sinksMutex.RUnlock()
+
+	engine.notifyWaitGroup.Add(1)
+	go func() {
+		defer engine.notifyWaitGroup.Done()
+		defer close(notification.done)
+		if notification.previous != nil {
+			<-notification.previous
+		}
+
+		waitGroup := sync.WaitGroup{}
+		for _, sink := range sinks {
+			waitGroup.Add(1)
+			go func(sink Sink) {
+				defer waitGroup.Done()
+				errChannel := make(chan error, 1)
+				go func() {
+					errChannel <- sink.Notify(notification.alert)
+				}()
+				timer := time.NewTimer(time.Duration(engine.config.NotifyTimeoutNs))
+				defer timer.Stop()
+				select {
+				case err := <-errChannel:
+					if err != nil {
+						engine.NotificationsFailed.Add(1)
+						return
+					}
+					engine.NotificationsSent.Add(1)
+				case <-timer.C:
+					engine.NotificationsFailed.Add(1)
+				}
+			}(sink)
+		}
+		waitGroup.Wait()
+	}()
+}
+
+func (engine *Engine) evaluationRoutine(stopChannel <-chan struct{}) {
+	defer engine.waitGroup.Done()
+
+	ticker := time.NewTicker(time.Duration(engine.config.EvaluationIntervalNs))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopChannel:
+			return
+		case <-ticker.C:
+			engine.Evaluate()
+		}
+	}
+}
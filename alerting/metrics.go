@@ -0,0 +1,45 @@
+package alerting
+
+import (
+	"sync/atomic"
+
+	"github.com/neutralusername/systemge/tools"
+)
+
+func (engine *Engine) CheckMetrics() tools.MetricsTypes {
+	return engine.getMetrics(func(value *atomic.Uint64) uint64 { return value.Load() })
+}
+
+func (engine *Engine) GetMetrics() tools.MetricsTypes {
+	return engine.getMetrics(func(value *atomic.Uint64) uint64 { return value.Swap(0) })
+}
+
+func (engine *Engine) getMetrics(load func(*atomic.Uint64) uint64) tools.MetricsTypes {
+	engine.rulesMutex.Lock()
+	rules := len(engine.rules)
+	pending, firing := 0, 0
+	for _, ruleState := range engine.rules {
+		switch ruleState.state {
+		case STATE_PENDING:
+			pending++
+		case STATE_FIRING:
+			firing++
+		}
+	}
+	engine.rulesMutex.Unlock()
+
+	metricsTypes := tools.NewMetricsTypes()
+	metricsTypes.AddMetrics("alerting_engine", tools.NewMetrics(
+		map[string]uint64{
+			"rules":               uint64(rules),
+			"alertsPending":       uint64(pending),
+			"alertsFiring":        uint64(firing),
+			"evaluations":         load(&engine.Evaluations),
+			"alertsFired":         load(&engine.AlertsFired),
+			"alertsResolved":      load(&engine.AlertsResolved),
+			"notificationsSent":   load(&engine.NotificationsSent),
+			"notificationsFailed": load(&engine.NotificationsFailed),
+		},
+	))
+	return metricsTypes
+}
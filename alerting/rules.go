@@ -0,0 +1,190 @@
+package alerting
+
+import (
+	"errors"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/tools"
+)
+
+const (
+	FUNCTION_VALUE = "value"
+	FUNCTION_RATE  = "rate"
+)
+
+type ruleState struct {
+	rule *configs.AlertRule
+
+	samples []*sample // within the rate window, oldest first
+
+	state        string
+	value        float64
+	activeSince  time.Time
+	firedAt      time.Time
+	lastNotified time.Time
+
+	notified chan struct{} // closed once the most recent notification of the rule was sent. nil if there is none
+}
+
+type sample struct {
+	time  time.Time
+	value uint64
+}
+
+// sets the defaults of the rule and returns an error if it is invalid.
+func validateRule(rule *configs.AlertRule) error {
+	if rule == nil {
+		return errors.New("rule is nil")
+	}
+	if rule.Name == "" {
+		return errors.New("rule name is empty")
+	}
+	if rule.Source == "" || rule.MetricsType == "" || rule.Key == "" {
+		return errors.New("rule source, metricsType and key are required")
+	}
+	switch rule.Function {
+	case "":
+		rule.Function = FUNCTION_VALUE
+	case FUNCTION_VALUE, FUNCTION_RATE:
+	default:
+		return errors.New("unknown function \"" + rule.Function + "\"")
+	}
+	if rule.RateWindowNs <= 0 {
+		rule.RateWindowNs = int64(time.Minute)
+	}
+	switch rule.Operator {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return errors.New("unknown operator \"" + rule.Operator + "\"")
+	}
+	if rule.ForNs < 0 {
+		return errors.New("forNs is negative")
+	}
+	return nil
+}
+
+func compare(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	}
+	return false
+}
+
+// returns the current value of the rule's function and false if there is not enough data.
+func (ruleState *ruleState) computeValue(metricsTypes tools.MetricsTypes, now time.Time) (float64, bool) {
+	metrics := metricsTypes.GetMetrics(ruleState.rule.MetricsType)
+	if metrics == nil {
+		return 0, false
+	}
+	value, ok := metrics.KeyValuePairs[ruleState.rule.Key]
+	if !ok {
+		return 0, false
+	}
+	if ruleState.rule.Function == FUNCTION_VALUE {
+		return float64(value), true
+	}
+
+	ruleState.samples = append(ruleState.samples, &sample{time: now, value: value})
+	windowStart := now.Add(-time.Duration(ruleState.rule.RateWindowNs))
+	expired := 0
+	for expired < len(ruleState.samples) && ruleState.samples[expired].time.Before(windowStart) {
+		expired++
+	}
+	ruleState.samples = ruleState.samples[expired:]
+	if len(ruleState.samples) < 2 {
+		return 0, false
+	}
+
+	// decreasing values are treated as resets (e.g. by GetMetrics)
+	increase := uint64(0)
+	for i := 1; i < len(ruleState.samples); i++ {
+		previous, current := ruleState.samples[i-1].value, ruleState.samples[i].value
+		if current >= previous {
+			increase += current - previous
+		} else {
+			increase += current
+		}
+	}
+	seconds := ruleState.samples[len(ruleState.samples)-1].time.Sub(ruleState.samples[0].time).Seconds()
+	if seconds <= 0 {
+		return 0, false
+	}
+	return float64(increase) / seconds, true
+}
+
+// advances the state of the rule and returns the alert to send, or nil if nothing changed.
+// resendIntervalNs > 0 re-sends firing alerts.
+func (ruleState *ruleState) evaluate(metricsTypes tools.MetricsTypes, now time.Time, resendIntervalNs int64) *Alert {
+	value, ok := ruleState.computeValue(metricsTypes, now)
+	conditionMet := ok && compare(value, ruleState.rule.Operator, ruleState.rule.Threshold)
+	if ok {
+		ruleState.value = value
+	}
+
+	if !conditionMet {
+		switch ruleState.state {
+		case STATE_PENDING:
+			ruleState.state = STATE_INACTIVE
+		case STATE_FIRING:
+			alert := ruleState.newAlert(STATE_RESOLVED, now)
+			alert.ResolvedAt = now
+			ruleState.state = STATE_INACTIVE
+			ruleState.activeSince = time.Time{}
+			ruleState.firedAt = time.Time{}
+			return alert
+		}
+		return nil
+	}
+
+	switch ruleState.state {
+	case STATE_INACTIVE:
+		ruleState.state = STATE_PENDING
+		ruleState.activeSince = now
+		fallthrough
+	case STATE_PENDING:
+		if now.Sub(ruleState.activeSince) < time.Duration(ruleState.rule.ForNs) {
+			return nil
+		}
+		ruleState.state = STATE_FIRING
+		ruleState.firedAt = now
+		ruleState.lastNotified = now
+		return ruleState.newAlert(STATE_FIRING, now)
+	case STATE_FIRING:
+		if resendIntervalNs > 0 && now.Sub(ruleState.lastNotified) >= time.Duration(resendIntervalNs) {
+			ruleState.lastNotified = now
+			return ruleState.newAlert(STATE_FIRING, now)
+		}
+	}
+	return nil
+}
+
+func (ruleState *ruleState) newAlert(state string, now time.Time) *Alert {
+	return &Alert{
+		Rule:        ruleState.rule.Name,
+		State:       state,
+		Severity:    ruleState.rule.Severity,
+		Description: ruleState.rule.Description,
+		Source:      ruleState.rule.Source,
+		MetricsType: ruleState.rule.MetricsType,
+		Key:         ruleState.rule.Key,
+		Function:    ruleState.rule.Function,
+		Operator:    ruleState.rule.Operator,
+		Threshold:   ruleState.rule.Threshold,
+		Value:       ruleState.value,
+		ActiveSince: ruleState.activeSince,
+		FiredAt:     ruleState.firedAt,
+		Time:        now,
+	}
+}
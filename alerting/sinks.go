@@ -0,0 +1,140 @@
+package alerting
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// receives the alerts of an engine whenever a rule fires, is re-sent or resolves.
+type Sink interface {
+	Notify(alert *Alert) error
+}
+
+// wraps a function as a sink.
+type SinkFunc func(alert *Alert) error
+
+func (sinkFunc SinkFunc) Notify(alert *Alert) error {
+	return sinkFunc(alert)
+}
+
+// queues every alert as a mail to the mailer's recipients.
+// the mailer has to be started. it delivers the mails of a rule in order and retries them on its own,
+// so a slow smtp server neither delays the engine nor reorders the alerts.
+// delivery failures are counted by the mailer's metrics, not by the engine's.
+type MailSink struct {
+	mailer *tools.Mailer
+}
+
+func NewMailSink(mailer *tools.Mailer) (*MailSink, error) {
+	if mailer == nil {
+		return nil, errors.New("mailer is nil")
+	}
+	return &MailSink{
+		mailer: mailer,
+	}, nil
+}
+
+func (sink *MailSink) Notify(alert *Alert) error {
+	return sink.mailer.SendAsync(tools.NewMail(nil, alert.GetSummary(), formatAlertHtml(alert)))
+}
+
+func formatAlertHtml(alert *Alert) string {
+	lines := []string{
+		"rule: " + alert.Rule,
+		"state: " + alert.State,
+		"condition: " + alert.GetCondition(),
+		"value: " + strconv.FormatFloat(alert.Value, 'f', -1, 64),
+	}
+	if alert.Severity != "" {
+		lines = append(lines, "severity: "+alert.Severity)
+	}
+	if alert.Description != "" {
+		lines = append(lines, "description: "+alert.Description)
+	}
+	lines = append(lines, "active since: "+alert.ActiveSince.Format(time.RFC3339))
+	if !alert.ResolvedAt.IsZero() {
+		lines = append(lines, "resolved at: "+alert.ResolvedAt.Format(time.RFC3339))
+	}
+	return "<pre>" + html.EscapeString(strings.Join(lines, "\n")) + "</pre>"
+}
+
+// logs every alert through a logger (firing alerts as warnings, resolved alerts as info).
+type LogSink struct {
+	logger *tools.Logger
+}
+
+func NewLogSink(logger *tools.Logger) (*LogSink, error) {
+	if logger == nil {
+		return nil, errors.New("logger is nil")
+	}
+	return &LogSink{
+		logger: logger,
+	}, nil
+}
+
+func (sink *LogSink) Notify(alert *Alert) error {
+	args := []any{
+		"rule", alert.Rule,
+		"state", alert.State,
+		"severity", alert.Severity,
+		"condition", alert.GetCondition(),
+		"value", alert.Value,
+		"activeSince", alert.ActiveSince,
+	}
+	if alert.State == STATE_RESOLVED {
+		sink.logger.Info(alert.GetSummary(), append(args, "resolvedAt", alert.ResolvedAt)...)
+	} else {
+		sink.logger.Warn(alert.GetSummary(), args...)
+	}
+	return nil
+}
+
+// writes every alert as an async message with the alert as json payload to the connections.
+type TopicSink struct {
+	topic          string
+	writeTimeoutNs int64
+	connections    []systemge.Connection[*tools.Message]
+}
+
+func NewTopicSink(topic string, writeTimeoutNs int64, connections ...systemge.Connection[*tools.Message]) (*TopicSink, error) {
+	if topic == "" {
+		return nil, errors.New("topic is empty")
+	}
+	if len(connections) == 0 {
+		return nil, errors.New("no connections provided")
+	}
+	return &TopicSink{
+		topic:          topic,
+		writeTimeoutNs: writeTimeoutNs,
+		connections:    connections,
+	}, nil
+}
+
+// returns an error if the alert could not be written to any of the connections.
+func (sink *TopicSink) Notify(alert *Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	message := tools.NewAsync(sink.topic, string(data))
+	var lastErr error
+	written := 0
+	for _, connection := range sink.connections {
+		if err := connection.Write(message, sink.writeTimeoutNs); err != nil {
+			lastErr = err
+			continue
+		}
+		written++
+	}
+	if written == 0 {
+		return lastErr
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// alertingexample runs an alerting engine that mails its alerts through an in-process smtp server.
+//
+// usage:
+//
+//	alertingexample [-timeout <duration>]
+//
+// a rule fires while the "length" of a simulated queue is above 10.
+// the example raises the length until the rule fires, lowers it until the rule resolves
+// and prints the subject of every mail the smtp server received.
+// the exit code is 1 if a mail is not received before the timeout expires.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/neutralusername/systemge/alerting"
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/tools"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", 10*time.Second, "time to wait for every mail")
+	flag.Parse()
+
+	if err := run(*timeout); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(timeout time.Duration) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	subjects := make(chan string, 10)
+	go serveSmtp(listener, subjects)
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	mailer, err := tools.NewMailer(&configs.Mailer{
+		SmtpHost:      "127.0.0.1",
+		SmtpPort:      uint16(port),
+		SenderEmail:   "alerts@example.com",
+		Recipients:    []string{"oncall@example.com"},
+		TlsMode:       tools.MAILER_TLS_NONE,
+		SendTimeoutNs: int64(5 * time.Second),
+	})
+	if err != nil {
+		return err
+	}
+	if err := mailer.Start(); err != nil {
+		return err
+	}
+	defer mailer.Stop()
+	mailSink, err := alerting.NewMailSink(mailer)
+	if err != nil {
+		return err
+	}
+
+	queueLength := atomic.Uint64{}
+	engine, err := alerting.New("example", &configs.AlertingEngine{
+		EvaluationIntervalNs: int64(100 * time.Millisecond),
+		Rules: []*configs.AlertRule{{
+			Name:        "queueFull",
+			Severity:    "warning",
+			Description: "the queue holds more than 10 entries",
+			Source:      "queue",
+			MetricsType: "queue",
+			Key:         "length",
+			Operator:    ">",
+			Threshold:   10,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := engine.AddSource("queue", func() tools.MetricsTypes {
+		metricsTypes := tools.NewMetricsTypes()
+		metricsTypes.AddMetrics("queue", tools.NewMetrics(map[string]uint64{
+			"length": queueLength.Load(),
+		}))
+		return metricsTypes
+	}); err != nil {
+		return err
+	}
+	if err := engine.AddSink("mail", mailSink); err != nil {
+		return err
+	}
+	if err := engine.Start(); err != nil {
+		return err
+	}
+	defer engine.Stop()
+
+	queueLength.Store(20)
+	if err := awaitMail(subjects, timeout); err != nil {
+		return err
+	}
+	queueLength.Store(0)
+	return awaitMail(subjects, timeout)
+}
+
+func awaitMail(subjects <-chan string, timeout time.Duration) error {
+	select {
+	case subject := <-subjects:
+		fmt.Println(subject)
+		return nil
+	case <-time.After(timeout):
+		return errors.New("no mail received")
+	}
+}
+
+// accepts the mails of the mailer (without tls and authentication) and sends their subjects to subjects.
+func serveSmtp(listener net.Listener, subjects chan<- string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go handleSmtp(textproto.NewConn(conn), subjects)
+	}
+}
+
+func handleSmtp(conn *textproto.Conn, subjects chan<- string) {
+	defer conn.Close()
+	conn.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		command, _, _ := strings.Cut(strings.ToUpper(line), " ")
+		switch command {
+		case "EHLO", "HELO":
+			conn.PrintfLine("250 localhost")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			conn.PrintfLine("250 OK")
+		case "DATA":
+			conn.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			header, err := conn.ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			if _, err := conn.ReadDotBytes(); err != nil {
+				return
+			}
+			subjects <- header.Get("Subject")
+			conn.PrintfLine("250 OK")
+		case "QUIT":
+			conn.PrintfLine("221 bye")
+			return
+		default:
+			conn.PrintfLine("502 command not implemented")
+		}
+	}
+}
@@ -0,0 +1,385 @@
+// systemgectl performs systemge operations from the os command line.
+//
+// usage:
+//
+//	systemgectl -config <path> [-timeout <duration>] <operation> [flags]
+//
+// operations:
+//
+//	async     -topic <topic> -payload <payload>
+//	          writes an async message.
+//	sync      -topic <topic> -payload <payload> [-responses <n>]
+//	          writes a sync message and prints responses until n responses were received or the timeout expires (no limit if <= 0).
+//	subscribe [-topics <topic,topic>] [-subscribe] [-count <n>]
+//	          prints received messages of the topics (all topics if empty).
+//	          -subscribe writes a tools.TOPIC_SUBSCRIBE_ASYNC message with the topics as json array first.
+//	          runs until n messages were printed (forever if 0), the timeout expires (if set explicitly) or the connection is closed.
+//	command   -name <name> -command <command> [args...]
+//	          executes a command of a remoteCommands.Server and prints its result.
+//
+// messages are printed as one json object per line. errors are printed to stderr.
+// the exit code is one of the exit* constants below.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/listenerTcp"
+	"github.com/neutralusername/systemge/listenerWebsocket"
+	"github.com/neutralusername/systemge/remoteCommands"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+	"github.com/neutralusername/systemge/typedConnection"
+)
+
+const (
+	exitSuccess         = 0
+	exitUsage           = 1 // invalid flags or operation
+	exitConfig          = 2 // config file missing or invalid
+	exitConnection      = 3 // connecting failed or the connection was closed
+	exitWrite           = 4 // writing a message failed
+	exitTimeout         = 5 // fewer responses/messages than expected before the timeout expired
+	exitFailureResponse = 6 // a failure response was received or a remote command failed
+)
+
+type config struct {
+	Transport                string                     `json:"transport"`                // default: "tcp" ("tcp" or "websocket")
+	TcpClientConfig          *configs.TcpClient         `json:"tcpClientConfig"`          // *required*
+	TcpBufferedReaderConfig  *configs.TcpBufferedReader `json:"tcpBufferedReaderConfig"`  // *optional* (tcp only)
+	WebsocketPattern         string                     `json:"websocketPattern"`         // default: "/" (websocket only)
+	IncomingMessageByteLimit uint64                     `json:"incomingMessageByteLimit"` // default: 0 == no limit (websocket only)
+}
+
+type exitError struct {
+	code int
+	err  error
+}
+
+func (err *exitError) Error() string {
+	return err.err.Error()
+}
+
+func newExitError(code int, err error) error {
+	return &exitError{code, err}
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err == nil {
+		os.Exit(exitSuccess)
+	}
+	fmt.Fprintln(os.Stderr, "error: "+err.Error())
+	exitErr := &exitError{}
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.code)
+	}
+	os.Exit(exitUsage)
+}
+
+func run(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("systemgectl", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path of the json config file (required)")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout for connecting and waiting for responses")
+	if err := flags.Parse(args); err != nil {
+		return newExitError(exitUsage, err)
+	}
+	timeoutSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "timeout" {
+			timeoutSet = true
+		}
+	})
+	if *configPath == "" {
+		return newExitError(exitUsage, errors.New("-config is required"))
+	}
+	if flags.NArg() == 0 {
+		return newExitError(exitUsage, errors.New("operation is required (async, sync, subscribe or command)"))
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		return newExitError(exitConfig, err)
+	}
+
+	operation, operationArgs := flags.Arg(0), flags.Args()[1:]
+	switch operation {
+	case "async":
+		return runAsync(config, *timeout, operationArgs)
+	case "sync":
+		return runSync(config, *timeout, operationArgs, output)
+	case "subscribe":
+		if !timeoutSet {
+			*timeout = 0
+		}
+		return runSubscribe(config, *timeout, operationArgs, output)
+	case "command":
+		return runCommand(config, *timeout, operationArgs, output)
+	default:
+		return newExitError(exitUsage, errors.New("unknown operation \""+operation+"\""))
+	}
+}
+
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	if config.TcpClientConfig == nil {
+		return nil, errors.New("tcpClientConfig is required")
+	}
+	switch config.Transport {
+	case "", "tcp":
+		if config.TcpBufferedReaderConfig == nil {
+			config.TcpBufferedReaderConfig = &configs.TcpBufferedReader{}
+		}
+	case "websocket":
+		if config.WebsocketPattern == "" {
+			config.WebsocketPattern = "/"
+		}
+	default:
+		return nil, errors.New("unknown transport \"" + config.Transport + "\"")
+	}
+	return config, nil
+}
+
+func connect(config *config, timeout time.Duration) (systemge.Connection[*tools.Message], error) {
+	var connection systemge.Connection[[]byte]
+	var err error
+	connectTimeoutNs := int64(timeout)
+	if connectTimeoutNs == 0 {
+		connectTimeoutNs = int64(10 * time.Second)
+	}
+	if config.Transport == "websocket" {
+		connection, err = listenerWebsocket.ConnectPattern(config.TcpClientConfig, config.WebsocketPattern, config.IncomingMessageByteLimit, connectTimeoutNs)
+	} else {
+		connection, err = listenerTcp.Connect(config.TcpBufferedReaderConfig, config.TcpClientConfig, connectTimeoutNs)
+	}
+	if err != nil {
+		return nil, newExitError(exitConnection, err)
+	}
+	messageConnection, err := typedConnection.New(connection, tools.JsonMarshalMessage, tools.JsonUnmarshalMessage)
+	if err != nil {
+		connection.Close()
+		return nil, newExitError(exitConnection, err)
+	}
+	return messageConnection, nil
+}
+
+func runAsync(config *config, timeout time.Duration, args []string) error {
+	flags := flag.NewFlagSet("async", flag.ContinueOnError)
+	topic := flags.String("topic", "", "topic of the message (required)")
+	payload := flags.String("payload", "", "payload of the message")
+	if err := flags.Parse(args); err != nil {
+		return newExitError(exitUsage, err)
+	}
+	if *topic == "" {
+		return newExitError(exitUsage, errors.New("-topic is required"))
+	}
+
+	connection, err := connect(config, timeout)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	if err := connection.Write(tools.NewAsync(*topic, *payload), int64(timeout)); err != nil {
+		return newExitError(exitWrite, err)
+	}
+	return nil
+}
+
+func runSync(config *config, timeout time.Duration, args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	topic := flags.String("topic", "", "topic of the message (required)")
+	payload := flags.String("payload", "", "payload of the message")
+	responses := flags.Uint("responses", 1, "number of responses to wait for")
+	if err := flags.Parse(args); err != nil {
+		return newExitError(exitUsage, err)
+	}
+	if *topic == "" {
+		return newExitError(exitUsage, errors.New("-topic is required"))
+	}
+	if *responses == 0 {
+		*responses = 1
+	}
+
+	connection, err := connect(config, timeout)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	syncToken := tools.GenerateRandomString(32, tools.ALPHA_NUMERIC)
+	if err := connection.Write(tools.NewSync(*topic, *payload, syncToken), int64(timeout)); err != nil {
+		return newExitError(exitWrite, err)
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	received := uint(0)
+	failed := false
+	for received < *responses {
+		readTimeoutNs := int64(0)
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return newExitError(exitTimeout, fmt.Errorf("received %d of %d responses", received, *responses))
+			}
+			readTimeoutNs = int64(remaining)
+		}
+		message, err := connection.Read(readTimeoutNs)
+		if err != nil {
+			if deadline.IsZero() || time.Until(deadline) > 0 {
+				return newExitError(exitConnection, err)
+			}
+			return newExitError(exitTimeout, fmt.Errorf("received %d of %d responses", received, *responses))
+		}
+		if !message.IsResponse() || message.GetSyncToken() != syncToken {
+			continue
+		}
+		received++
+		if message.GetTopic() == tools.TOPIC_FAILURE {
+			failed = true
+		}
+		printMessage(output, message)
+	}
+	if failed {
+		return newExitError(exitFailureResponse, errors.New("received failure response"))
+	}
+	return nil
+}
+
+func runSubscribe(config *config, timeout time.Duration, args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("subscribe", flag.ContinueOnError)
+	topicsFlag := flags.String("topics", "", "comma separated topics to print (all if empty)")
+	subscribe := flags.Bool("subscribe", false, "write a subscribe message with the topics first")
+	count := flags.Uint("count", 0, "number of messages to print before exiting (0 == no limit)")
+	if err := flags.Parse(args); err != nil {
+		return newExitError(exitUsage, err)
+	}
+	topics := map[string]bool{}
+	topicList := []string{}
+	for _, topic := range strings.Split(*topicsFlag, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics[topic] = true
+			topicList = append(topicList, topic)
+		}
+	}
+
+	connection, err := connect(config, timeout)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	if *subscribe {
+		payload, err := json.Marshal(topicList)
+		if err != nil {
+			return newExitError(exitUsage, err)
+		}
+		if err := connection.Write(tools.NewAsync(tools.TOPIC_SUBSCRIBE_ASYNC, string(payload)), int64(timeout)); err != nil {
+			return newExitError(exitWrite, err)
+		}
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	printed := uint(0)
+	for *count == 0 || printed < *count {
+		readTimeoutNs := int64(0)
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				break
+			}
+			readTimeoutNs = int64(remaining)
+		}
+		message, err := connection.Read(readTimeoutNs)
+		if err != nil {
+			if !deadline.IsZero() && time.Until(deadline) <= 0 {
+				break
+			}
+			return newExitError(exitConnection, err)
+		}
+		if len(topics) > 0 && !topics[message.GetTopic()] {
+			continue
+		}
+		printMessage(output, message)
+		printed++
+	}
+	if *count > 0 && printed < *count {
+		return newExitError(exitTimeout, fmt.Errorf("received %d of %d messages", printed, *count))
+	}
+	return nil
+}
+
+func runCommand(config *config, timeout time.Duration, args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("command", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the command handlers (required)")
+	command := flags.String("command", "", "command to execute (required)")
+	if err := flags.Parse(args); err != nil {
+		return newExitError(exitUsage, err)
+	}
+	if *name == "" || *command == "" {
+		return newExitError(exitUsage, errors.New("-name and -command are required"))
+	}
+
+	connection, err := connect(config, timeout)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	client, err := remoteCommands.NewClient(
+		&configs.CommandClient{
+			RequestTimeoutNs: int64(timeout),
+			WriteTimeoutNs:   int64(timeout),
+		},
+		connection,
+		nil,
+	)
+	if err != nil {
+		return newExitError(exitUsage, err)
+	}
+	go func() {
+		for {
+			message, err := connection.Read(0)
+			if err != nil {
+				return
+			}
+			if message.IsResponse() {
+				client.HandleResponse(message, connection)
+			}
+		}
+	}()
+
+	result, err := client.Execute(*name, *command, flags.Args())
+	if err != nil {
+		if errors.Is(err, remoteCommands.ErrNoResponse) {
+			return newExitError(exitTimeout, err)
+		}
+		return newExitError(exitFailureResponse, err)
+	}
+	fmt.Fprintln(output, result)
+	return nil
+}
+
+func printMessage(output io.Writer, message *tools.Message) {
+	fmt.Fprintln(output, string(message.JsonMarshal()))
+}
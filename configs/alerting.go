@@ -0,0 +1,45 @@
+package configs
+
+import "encoding/json"
+
+type AlertingEngine struct {
+	EvaluationIntervalNs int64        `json:"evaluationIntervalNs"` // *required*
+	ResendIntervalNs     int64        `json:"resendIntervalNs"`     // default: 0 == firing alerts are only sent once
+	NotifyTimeoutNs      int64        `json:"notifyTimeoutNs"`      // default: 0 == 30 seconds, as the mailer's sendTimeoutNs (notifications that take longer are counted as failed)
+	Rules                []*AlertRule `json:"rules"`                // *optional* (rules can be added at runtime)
+}
+
+func UnmarshalAlertingEngine(data string) *AlertingEngine {
+	var alertingEngine AlertingEngine
+	err := json.Unmarshal([]byte(data), &alertingEngine)
+	if err != nil {
+		return nil
+	}
+	return &alertingEngine
+}
+
+// the alert fires once the condition "<function>(source/metricsType/key) <operator> threshold" held for ForNs.
+type AlertRule struct {
+	Name        string `json:"name"`        // *required* (unique)
+	Severity    string `json:"severity"`    // *optional* (e.g. "warning", "critical")
+	Description string `json:"description"` // *optional*
+
+	Source      string `json:"source"`      // *required* (name of a source of the engine)
+	MetricsType string `json:"metricsType"` // *required*
+	Key         string `json:"key"`         // *required*
+
+	Function     string  `json:"function"`     // default: "value" ("value" or "rate" (per second))
+	RateWindowNs int64   `json:"rateWindowNs"` // default: 0 == 1 minute (only for "rate")
+	Operator     string  `json:"operator"`     // *required* (">", ">=", "<", "<=", "==", "!=")
+	Threshold    float64 `json:"threshold"`
+	ForNs        int64   `json:"forNs"` // default: 0 == fires on the first evaluation that meets the condition
+}
+
+func UnmarshalAlertRule(data string) *AlertRule {
+	var alertRule AlertRule
+	err := json.Unmarshal([]byte(data), &alertRule)
+	if err != nil {
+		return nil
+	}
+	return &alertRule
+}
@@ -0,0 +1,33 @@
+package configs
+
+import "encoding/json"
+
+type Console struct {
+	Prompt            string `json:"prompt"`            // default: "> " (prefixed with the current target and namespace)
+	HistoryPath       string `json:"historyPath"`       // *optional* (history is not persisted if empty)
+	MaxHistoryEntries int    `json:"maxHistoryEntries"` // default: <=0 == 1000
+}
+
+func UnmarshalConsole(data string) *Console {
+	var console Console
+	err := json.Unmarshal([]byte(data), &console)
+	if err != nil {
+		return nil
+	}
+	return &console
+}
+
+type ConsoleServer struct {
+	Network string `json:"network"` // *required* "tcp" or "unix"
+	Address string `json:"address"` // *required* e.g. "127.0.0.1:60000" or "/tmp/systemge.sock"
+	Token   string `json:"token"`   // *required* for "tcp", *optional* for "unix". clients must provide this token if not empty (sent in plaintext)
+}
+
+func UnmarshalConsoleServer(data string) *ConsoleServer {
+	var consoleServer ConsoleServer
+	err := json.Unmarshal([]byte(data), &consoleServer)
+	if err != nil {
+		return nil
+	}
+	return &consoleServer
+}
@@ -1,31 +1,25 @@
 package configs
 
-/*
-type DashboardServer struct {
-	HTTPServerConfig      *HTTPServer      `json:"httpServerConfig"`      // *required*
-	WebsocketServerConfig *WebsocketServer `json:"websocketServerConfig"` // *required*
-	SystemgeServerConfig  *Server          `json:"systemgeServerConfig"`  // *required*
-
-	InfoLoggerPath    string  `json:"infoLoggerPath"`    // *optional*
-	WarningLoggerPath string  `json:"warningLoggerPath"` // *optional*
-	ErrorLoggerPath   string  `json:"errorLoggerPath"`   // *optional*
-	MailerConfig      *Mailer `json:"mailerConfig"`      // *optional*
-
-	FrontendPassword            string `json:"frontendPassword"`            // default: "" (no password)
-	FrontendHeartbeatIntervalMs uint64 `json:"frontendHeartbeatIntervalMs"` // default: 0 = disabled
-
-	DashboardSystemgeCommands  bool `json:"dashboardSystemgeCommands"`  // default: false
-	DashboardHttpCommands      bool `json:"dashboardHttpCommands"`      // default: false
-	DashboardWebsocketCommands bool `json:"dashboardWebsocketCommands"` // default: false
+import "encoding/json"
 
-	UpdateIntervalMs     uint64 `json:"updateIntervalMs"`  // default: 0 == disabled
-	MaxMetricsTypes      int    `json:"maxMetricTypes"`    // default: 0 == no limit
-	MaxMetricsPerType    int    `json:"maxMetricsPerType"` // default: 0 == no limit
-	MaxEntriesPerMetrics int    `json:"maxMetricEntries"`  // default: 100 (must be > 0)
-
-	MaxCommandsPerClient int `json:"maxCommands"` // default: 0 == no limit
-
-	ResponseMessageCacheSize int `json:"responseMessageCacheSize"` // default: 0 == disabled
+type DashboardServer struct {
+	HTTPServerConfig        *HTTPServer        `json:"httpServerConfig"`        // *required*
+	WebsocketListenerConfig *WebsocketListener `json:"websocketListenerConfig"` // *required* (mounted onto the http server, HttpTcpListenerConfig is ignored)
+	SessionManagerConfig    *SessionManager    `json:"sessionManagerConfig"`    // *required*
+
+	FrontendPasswordHash     string `json:"frontendPasswordHash"`     // *required* unless AllowUnauthenticated is true (generate with tools.HashPassword)
+	AllowUnauthenticated     bool   `json:"allowUnauthenticated"`     // default: false (if true and FrontendPasswordHash is empty, anyone who can reach the http server can use the frontend)
+	SessionIdCookie          string `json:"sessionIdCookie"`          // default: "systemge_dashboard_session"
+	FrontendWriteTimeoutNs   int64  `json:"frontendWriteTimeoutNs"`   // default: 0 == no timeout
+	IncomingMessageByteLimit uint64 `json:"incomingMessageByteLimit"` // default: 0 == no limit
+
+	IntroductionTimeoutNs     int64 `json:"introductionTimeoutNs"`     // default: 0 == no timeout (components that do not introduce themselves in time are disconnected)
+	ComponentRequestTimeoutNs int64 `json:"componentRequestTimeoutNs"` // default: 0 == no timeout
+	ComponentWriteTimeoutNs   int64 `json:"componentWriteTimeoutNs"`   // default: 0 == no timeout
+	MaxComponentNameLength    int   `json:"maxComponentNameLength"`    // default: 0 == no limit
+
+	UpdateIntervalNs     int64 `json:"updateIntervalNs"`     // default: 0 == disabled (metrics are only fetched on request)
+	MaxEntriesPerMetrics int   `json:"maxEntriesPerMetrics"` // default: 100
 }
 
 func UnmarshalDashboardServer(data string) *DashboardServer {
@@ -38,11 +32,12 @@ func UnmarshalDashboardServer(data string) *DashboardServer {
 }
 
 type DashboardClient struct {
-	MaxServerNameLength         int            `json:"maxServerNameLength"`         // default: <=0 == no limit
-	TcpSystemgeConnectionConfig *TcpConnection `json:"tcpSystemgeConnectionConfig"` // *required*
-	TcpClientConfig             *TcpClient     `json:"tcpClientConfig"`             // *required*
-
-	MessageHandlerQueueSize int `json:"messageHandlerQueueSize"`
+	ConnectTimeoutNs      int64 `json:"connectTimeoutNs"`      // default: 0 == no timeout
+	IntroductionTimeoutNs int64 `json:"introductionTimeoutNs"` // default: 0 == no timeout (time to wait for the dashboard to accept the introduction)
+	WriteTimeoutNs        int64 `json:"writeTimeoutNs"`        // default: 0 == no timeout
+	ReconnectDelayNs      int64 `json:"reconnectDelayNs"`      // default: 0 == 1 second
+	MaxReconnectDelayNs   int64 `json:"maxReconnectDelayNs"`   // default: 0 == ReconnectDelayNs (the delay doubles after every failed attempt up to this value)
+	MaxReconnectAttempts  int   `json:"maxReconnectAttempts"`  // default: 0 == no limit (consecutive failed attempts before the client fails)
 }
 
 func UnmarshalDashboardClient(data string) *DashboardClient {
@@ -53,4 +48,3 @@ func UnmarshalDashboardClient(data string) *DashboardClient {
 	}
 	return &dashboard
 }
-*/
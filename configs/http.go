@@ -0,0 +1,82 @@
+package configs
+
+import "encoding/json"
+
+type Cors struct {
+	AllowedOrigins   []string `json:"allowedOrigins"`   // default: empty == "*"
+	AllowedMethods   []string `json:"allowedMethods"`   // default: empty == GET, POST, PUT, PATCH, DELETE, OPTIONS
+	AllowedHeaders   []string `json:"allowedHeaders"`   // default: empty == the headers requested by the preflight request
+	ExposedHeaders   []string `json:"exposedHeaders"`   // *optional*
+	AllowCredentials bool     `json:"allowCredentials"` // default: false
+	MaxAgeSeconds    int      `json:"maxAgeSeconds"`    // default: 0 == browser default
+}
+
+func UnmarshalCors(data string) *Cors {
+	var cors Cors
+	err := json.Unmarshal([]byte(data), &cors)
+	if err != nil {
+		return nil
+	}
+	return &cors
+}
+
+type SecurityHeaders struct {
+	HstsMaxAgeSeconds     int    `json:"hstsMaxAgeSeconds"`     // default: 0 == no Strict-Transport-Security header
+	FrameOptions          string `json:"frameOptions"`          // default: "DENY"
+	ReferrerPolicy        string `json:"referrerPolicy"`        // default: "no-referrer"
+	ContentSecurityPolicy string `json:"contentSecurityPolicy"` // *optional*
+}
+
+func UnmarshalSecurityHeaders(data string) *SecurityHeaders {
+	var securityHeaders SecurityHeaders
+	err := json.Unmarshal([]byte(data), &securityHeaders)
+	if err != nil {
+		return nil
+	}
+	return &securityHeaders
+}
+
+type CommandApi struct {
+	ApiKeys         map[string]string `json:"apiKeys"`         // *optional* identity -> api key. keys are accepted through the "X-Api-Key" header or as "Authorization: Bearer <key>"
+	SessionIdCookie string            `json:"sessionIdCookie"` // *optional* name of the cookie that carries the id of an accepted session (requires a session manager)
+	AuditLogger     *Logger           `json:"auditLogger"`     // *optional* (no audit log if nil)
+	AuditLogArgs    bool              `json:"auditLogArgs"`    // default: false (only the number of args is logged, since args may contain passwords or tokens)
+	MaxBodyBytes    int64             `json:"maxBodyBytes"`    // default: 0 == 1 MB
+}
+
+func UnmarshalCommandApi(data string) *CommandApi {
+	var commandApi CommandApi
+	err := json.Unmarshal([]byte(data), &commandApi)
+	if err != nil {
+		return nil
+	}
+	return &commandApi
+}
+
+type MetricsExporter struct {
+	Namespace string            `json:"namespace"` // default: "systemge" (prefix of all metric names)
+	ApiKeys   map[string]string `json:"apiKeys"`   // *optional* identity -> api key (no authentication if empty). keys are accepted through the "X-Api-Key" header or as "Authorization: Bearer <key>"
+}
+
+func UnmarshalMetricsExporter(data string) *MetricsExporter {
+	var metricsExporter MetricsExporter
+	err := json.Unmarshal([]byte(data), &metricsExporter)
+	if err != nil {
+		return nil
+	}
+	return &metricsExporter
+}
+
+type HealthRegistry struct {
+	CheckTimeoutNs  int64 `json:"checkTimeoutNs"`  // default: 0 == 5 seconds (checks that take longer are failing. AddCheck may set a timeout per check)
+	CacheDurationNs int64 `json:"cacheDurationNs"` // default: 0 == checks run on every request
+}
+
+func UnmarshalHealthRegistry(data string) *HealthRegistry {
+	var healthRegistry HealthRegistry
+	err := json.Unmarshal([]byte(data), &healthRegistry)
+	if err != nil {
+		return nil
+	}
+	return &healthRegistry
+}
@@ -23,3 +23,14 @@ type PublishSubscribeServer struct {
 	PropagateTimeoutNs int64
 	Topics             []string
 }
+
+type CommandServer struct {
+	Permissions    map[string][]string // identity -> allowed "name" or "name:command" entries. "*" allows everything. no entry for an identity == no permissions
+	WriteTimeoutNs int64
+}
+
+type CommandClient struct {
+	RequestTimeoutNs int64  // default: 0 == no timeout
+	WriteTimeoutNs   int64  // default: 0 == no timeout
+	SyncTokenLength  uint32 // default: 0 == 32
+}
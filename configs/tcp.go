@@ -28,12 +28,19 @@ func UnmarshalHTTPServer(data string) *HTTPServer {
 }
 
 type WebsocketListener struct {
-	HttpTcpListenerConfig *TcpListener `json:"tcpServerConfig"` // *required*
+	HttpTcpListenerConfig *TcpListener `json:"tcpServerConfig"` // *required* (unless the listener is mounted onto an existing http server)
 	Pattern               string       `json:"pattern"`         // *required* (the pattern that the underlying http server will listen to) (e.g. "/ws")
 
 	Upgrader *websocket.Upgrader `json:"upgrader"` // *required*
 
 	UpgradeRequestTimeoutNs int64 `json:"upgradeRequestTimeoutNs"` // default: 0 (no timeout)
+
+	TrustedProxies []string `json:"trustedProxies"` // *optional* (ips/cidr ranges of proxies whose "Forwarded"/"X-Forwarded-For" headers are trusted to resolve the client address)
+
+	MetadataHeaders     []string `json:"metadataHeaders"`     // *optional* (request headers that remain readable from the connection)
+	MetadataCookies     []string `json:"metadataCookies"`     // *optional* (request cookies that remain readable from the connection)
+	MetadataQueryParams []string `json:"metadataQueryParams"` // *optional* (request query params that remain readable from the connection)
+	SessionIdCookie     string   `json:"sessionIdCookie"`     // *optional* (name of the cookie that carries the session id)
 }
 
 func UnmarshalWebsocketListener(data string) *WebsocketListener {
@@ -51,6 +58,10 @@ type TcpListener struct {
 	Domain      string `json:"domain"`      // *optional* (e.g. "example.com")
 	TlsCertPath string `json:"tlsCertPath"` // *optional* cert path!
 	TlsKeyPath  string `json:"tlsKeyPath"`  // *optional*
+
+	ProxyProtocol        bool     `json:"proxyProtocol"`        // default: false (if true, every connection must start with a PROXY protocol v1/v2 header)
+	ProxyHeaderTimeoutNs int64    `json:"proxyHeaderTimeoutNs"` // default: 0 == 5 seconds (connections that do not send their header in time are closed)
+	TrustedProxies       []string `json:"trustedProxies"`       // *optional* (ips/cidr ranges allowed to send PROXY headers. empty == all)
 }
 
 func UnmarshalTcpListener(data string) *TcpListener {
@@ -5,11 +5,23 @@ import (
 )
 
 type Mailer struct {
-	SmtpHost       string   `json:"smtpHost"`       // *required*
-	SmtpPort       uint16   `json:"smtpPort"`       // *required*
-	SenderEmail    string   `json:"senderEmail"`    // *required*
-	SenderPassword string   `json:"senderPassword"` // *required*
-	Recipients     []string `json:"recipients"`
+	SmtpHost           string   `json:"smtpHost"`           // *required*
+	SmtpPort           uint16   `json:"smtpPort"`           // *required*
+	SenderEmail        string   `json:"senderEmail"`        // *required*
+	SenderPassword     string   `json:"senderPassword"`     // *optional* (plaintext. prefer SenderPasswordEnv or SenderPasswordFile. no authentication if no password is provided)
+	SenderPasswordEnv  string   `json:"senderPasswordEnv"`  // *optional* (name of an environment variable containing the password)
+	SenderPasswordFile string   `json:"senderPasswordFile"` // *optional* (path of a file containing the password. surrounding whitespace is trimmed)
+	Username           string   `json:"username"`           // default: SenderEmail
+	Recipients         []string `json:"recipients"`
+
+	TlsMode            string `json:"tlsMode"`            // default: "" == STARTTLS if the server supports it ("starttls" == STARTTLS required, "tls" == implicit TLS (e.g. port 465), "none")
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"` // default: false
+	SendTimeoutNs      int64  `json:"sendTimeoutNs"`      // default: 0 == 30 seconds
+
+	Queue             *Queue `json:"queue"`             // *optional* (queue of SendAsync. default: no limit)
+	MaxRetries        int    `json:"maxRetries"`        // default: 0 == no retries (only for SendAsync. only transient errors, i.e. network errors and 4xx replies, are retried)
+	RetryBackoffNs    int64  `json:"retryBackoffNs"`    // default: 0 == 1 second (doubled after every failed retry)
+	MaxRetryBackoffNs int64  `json:"maxRetryBackoffNs"` // default: 0 == no limit
 }
 
 func UnmarshalMailer(data string) *Mailer {
@@ -21,6 +33,15 @@ func UnmarshalMailer(data string) *Mailer {
 	return &mailer
 }
 
+// redacts SenderPassword so configs can be logged or sent to the dashboard.
+func (mailer Mailer) MarshalJSON() ([]byte, error) {
+	type mailerConfig Mailer
+	if mailer.SenderPassword != "" {
+		mailer.SenderPassword = "<redacted>"
+	}
+	return json.Marshal(mailerConfig(mailer))
+}
+
 type TokenBucketRateLimiter struct {
 	InitialBucketSize uint64 `json:"initialBucketSize"` // default: 0
 	MaxBucketSize     uint64 `json:"maxBucketSize"`     // default: 0
@@ -41,6 +62,8 @@ type IpRateLimiter struct {
 	MaxAttempts         int   `json:"maxAttempts"`         // default: 1
 	AttemptTimeWindowNs int64 `json:"attemptTimeWindowNs"` // default: 1
 	CleanupIntervalNs   int64 `json:"cleanupIntervalNs"`   // default: 1000ms
+	Ipv4PrefixLength    int   `json:"ipv4PrefixLength"`    // default: 0 == attempts are counted per address (e.g. 24 counts attempts per /24)
+	Ipv6PrefixLength    int   `json:"ipv6PrefixLength"`    // default: 0 == attempts are counted per address (e.g. 64 counts attempts per /64)
 }
 
 func UnmarshalIpRateLimiter(data string) *IpRateLimiter {
@@ -52,6 +75,38 @@ func UnmarshalIpRateLimiter(data string) *IpRateLimiter {
 	return &ipRateLimiterConfig
 }
 
+type AccessControlList struct {
+	PersistencePath string `json:"persistencePath"` // *optional* (the loadFile and saveFile commands are not available if empty)
+}
+
+func UnmarshalAccessControlList(data string) *AccessControlList {
+	var accessControlListConfig AccessControlList
+	err := json.Unmarshal([]byte(data), &accessControlListConfig)
+	if err != nil {
+		return nil
+	}
+	return &accessControlListConfig
+}
+
+type BanManager struct {
+	FailureThreshold      int     `json:"failureThreshold"`      // default: 1 (number of failures within FailureTimeWindowNs that result in a ban)
+	FailureTimeWindowNs   int64   `json:"failureTimeWindowNs"`   // default: 0 == failures never expire
+	InitialBanDurationNs  int64   `json:"initialBanDurationNs"`  // default: 0 == bans never expire
+	BanDurationMultiplier float64 `json:"banDurationMultiplier"` // default: 2 (ban duration is multiplied by this for each repeated offence)
+	MaxBanDurationNs      int64   `json:"maxBanDurationNs"`      // default: 0 == no limit
+	OffenceLifetimeNs     int64   `json:"offenceLifetimeNs"`     // default: 0 == offences are never forgotten (time after the last ban ended until the offence count is reset)
+	PersistencePath       string  `json:"persistencePath"`       // *optional* (bans are not persisted if empty)
+}
+
+func UnmarshalBanManager(data string) *BanManager {
+	var banManagerConfig BanManager
+	err := json.Unmarshal([]byte(data), &banManagerConfig)
+	if err != nil {
+		return nil
+	}
+	return &banManagerConfig
+}
+
 type SessionManager struct {
 	SessionLifetimeNs      int64  `json:"sessionLifetimeNs"`      // default: 0 == no expiration
 	SessionIdLength        uint32 `json:"sessionIdLength"`        // default: 32
@@ -165,3 +220,41 @@ func UnmarshalTcpBufferedReader(data string) *TcpBufferedReader {
 	}
 	return &tcpBufferedReaderConfig
 }
+
+type MetricsRecorder struct {
+	IntervalNs          int64  `json:"intervalNs"`          // *required* (time between two samples of the registered sources)
+	MaxEntriesPerSeries int    `json:"maxEntriesPerSeries"` // default: 1000 (older entries are overwritten)
+	MaxTopics           int    `json:"maxTopics"`           // default: 0 == no limit (messages of further topics are counted as "other")
+	DumpDirectory       string `json:"dumpDirectory"`       // default: "." (directory the dump command writes its files to)
+}
+
+func UnmarshalMetricsRecorder(data string) *MetricsRecorder {
+	var metricsRecorder MetricsRecorder
+	err := json.Unmarshal([]byte(data), &metricsRecorder)
+	if err != nil {
+		return nil
+	}
+	return &metricsRecorder
+}
+
+type Logger struct {
+	Path      string `json:"path"`      // default: "" == standard error (loggers with the same path share one file)
+	Level     string `json:"level"`     // default: "info" ("debug", "info", "warn" or "error")
+	Format    string `json:"format"`    // default: "text" ("text" or "json")
+	AddSource bool   `json:"addSource"` // default: false (adds the file and line of the log call)
+	QueueSize int    `json:"queueSize"` // default: 1000 (entries that are not written yet. further entries are dropped)
+
+	MaxSizeBytes       int64 `json:"maxSizeBytes"`       // default: 0 == no size-based rotation
+	RotationIntervalNs int64 `json:"rotationIntervalNs"` // default: 0 == no time-based rotation
+	MaxBackups         int   `json:"maxBackups"`         // default: 0 == rotated files are kept
+	MaxBackupAgeNs     int64 `json:"maxBackupAgeNs"`     // default: 0 == rotated files are kept
+}
+
+func UnmarshalLogger(data string) *Logger {
+	var logger Logger
+	err := json.Unmarshal([]byte(data), &logger)
+	if err != nil {
+		return nil
+	}
+	return &logger
+}
@@ -35,15 +35,22 @@ type ChannelConnection[T any] struct {
 
 	MessagesSent     atomic.Uint64
 	MessagesReceived atomic.Uint64
+
+	metricsRegistry *tools.MetricsRegistry
+	readLatency     *tools.Histogram // time spent in Read, including waiting for data
+	writeLatency    *tools.Histogram // time spent in Write, including waiting for the receiver
 }
 
 func New[T any](receiveChannel chan T, sendChannel chan T) *ChannelConnection[T] {
 	connection := &ChannelConnection[T]{
-		closeChannel:   make(chan struct{}),
-		instanceId:     tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
-		receiveChannel: receiveChannel,
-		sendChannel:    sendChannel,
+		closeChannel:    make(chan struct{}),
+		instanceId:      tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
+		receiveChannel:  receiveChannel,
+		sendChannel:     sendChannel,
+		metricsRegistry: tools.NewMetricsRegistry(),
 	}
+	connection.readLatency = connection.metricsRegistry.DurationHistogram("readLatencyNs", tools.DefaultLatencyBounds)
+	connection.writeLatency = connection.metricsRegistry.DurationHistogram("writeLatencyNs", tools.DefaultLatencyBounds)
 
 	return connection
 }
@@ -10,6 +10,7 @@ func (connection *ChannelConnection[T]) GetMetrics() tools.MetricsTypes {
 			"messagesSent":     connection.MessagesSent.Swap(0),
 			"messagesReceived": connection.MessagesReceived.Swap(0),
 		}),
+		"channelClient_latency": connection.metricsRegistry.GetMetrics(),
 	}
 }
 
@@ -19,5 +20,10 @@ func (connection *ChannelConnection[T]) CheckMetrics() tools.MetricsTypes {
 			"messagesSent":     connection.MessagesSent.Load(),
 			"messagesReceived": connection.MessagesReceived.Load(),
 		}),
+		"channelClient_latency": connection.metricsRegistry.GetMetrics(),
 	}
 }
+
+func (connection *ChannelConnection[T]) GetMetricsRegistry() *tools.MetricsRegistry {
+	return connection.metricsRegistry
+}
@@ -2,6 +2,7 @@ package connectionChannel
 
 import (
 	"errors"
+	"time"
 
 	"github.com/neutralusername/systemge/tools"
 )
@@ -10,6 +11,7 @@ func (connection *ChannelConnection[T]) Read(timeoutNs int64) (T, error) {
 	connection.readMutex.Lock()
 	defer connection.readMutex.Unlock()
 
+	start := time.Now()
 	connection.readTimeout = tools.NewTimeout(
 		timeoutNs,
 		nil,
@@ -19,6 +21,7 @@ func (connection *ChannelConnection[T]) Read(timeoutNs int64) (T, error) {
 	for {
 		select {
 		case data := <-connection.receiveChannel:
+			connection.readLatency.ObserveSince(start)
 			connection.MessagesReceived.Add(1)
 			connection.readTimeout.Trigger()
 			connection.readTimeout = nil
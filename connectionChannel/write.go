@@ -2,6 +2,7 @@ package connectionChannel
 
 import (
 	"errors"
+	"time"
 
 	"github.com/neutralusername/systemge/tools"
 )
@@ -10,6 +11,7 @@ func (connection *ChannelConnection[T]) Write(data T, timeoutNs int64) error {
 	connection.writeMutex.Lock()
 	defer connection.writeMutex.Unlock()
 
+	start := time.Now()
 	connection.writeTimeout = tools.NewTimeout(
 		timeoutNs,
 		nil,
@@ -19,6 +21,7 @@ func (connection *ChannelConnection[T]) Write(data T, timeoutNs int64) error {
 	for {
 		select {
 		case connection.sendChannel <- data:
+			connection.writeLatency.ObserveSince(start)
 			connection.MessagesSent.Add(1)
 			connection.writeTimeout.Trigger()
 			connection.writeTimeout = nil
@@ -33,6 +33,10 @@ type TcpConnection struct {
 
 	MessagesSent     atomic.Uint64
 	MessagesReceived atomic.Uint64
+
+	metricsRegistry *tools.MetricsRegistry
+	readLatency     *tools.Histogram // time spent in Read, including waiting for data
+	writeLatency    *tools.Histogram
 }
 
 func New(config *configs.TcpBufferedReader, netConn net.Conn) (*TcpConnection, error) {
@@ -49,7 +53,10 @@ func New(config *configs.TcpBufferedReader, netConn net.Conn) (*TcpConnection, e
 		tcpBufferedReader: tools.NewTcpBufferedReader(netConn, config),
 		closeChannel:      make(chan struct{}),
 		instanceId:        tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
+		metricsRegistry:   tools.NewMetricsRegistry(),
 	}
+	connection.readLatency = connection.metricsRegistry.DurationHistogram("readLatencyNs", tools.DefaultLatencyBounds)
+	connection.writeLatency = connection.metricsRegistry.DurationHistogram("writeLatencyNs", tools.DefaultLatencyBounds)
 
 	return connection, nil
 }
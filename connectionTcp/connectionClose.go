@@ -12,9 +12,10 @@ func (connection *TcpConnection) Close() error {
 		return errors.New("connection already closed")
 	}
 
+	// the close channel is closed first so readers stop before the underlying connection fails their reads
 	connection.closed = true
-	connection.netConn.Close()
 	close(connection.closeChannel)
+	connection.netConn.Close()
 
 	return nil
 }
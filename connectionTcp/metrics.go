@@ -31,6 +31,7 @@ func (connection *TcpConnection) CheckMetrics() tools.MetricsTypes {
 			"rejected_messages":         connection.CheckRejectedMessages(), */
 		},
 	))
+	metricsTypes.AddMetrics("tcpSystemgeConnection_latency", connection.metricsRegistry.GetMetrics())
 	return metricsTypes
 }
 
@@ -63,5 +64,10 @@ func (connection *TcpConnection) GetMetrics() tools.MetricsTypes {
 			"rejected_messages":         connection.GetRejectedMessages(), */
 		},
 	))
+	metricsTypes.AddMetrics("tcpSystemgeConnection_latency", connection.metricsRegistry.GetMetrics())
 	return metricsTypes
 }
+
+func (connection *TcpConnection) GetMetricsRegistry() *tools.MetricsRegistry {
+	return connection.metricsRegistry
+}
@@ -11,6 +11,7 @@ func (client *TcpConnection) Read(timeoutNs int64) ([]byte, error) {
 	defer client.readMutex.Unlock()
 
 	client.SetReadDeadline(timeoutNs)
+	start := time.Now()
 	data, newBytesRead, err := client.tcpBufferedReader.Read()
 	if err != nil {
 		if helpers.IsNetConnClosedErr(err) {
@@ -18,6 +19,7 @@ func (client *TcpConnection) Read(timeoutNs int64) ([]byte, error) {
 		}
 		return nil, err
 	}
+	client.readLatency.ObserveSince(start)
 	client.BytesReceived.Add(uint64(newBytesRead))
 	client.MessagesReceived.Add(1)
 	return data, nil
@@ -30,6 +30,7 @@ func (client *TcpConnection) Write(data []byte, timeoutNs int64) error {
 	defer client.writeMutex.Unlock()
 
 	client.SetWriteDeadline(timeoutNs)
+	start := time.Now()
 	_, err := client.netConn.Write(append(data, tools.ENDOFMESSAGE))
 	if err != nil {
 		if helpers.IsNetConnClosedErr(err) {
@@ -37,6 +38,7 @@ func (client *TcpConnection) Write(data []byte, timeoutNs int64) error {
 		}
 		return err
 	}
+	client.writeLatency.ObserveSince(start)
 	client.BytesSent.Add(uint64(len(data)))
 	client.MessagesSent.Add(1)
 	return nil
@@ -14,9 +14,10 @@ func (connection *WebsocketConnection) Close() error {
 		return errors.New("websocketClient already closed")
 	}
 
+	// the close channel is closed first so readers stop before the underlying connection fails their reads
 	connection.closed = true
-	connection.websocketConn.Close()
 	close(connection.closeChannel)
+	connection.websocketConn.Close()
 
 	return nil
 }
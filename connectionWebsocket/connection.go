@@ -16,6 +16,9 @@ type WebsocketConnection struct {
 	instanceId string
 
 	websocketConn *websocket.Conn
+	address       string
+
+	requestMetadata *RequestMetadata
 
 	closed       bool
 	closedMutex  sync.Mutex
@@ -31,6 +34,10 @@ type WebsocketConnection struct {
 
 	MessagesSent     atomic.Uint64
 	MessagesReceived atomic.Uint64
+
+	metricsRegistry *tools.MetricsRegistry
+	readLatency     *tools.Histogram // time spent in Read, including waiting for data
+	writeLatency    *tools.Histogram
 }
 
 func New(websocketConn *websocket.Conn, incomingMessageByteLimit uint64) (*WebsocketConnection, error) {
@@ -41,10 +48,13 @@ func New(websocketConn *websocket.Conn, incomingMessageByteLimit uint64) (*Webso
 	websocketConn.SetReadLimit(int64(incomingMessageByteLimit))
 
 	connection := &WebsocketConnection{
-		websocketConn: websocketConn,
-		closeChannel:  make(chan struct{}),
-		instanceId:    tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
+		websocketConn:   websocketConn,
+		closeChannel:    make(chan struct{}),
+		instanceId:      tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
+		metricsRegistry: tools.NewMetricsRegistry(),
 	}
+	connection.readLatency = connection.metricsRegistry.DurationHistogram("readLatencyNs", tools.DefaultLatencyBounds)
+	connection.writeLatency = connection.metricsRegistry.DurationHistogram("writeLatencyNs", tools.DefaultLatencyBounds)
 
 	return connection, nil
 }
@@ -71,5 +81,14 @@ func (connection *WebsocketConnection) GetCloseChannel() <-chan struct{} {
 }
 
 func (connection *WebsocketConnection) GetAddress() string {
+	if connection.address != "" {
+		return connection.address
+	}
 	return connection.websocketConn.RemoteAddr().String()
 }
+
+// overrides the address returned by GetAddress (e.g. with the client address resolved from trusted proxy headers).
+// should be called before the connection is handed to other components.
+func (connection *WebsocketConnection) SetAddress(address string) {
+	connection.address = address
+}
@@ -10,6 +10,7 @@ func (connection *WebsocketConnection) GetMetrics() tools.MetricsTypes {
 			"messagesSent":     connection.MessagesSent.Swap(0),
 			"messagesReceived": connection.MessagesReceived.Swap(0),
 		}),
+		"websocketClient_latency": connection.metricsRegistry.GetMetrics(),
 	}
 }
 
@@ -21,5 +22,10 @@ func (connection *WebsocketConnection) CheckMetrics() tools.MetricsTypes {
 			"messagesSent":     connection.MessagesSent.Load(),
 			"messagesReceived": connection.MessagesReceived.Load(),
 		}),
+		"websocketClient_latency": connection.metricsRegistry.GetMetrics(),
 	}
 }
+
+func (connection *WebsocketConnection) GetMetricsRegistry() *tools.MetricsRegistry {
+	return connection.metricsRegistry
+}
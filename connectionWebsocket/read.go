@@ -11,14 +11,15 @@ func (connection *WebsocketConnection) Read(timeoutNs int64) ([]byte, error) {
 	defer connection.readMutex.Unlock()
 
 	connection.SetReadDeadline(timeoutNs)
+	start := time.Now()
 	_, data, err := connection.websocketConn.ReadMessage()
 	if err != nil {
-
 		if helpers.IsWebsocketConnClosedErr(err) {
 			connection.Close()
 		}
 		return nil, err
 	}
+	connection.readLatency.ObserveSince(start)
 	connection.BytesReceived.Add(uint64(len(data)))
 	connection.MessagesReceived.Add(1)
 	return data, nil
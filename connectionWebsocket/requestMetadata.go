@@ -0,0 +1,27 @@
+package connectionWebsocket
+
+// selected data of the http request that was upgraded to this connection.
+type RequestMetadata struct {
+	Headers     map[string]string `json:"headers"`
+	Cookies     map[string]string `json:"cookies"`
+	QueryParams map[string]string `json:"queryParams"`
+	SessionId   string            `json:"sessionId"`
+}
+
+func NewRequestMetadata() *RequestMetadata {
+	return &RequestMetadata{
+		Headers:     make(map[string]string),
+		Cookies:     make(map[string]string),
+		QueryParams: make(map[string]string),
+	}
+}
+
+// returns nil if the connection was not created from an upgraded http request.
+func (connection *WebsocketConnection) GetRequestMetadata() *RequestMetadata {
+	return connection.requestMetadata
+}
+
+// should be called before the connection is handed to other components.
+func (connection *WebsocketConnection) SetRequestMetadata(requestMetadata *RequestMetadata) {
+	connection.requestMetadata = requestMetadata
+}
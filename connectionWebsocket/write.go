@@ -12,6 +12,7 @@ func (connection *WebsocketConnection) Write(data []byte, timeoutNs int64) error
 	defer connection.writeMutex.Unlock()
 
 	connection.SetWriteDeadline(timeoutNs)
+	start := time.Now()
 	err := connection.websocketConn.WriteMessage(websocket.TextMessage, data)
 	if err != nil {
 		if helpers.IsWebsocketConnClosedErr(err) {
@@ -19,6 +20,7 @@ func (connection *WebsocketConnection) Write(data []byte, timeoutNs int64) error
 		}
 		return err
 	}
+	connection.writeLatency.ObserveSince(start)
 	connection.BytesSent.Add(uint64(len(data)))
 	connection.MessagesSent.Add(1)
 	return nil
@@ -0,0 +1,465 @@
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/tools"
+)
+
+const remoteTimeoutNs = 30_000_000_000
+
+// interactive terminal for executing commands of one or more targets.
+//
+// commands of nested components are prefixed with the component's name (e.g. "listener_getStatus").
+// these prefixes act as namespaces: after "cd listener", "getStatus" executes "listener_getStatus".
+// commands of a target other than the current one can be executed as "<target>:<command>".
+//
+// builtin commands:
+//   - help [command]                           lists all commands or describes one
+//   - targets                                  lists all targets
+//   - use <target>                             switches the current target
+//   - cd <namespace> | cd .. | cd /            enters or leaves a namespace
+//   - ls                                       lists the commands and namespaces of the current namespace
+//   - attach <name> <network> <address> [token] adds the commands of a remote process (see Server) as target (not recorded in the history)
+//   - detach <name>                            removes a remote target
+//   - history                                  lists the history
+//   - exit                                     exits the console
+//
+// results that are json (e.g. metrics) are pretty printed.
+type Console struct {
+	config  *configs.Console
+	history *History
+	output  io.Writer
+	editor  *lineEditor
+
+	mutex         sync.Mutex
+	targets       map[string]Target
+	currentTarget string
+	namespace     []string
+}
+
+func New(config *configs.Console) (*Console, error) {
+	if config == nil {
+		config = &configs.Console{}
+	}
+	if config.Prompt == "" {
+		config.Prompt = "> "
+	}
+	history, err := NewHistory(config.HistoryPath, config.MaxHistoryEntries)
+	if err != nil {
+		return nil, err
+	}
+	console := &Console{
+		config:  config,
+		history: history,
+		output:  os.Stdout,
+		targets: make(map[string]Target),
+	}
+	console.editor = newLineEditor(int(os.Stdin.Fd()), os.Stdin, os.Stdout, history, console.complete)
+	return console, nil
+}
+
+// the first target added becomes the current target.
+func (console *Console) AddTarget(name string, target Target) error {
+	if name == "" || strings.ContainsAny(name, ": ") {
+		return errors.New("invalid target name")
+	}
+	console.mutex.Lock()
+	defer console.mutex.Unlock()
+	if _, ok := console.targets[name]; ok {
+		return errors.New("target already exists")
+	}
+	console.targets[name] = target
+	if console.currentTarget == "" {
+		console.currentTarget = name
+	}
+	return nil
+}
+
+func (console *Console) RemoveTarget(name string) error {
+	console.mutex.Lock()
+	defer console.mutex.Unlock()
+	if _, ok := console.targets[name]; !ok {
+		return errors.New("target does not exist")
+	}
+	delete(console.targets, name)
+	if console.currentTarget == name {
+		console.currentTarget = ""
+		console.namespace = nil
+		for _, targetName := range console.getTargetNames() {
+			console.currentTarget = targetName
+			break
+		}
+	}
+	return nil
+}
+
+// reads and executes commands until "exit" is entered or input is closed.
+func (console *Console) Run() error {
+	console.println("Welcome to the Systemge console")
+	console.println("Type 'help' to list all commands, 'exit' to exit")
+	for {
+		line, err := console.editor.readLine(console.getPrompt())
+		if err == ErrInterrupted {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		segments := strings.Fields(line)
+		if len(segments) == 0 {
+			continue
+		}
+		if segments[0] != "attach" { // may contain a token
+			console.history.Add(line)
+		}
+		if segments[0] == "exit" {
+			return nil
+		}
+		result, err := console.Execute(segments[0], segments[1:])
+		if err != nil {
+			console.println("Error: " + err.Error())
+			continue
+		}
+		if result != "" {
+			console.println(prettyPrint(result))
+		}
+	}
+}
+
+// executes a builtin command or a command of a target.
+func (console *Console) Execute(command string, args []string) (string, error) {
+	switch command {
+	case "help":
+		return console.help(args)
+	case "targets":
+		console.mutex.Lock()
+		defer console.mutex.Unlock()
+		return strings.Join(console.getTargetNames(), "\n"), nil
+	case "use":
+		return console.use(args)
+	case "cd":
+		return console.cd(args)
+	case "ls":
+		return console.ls()
+	case "attach":
+		return console.attach(args)
+	case "detach":
+		return console.detach(args)
+	case "history":
+		return strings.Join(console.history.GetEntries(), "\n"), nil
+	}
+
+	target, name, err := console.resolve(command)
+	if err != nil {
+		return "", err
+	}
+	return target.Execute(name, args)
+}
+
+// returns the target and the full name of a command relative to the current target and namespace.
+func (console *Console) resolve(command string) (Target, string, error) {
+	console.mutex.Lock()
+	defer console.mutex.Unlock()
+
+	if targetName, name, ok := strings.Cut(command, ":"); ok {
+		target, ok := console.targets[targetName]
+		if !ok {
+			return nil, "", errors.New("target does not exist")
+		}
+		return target, name, nil
+	}
+	target, ok := console.targets[console.currentTarget]
+	if !ok {
+		return nil, "", errors.New("no target selected")
+	}
+	name := console.getPrefix() + command
+	for _, commandName := range target.GetCommandNames() {
+		if commandName == name {
+			return target, name, nil
+		}
+	}
+	return target, command, nil
+}
+
+func (console *Console) help(args []string) (string, error) {
+	target, name, err := console.resolve("")
+	if len(args) > 0 {
+		target, name, err = console.resolve(args[0])
+	}
+	if err != nil {
+		return "", err
+	}
+	commandHandlers := tools.CommandHandlers{}
+	for _, commandName := range target.GetCommandNames() {
+		commandHandlers[commandName] = nil
+	}
+	if len(args) == 0 {
+		name = ""
+	}
+	return target.GetCommandDescriptions().Help(commandHandlers, name)
+}
+
+func (console *Console) use(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: use <target>")
+	}
+	console.mutex.Lock()
+	defer console.mutex.Unlock()
+	if _, ok := console.targets[args[0]]; !ok {
+		return "", errors.New("target does not exist")
+	}
+	console.currentTarget = args[0]
+	console.namespace = nil
+	return "", nil
+}
+
+func (console *Console) cd(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: cd <namespace> | cd .. | cd /")
+	}
+	console.mutex.Lock()
+	defer console.mutex.Unlock()
+	switch args[0] {
+	case "/":
+		console.namespace = nil
+	case "..":
+		if len(console.namespace) > 0 {
+			console.namespace = console.namespace[:len(console.namespace)-1]
+		}
+	default:
+		namespace := strings.TrimSuffix(args[0], "_")
+		_, namespaces := console.getEntries()
+		if !contains(namespaces, namespace) {
+			return "", errors.New("namespace does not exist")
+		}
+		console.namespace = append(console.namespace, namespace)
+	}
+	return "", nil
+}
+
+func (console *Console) ls() (string, error) {
+	console.mutex.Lock()
+	defer console.mutex.Unlock()
+	commands, namespaces := console.getEntries()
+	for i, namespace := range namespaces {
+		namespaces[i] = namespace + "_"
+	}
+	return strings.Join(append(namespaces, commands...), "\n"), nil
+}
+
+func (console *Console) attach(args []string) (string, error) {
+	if len(args) < 3 || len(args) > 4 {
+		return "", errors.New("usage: attach <name> <network> <address> [token]")
+	}
+	token := ""
+	if len(args) == 4 {
+		token = args[3]
+	}
+	target, err := Attach(args[1], args[2], token, remoteTimeoutNs)
+	if err != nil {
+		return "", err
+	}
+	if err := console.AddTarget(args[0], target); err != nil {
+		target.Close()
+		return "", err
+	}
+	return "attached", nil
+}
+
+func (console *Console) detach(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: detach <name>")
+	}
+	console.mutex.Lock()
+	target, ok := console.targets[args[0]].(*RemoteTarget)
+	console.mutex.Unlock()
+	if !ok {
+		return "", errors.New("remote target does not exist")
+	}
+	if err := console.RemoveTarget(args[0]); err != nil {
+		return "", err
+	}
+	target.Close()
+	return "detached", nil
+}
+
+// returns the candidates for the last word of line.
+func (console *Console) complete(line string) []string {
+	words := strings.Fields(line)
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	prefix := words[len(words)-1]
+
+	console.mutex.Lock()
+	candidates := []string{}
+	if len(words) == 1 {
+		if targetName, _, ok := strings.Cut(prefix, ":"); ok {
+			if target, ok := console.targets[targetName]; ok {
+				for _, commandName := range target.GetCommandNames() {
+					candidates = append(candidates, targetName+":"+commandName)
+				}
+			}
+			console.mutex.Unlock()
+			sort.Strings(candidates)
+			return filterPrefix(candidates, prefix)
+		}
+		commands, namespaces := console.getEntries()
+		if strings.Contains(prefix, "_") {
+			commands, namespaces = console.getRelativeCommands(), nil
+		}
+		for _, namespace := range namespaces {
+			commands = append(commands, namespace+"_")
+		}
+		commands = append(commands, "help", "targets", "use", "cd", "ls", "attach", "detach", "history", "exit")
+		for _, targetName := range console.getTargetNames() {
+			commands = append(commands, targetName+":")
+		}
+		candidates = filterPrefix(commands, prefix)
+		console.mutex.Unlock()
+		return candidates
+	}
+	switch words[0] {
+	case "use", "detach":
+		candidates = filterPrefix(console.getTargetNames(), prefix)
+		console.mutex.Unlock()
+		return candidates
+	case "cd":
+		_, namespaces := console.getEntries()
+		candidates = filterPrefix(append(namespaces, ".."), prefix)
+		console.mutex.Unlock()
+		return candidates
+	case "help":
+		commands, _ := console.getEntries()
+		candidates = filterPrefix(commands, prefix)
+		console.mutex.Unlock()
+		return candidates
+	}
+	console.mutex.Unlock()
+
+	target, name, err := console.resolve(words[0])
+	if err != nil {
+		return candidates
+	}
+	return target.GetCommandDescriptions().Complete(nil, name+" "+strings.Join(words[1:], " "))
+}
+
+// returns the commands and namespaces of the current namespace. must be called with mutex locked.
+func (console *Console) getEntries() ([]string, []string) {
+	target, ok := console.targets[console.currentTarget]
+	if !ok {
+		return nil, nil
+	}
+	prefix := console.getPrefix()
+	commands := []string{}
+	namespaces := []string{}
+	for _, commandName := range target.GetCommandNames() {
+		name, ok := strings.CutPrefix(commandName, prefix)
+		if !ok {
+			continue
+		}
+		if namespace, _, ok := strings.Cut(name, "_"); ok {
+			if !contains(namespaces, namespace) {
+				namespaces = append(namespaces, namespace)
+			}
+			continue
+		}
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+	sort.Strings(namespaces)
+	return commands, namespaces
+}
+
+// returns the names of all commands below the current namespace relative to it. must be called with mutex locked.
+func (console *Console) getRelativeCommands() []string {
+	target, ok := console.targets[console.currentTarget]
+	if !ok {
+		return nil
+	}
+	prefix := console.getPrefix()
+	commands := []string{}
+	for _, commandName := range target.GetCommandNames() {
+		if name, ok := strings.CutPrefix(commandName, prefix); ok {
+			commands = append(commands, name)
+		}
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// must be called with mutex locked.
+func (console *Console) getPrefix() string {
+	if len(console.namespace) == 0 {
+		return ""
+	}
+	return strings.Join(console.namespace, "_") + "_"
+}
+
+// must be called with mutex locked.
+func (console *Console) getTargetNames() []string {
+	targetNames := make([]string, 0, len(console.targets))
+	for targetName := range console.targets {
+		targetNames = append(targetNames, targetName)
+	}
+	sort.Strings(targetNames)
+	return targetNames
+}
+
+func (console *Console) getPrompt() string {
+	console.mutex.Lock()
+	defer console.mutex.Unlock()
+	prompt := console.currentTarget
+	if len(console.namespace) > 0 {
+		prompt += ":" + strings.Join(console.namespace, "_")
+	}
+	return prompt + console.config.Prompt
+}
+
+func (console *Console) println(str string) {
+	io.WriteString(console.output, str+"\n")
+}
+
+// indents results that are json objects or arrays.
+func prettyPrint(result string) string {
+	trimmed := strings.TrimSpace(result)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return result
+	}
+	buffer := bytes.Buffer{}
+	if err := json.Indent(&buffer, []byte(trimmed), "", "  "); err != nil {
+		return result
+	}
+	return buffer.String()
+}
+
+func filterPrefix(words []string, prefix string) []string {
+	filtered := []string{}
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			filtered = append(filtered, word)
+		}
+	}
+	return filtered
+}
+
+func contains(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
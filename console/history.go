@@ -0,0 +1,92 @@
+package console
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+)
+
+// keeps the most recent lines entered into the console.
+// if a path is provided, the history is loaded from and persisted to that file (one entry per line).
+type History struct {
+	path       string
+	maxEntries int
+	entries    []string
+	mutex      sync.Mutex
+}
+
+func NewHistory(path string, maxEntries int) (*History, error) {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	history := &History{
+		path:       path,
+		maxEntries: maxEntries,
+	}
+	if path == "" {
+		return history, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return history, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			history.entries = append(history.entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(history.entries) > maxEntries {
+		history.entries = history.entries[len(history.entries)-maxEntries:]
+	}
+	return history, nil
+}
+
+// empty lines and repetitions of the most recent entry are ignored.
+func (history *History) Add(line string) error {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	line = strings.TrimSpace(line)
+	if line == "" || (len(history.entries) > 0 && history.entries[len(history.entries)-1] == line) {
+		return nil
+	}
+	history.entries = append(history.entries, line)
+	if len(history.entries) > history.maxEntries {
+		history.entries = history.entries[len(history.entries)-history.maxEntries:]
+		return history.save()
+	}
+	if history.path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(history.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(line + "\n")
+	return err
+}
+
+func (history *History) GetEntries() []string {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+	return append([]string{}, history.entries...)
+}
+
+// rewrites the history file. must be called with mutex locked.
+func (history *History) save() error {
+	if history.path == "" {
+		return nil
+	}
+	return os.WriteFile(history.path, []byte(strings.Join(history.entries, "\n")+"\n"), 0600)
+}
@@ -0,0 +1,285 @@
+package console
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInterrupted = errors.New("interrupted")
+
+// returns the candidates for the last word of line.
+type CompleteFunc func(line string) []string
+
+// reads lines from a terminal in raw mode.
+// supports cursor movement, history navigation and tab completion.
+// falls back to reading plain lines if input is not a terminal.
+type lineEditor struct {
+	fd       int
+	input    *bufio.Reader
+	output   io.Writer
+	history  *History
+	complete CompleteFunc
+}
+
+func newLineEditor(fd int, input io.Reader, output io.Writer, history *History, complete CompleteFunc) *lineEditor {
+	return &lineEditor{
+		fd:       fd,
+		input:    bufio.NewReader(input),
+		output:   output,
+		history:  history,
+		complete: complete,
+	}
+}
+
+// returns io.EOF if input is closed or ctrl-d is pressed on an empty line.
+// returns ErrInterrupted if ctrl-c is pressed.
+func (editor *lineEditor) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(editor.fd)
+	if err != nil {
+		io.WriteString(editor.output, prompt)
+		line, err := editor.input.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	defer restore()
+
+	state := &lineState{
+		editor:       editor,
+		prompt:       prompt,
+		history:      editor.history.GetEntries(),
+		historyIndex: -1,
+	}
+	state.historyIndex = len(state.history)
+	state.refresh()
+	for {
+		r, _, err := editor.input.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			io.WriteString(editor.output, "\n")
+			return string(state.line), nil
+		case 3: // ctrl-c
+			io.WriteString(editor.output, "^C\n")
+			return "", ErrInterrupted
+		case 4: // ctrl-d
+			if len(state.line) == 0 {
+				io.WriteString(editor.output, "\n")
+				return "", io.EOF
+			}
+			state.delete()
+		case 1: // ctrl-a
+			state.cursor = 0
+		case 5: // ctrl-e
+			state.cursor = len(state.line)
+		case 2: // ctrl-b
+			state.left()
+		case 6: // ctrl-f
+			state.right()
+		case 8, 127: // backspace
+			state.backspace()
+		case 9: // tab
+			state.tab()
+		case 11: // ctrl-k
+			state.line = state.line[:state.cursor]
+		case 21: // ctrl-u
+			state.line = state.line[state.cursor:]
+			state.cursor = 0
+		case 23: // ctrl-w
+			state.deleteWord()
+		case 12: // ctrl-l
+			io.WriteString(editor.output, "\x1b[H\x1b[2J")
+		case 16: // ctrl-p
+			state.previous()
+		case 14: // ctrl-n
+			state.next()
+		case 27: // escape sequence
+			editor.handleEscapeSequence(state)
+		default:
+			if r >= 32 {
+				state.insert(r)
+			}
+		}
+		state.refresh()
+	}
+}
+
+func (editor *lineEditor) handleEscapeSequence(state *lineState) {
+	r, _, err := editor.input.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return
+	}
+	r, _, err = editor.input.ReadRune()
+	if err != nil {
+		return
+	}
+	// parameter and intermediate bytes (0x20-0x3F) are followed by a final byte (0x40-0x7E),
+	// e.g. "\x1b[3~" (delete) or "\x1b[1;5C" (ctrl+right)
+	params := ""
+	for r >= 0x20 && r <= 0x3F {
+		params += string(r)
+		r, _, err = editor.input.ReadRune()
+		if err != nil {
+			return
+		}
+	}
+	if r == '~' {
+		code, _, _ := strings.Cut(params, ";")
+		switch code {
+		case "1", "7":
+			state.cursor = 0
+		case "4", "8":
+			state.cursor = len(state.line)
+		case "3":
+			state.delete()
+		}
+		return
+	}
+	switch r {
+	case 'A':
+		state.previous()
+	case 'B':
+		state.next()
+	case 'C':
+		state.right()
+	case 'D':
+		state.left()
+	case 'H':
+		state.cursor = 0
+	case 'F':
+		state.cursor = len(state.line)
+	}
+}
+
+type lineState struct {
+	editor       *lineEditor
+	prompt       string
+	line         []rune
+	cursor       int
+	history      []string
+	historyIndex int
+	draft        string // the line that was being edited before navigating the history
+}
+
+func (state *lineState) refresh() {
+	output := "\r" + state.prompt + string(state.line) + "\x1b[K"
+	if back := len(state.line) - state.cursor; back > 0 {
+		output += "\x1b[" + strconv.Itoa(back) + "D"
+	}
+	io.WriteString(state.editor.output, output)
+}
+
+func (state *lineState) insert(r rune) {
+	state.line = append(state.line[:state.cursor], append([]rune{r}, state.line[state.cursor:]...)...)
+	state.cursor++
+}
+
+func (state *lineState) insertString(str string) {
+	for _, r := range str {
+		state.insert(r)
+	}
+}
+
+func (state *lineState) backspace() {
+	if state.cursor == 0 {
+		return
+	}
+	state.line = append(state.line[:state.cursor-1], state.line[state.cursor:]...)
+	state.cursor--
+}
+
+func (state *lineState) delete() {
+	if state.cursor >= len(state.line) {
+		return
+	}
+	state.line = append(state.line[:state.cursor], state.line[state.cursor+1:]...)
+}
+
+func (state *lineState) deleteWord() {
+	start := state.cursor
+	for start > 0 && state.line[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && state.line[start-1] != ' ' {
+		start--
+	}
+	state.line = append(state.line[:start], state.line[state.cursor:]...)
+	state.cursor = start
+}
+
+func (state *lineState) left() {
+	if state.cursor > 0 {
+		state.cursor--
+	}
+}
+
+func (state *lineState) right() {
+	if state.cursor < len(state.line) {
+		state.cursor++
+	}
+}
+
+func (state *lineState) previous() {
+	if state.historyIndex == 0 {
+		return
+	}
+	if state.historyIndex == len(state.history) {
+		state.draft = string(state.line)
+	}
+	state.historyIndex--
+	state.line = []rune(state.history[state.historyIndex])
+	state.cursor = len(state.line)
+}
+
+func (state *lineState) next() {
+	if state.historyIndex >= len(state.history) {
+		return
+	}
+	state.historyIndex++
+	if state.historyIndex == len(state.history) {
+		state.line = []rune(state.draft)
+	} else {
+		state.line = []rune(state.history[state.historyIndex])
+	}
+	state.cursor = len(state.line)
+}
+
+// completes the word before the cursor.
+// a single candidate is inserted, multiple candidates are completed up to their common prefix or listed.
+func (state *lineState) tab() {
+	if state.editor.complete == nil {
+		return
+	}
+	before := string(state.line[:state.cursor])
+	word := before[strings.LastIndex(before, " ")+1:]
+	candidates := state.editor.complete(before)
+	switch len(candidates) {
+	case 0:
+		io.WriteString(state.editor.output, "\a")
+	case 1:
+		state.insertString(strings.TrimPrefix(candidates[0], word) + " ")
+	default:
+		common := commonPrefix(candidates)
+		if len(common) > len(word) && strings.HasPrefix(common, word) {
+			state.insertString(common[len(word):])
+			return
+		}
+		io.WriteString(state.editor.output, "\n"+strings.Join(candidates, "  ")+"\n")
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
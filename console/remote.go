@@ -0,0 +1,285 @@
+package console
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/status"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// one json object per request and response.
+type remoteRequest struct {
+	Token   string   `json:"token"`
+	List    bool     `json:"list"` // requests the command names and descriptions instead of executing a command
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+type remoteResponse struct {
+	Result       string                    `json:"result,omitempty"`
+	Error        string                    `json:"error,omitempty"`
+	Commands     []string                  `json:"commands,omitempty"`
+	Descriptions tools.CommandDescriptions `json:"descriptions,omitempty"`
+}
+
+// lets consoles of other processes attach over tcp or a unix socket and execute commands of commandHandlers.
+// the token is sent in plaintext and the connection is not encrypted,
+// so tcp servers should only listen on loopback or otherwise trusted networks.
+type Server struct {
+	config              *configs.ConsoleServer
+	commandHandlers     tools.CommandHandlers
+	commandDescriptions tools.CommandDescriptions
+
+	status      *status.Machine
+	statusMutex sync.Mutex // serializes Start and Stop and guards listener and connections
+	listener    net.Listener
+	connections map[net.Conn]struct{}
+	waitGroup   sync.WaitGroup
+}
+
+func NewServer(config *configs.ConsoleServer, commandHandlers tools.CommandHandlers, commandDescriptions tools.CommandDescriptions) (*Server, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if config.Network != "tcp" && config.Network != "unix" {
+		return nil, errors.New("network must be \"tcp\" or \"unix\"")
+	}
+	if config.Address == "" {
+		return nil, errors.New("address is empty")
+	}
+	if config.Network == "tcp" && config.Token == "" {
+		return nil, errors.New("token is required for network \"tcp\"")
+	}
+	if commandHandlers == nil {
+		return nil, errors.New("commandHandlers is nil")
+	}
+	return &Server{
+		config:              config,
+		commandHandlers:     commandHandlers,
+		commandDescriptions: commandDescriptions,
+		status:              status.NewMachine(),
+		connections:         make(map[net.Conn]struct{}),
+	}, nil
+}
+
+func (server *Server) Start() error {
+	server.statusMutex.Lock()
+	defer server.statusMutex.Unlock()
+
+	if err := server.status.Transition(status.Starting, nil); err != nil {
+		return errors.New("server not stopped")
+	}
+	listener, err := net.Listen(server.config.Network, server.config.Address)
+	if err != nil {
+		return server.status.Fail(err)
+	}
+	server.listener = listener
+	server.status.Transition(status.Started, nil)
+
+	server.waitGroup.Add(1)
+	go server.acceptRoutine(listener)
+	return nil
+}
+
+func (server *Server) Stop() error {
+	server.statusMutex.Lock()
+	if !server.status.Is(status.Started) {
+		server.statusMutex.Unlock()
+		return errors.New("server not started")
+	}
+	server.status.Transition(status.Stopping, nil)
+	server.listener.Close()
+	for connection := range server.connections {
+		connection.Close()
+	}
+	server.statusMutex.Unlock()
+
+	server.waitGroup.Wait()
+
+	server.statusMutex.Lock()
+	server.listener = nil
+	server.status.Transition(status.Stopped, nil)
+	server.statusMutex.Unlock()
+	return nil
+}
+
+func (server *Server) GetStatus() int {
+	return server.status.Get()
+}
+
+func (server *Server) GetStatusMachine() *status.Machine {
+	return server.status
+}
+
+func (server *Server) acceptRoutine(listener net.Listener) {
+	defer server.waitGroup.Done()
+	for {
+		connection, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		server.statusMutex.Lock()
+		if !server.status.Is(status.Started) {
+			server.statusMutex.Unlock()
+			connection.Close()
+			return
+		}
+		server.connections[connection] = struct{}{}
+		server.waitGroup.Add(1)
+		server.statusMutex.Unlock()
+
+		go server.handleConnection(connection)
+	}
+}
+
+func (server *Server) handleConnection(connection net.Conn) {
+	defer func() {
+		connection.Close()
+		server.statusMutex.Lock()
+		delete(server.connections, connection)
+		server.statusMutex.Unlock()
+		server.waitGroup.Done()
+	}()
+
+	decoder := json.NewDecoder(connection)
+	encoder := json.NewEncoder(connection)
+	for {
+		request := &remoteRequest{}
+		if err := decoder.Decode(request); err != nil {
+			return
+		}
+		if server.config.Token != "" && subtle.ConstantTimeCompare([]byte(request.Token), []byte(server.config.Token)) != 1 {
+			encoder.Encode(&remoteResponse{Error: "invalid token"})
+			return
+		}
+		response := &remoteResponse{}
+		if request.List {
+			response.Commands = server.commandHandlers.GetKeys()
+			response.Descriptions = server.commandDescriptions
+		} else if result, err := server.execute(request.Command, request.Args); err != nil {
+			response.Error = err.Error()
+		} else {
+			response.Result = result
+		}
+		if err := encoder.Encode(response); err != nil {
+			return
+		}
+	}
+}
+
+// executes the command and returns a panic of its handler as error, so a remote client can not crash the process.
+func (server *Server) execute(command string, args []string) (result string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("command panicked: %v", recovered)
+		}
+	}()
+	return server.commandHandlers.Execute(command, args)
+}
+
+// executes commands on a Server of another process.
+type RemoteTarget struct {
+	token      string
+	timeoutNs  int64
+	connection net.Conn
+	decoder    *json.Decoder
+	encoder    *json.Encoder
+
+	requestMutex sync.Mutex // one request at a time
+
+	mutex        sync.Mutex
+	commands     []string
+	descriptions tools.CommandDescriptions
+}
+
+// connects to a Server and fetches its command names and descriptions.
+// timeoutNs bounds dialing and each request (<=0 == no timeout).
+// the connection is closed if a request fails, since responses can no longer be matched to requests.
+func Attach(network string, address string, token string, timeoutNs int64) (*RemoteTarget, error) {
+	connection, err := net.DialTimeout(network, address, time.Duration(timeoutNs))
+	if err != nil {
+		return nil, err
+	}
+	target := &RemoteTarget{
+		token:      token,
+		timeoutNs:  timeoutNs,
+		connection: connection,
+		decoder:    json.NewDecoder(connection),
+		encoder:    json.NewEncoder(connection),
+	}
+	if err := target.Refresh(); err != nil {
+		connection.Close()
+		return nil, err
+	}
+	return target, nil
+}
+
+// fetches the command names and descriptions again.
+func (target *RemoteTarget) Refresh() error {
+	response, err := target.request(&remoteRequest{List: true})
+	if err != nil {
+		return err
+	}
+	target.mutex.Lock()
+	target.commands = response.Commands
+	target.descriptions = response.Descriptions
+	target.mutex.Unlock()
+	return nil
+}
+
+func (target *RemoteTarget) Close() error {
+	return target.connection.Close()
+}
+
+func (target *RemoteTarget) GetCommandNames() []string {
+	target.mutex.Lock()
+	defer target.mutex.Unlock()
+	return append([]string{}, target.commands...)
+}
+
+func (target *RemoteTarget) GetCommandDescriptions() tools.CommandDescriptions {
+	target.mutex.Lock()
+	defer target.mutex.Unlock()
+	return target.descriptions
+}
+
+func (target *RemoteTarget) Execute(command string, args []string) (string, error) {
+	response, err := target.request(&remoteRequest{
+		Command: command,
+		Args:    args,
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.Result, nil
+}
+
+func (target *RemoteTarget) request(request *remoteRequest) (*remoteResponse, error) {
+	target.requestMutex.Lock()
+	defer target.requestMutex.Unlock()
+
+	if target.timeoutNs > 0 {
+		target.connection.SetDeadline(time.Now().Add(time.Duration(target.timeoutNs)))
+	}
+	request.Token = target.token
+	if err := target.encoder.Encode(request); err != nil {
+		target.connection.Close()
+		return nil, err
+	}
+	response := &remoteResponse{}
+	if err := target.decoder.Decode(response); err != nil {
+		target.connection.Close()
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return response, nil
+}
@@ -0,0 +1,37 @@
+package console
+
+import (
+	"github.com/neutralusername/systemge/tools"
+)
+
+// a set of commands the console can execute.
+type Target interface {
+	GetCommandNames() []string
+	GetCommandDescriptions() tools.CommandDescriptions
+	Execute(command string, args []string) (string, error)
+}
+
+type localTarget struct {
+	commandHandlers     tools.CommandHandlers
+	commandDescriptions tools.CommandDescriptions
+}
+
+// executes commands of commandHandlers in this process. commandDescriptions may be nil.
+func NewLocalTarget(commandHandlers tools.CommandHandlers, commandDescriptions tools.CommandDescriptions) Target {
+	return &localTarget{
+		commandHandlers:     commandHandlers,
+		commandDescriptions: commandDescriptions,
+	}
+}
+
+func (target *localTarget) GetCommandNames() []string {
+	return target.commandHandlers.GetKeys()
+}
+
+func (target *localTarget) GetCommandDescriptions() tools.CommandDescriptions {
+	return target.commandDescriptions
+}
+
+func (target *localTarget) Execute(command string, args []string) (string, error) {
+	return target.commandHandlers.Execute(command, args)
+}
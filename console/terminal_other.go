@@ -0,0 +1,10 @@
+//go:build !linux
+
+package console
+
+import "errors"
+
+// raw mode is only supported on linux. other platforms fall back to reading plain lines.
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw mode not supported on this platform")
+}
@@ -0,0 +1,431 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/status"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+	"github.com/neutralusername/systemge/typedConnection"
+)
+
+// returns the metrics of a component (e.g. its CheckMetrics or GetMetrics method).
+type MetricsSource func() tools.MetricsTypes
+
+// registers a component with a remote dashboard Server and answers its command and metrics requests.
+// the client reconnects and introduces itself again whenever the connection is lost.
+// every component of a process uses its own client (and therefore its own connection).
+type Client struct {
+	name      string
+	config    *configs.DashboardClient
+	connector systemge.Connector[[]byte]
+
+	commandHandlers     tools.CommandHandlers
+	commandDescriptions tools.CommandDescriptions
+	metricsSources      []MetricsSource
+	topologySource      TopologySource
+
+	status      *status.Machine
+	statusMutex sync.Mutex // serializes Start and Stop
+	stopChannel chan struct{}
+	waitGroup   sync.WaitGroup
+
+	connection      systemge.Connection[*tools.Message]
+	connectionMutex sync.Mutex
+
+	dashboardListenerInstanceId string // instance id of the dashboard's component listener, received with the introduction response
+
+	logger *tools.Logger
+
+	// metrics
+
+	ConnectionAttempts       atomic.Uint64
+	FailedConnectionAttempts atomic.Uint64
+	CommandsSucceeded        atomic.Uint64
+	CommandsFailed           atomic.Uint64
+	MetricsRequests          atomic.Uint64
+}
+
+// commandHandlers, commandDescriptions and topologySource may be nil.
+// the connection to the dashboard is added to the reported topology.
+// the metrics of all metricsSources are merged into one response.
+func NewClient(name string, config *configs.DashboardClient, connector systemge.Connector[[]byte], commandHandlers tools.CommandHandlers, commandDescriptions tools.CommandDescriptions, topologySource TopologySource, metricsSources ...MetricsSource) (*Client, error) {
+	if name == "" {
+		return nil, errors.New("name is empty")
+	}
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if connector == nil {
+		return nil, errors.New("connector is nil")
+	}
+	if config.ReconnectDelayNs <= 0 {
+		config.ReconnectDelayNs = int64(time.Second)
+	}
+	if config.MaxReconnectDelayNs < config.ReconnectDelayNs {
+		config.MaxReconnectDelayNs = config.ReconnectDelayNs
+	}
+	if commandHandlers == nil {
+		commandHandlers = tools.CommandHandlers{}
+	}
+	for _, metricsSource := range metricsSources {
+		if metricsSource == nil {
+			return nil, errors.New("metricsSource is nil")
+		}
+	}
+	return &Client{
+		name:                name,
+		config:              config,
+		connector:           connector,
+		commandHandlers:     commandHandlers,
+		commandDescriptions: commandDescriptions,
+		metricsSources:      metricsSources,
+		topologySource:      topologySource,
+		status:              status.NewMachine(),
+	}, nil
+}
+
+// failed connection attempts are logged to logger. should be called before the client is started.
+func (client *Client) SetLogger(logger *tools.Logger) {
+	client.logger = logger
+}
+
+// the client fails (and stops reconnecting) after MaxReconnectAttempts consecutive failed attempts.
+// a failed client can be started again.
+func (client *Client) Start() error {
+	client.statusMutex.Lock()
+	defer client.statusMutex.Unlock()
+
+	if err := client.status.Transition(status.Starting, nil); err != nil {
+		return errors.New("client not stopped")
+	}
+	client.stopChannel = make(chan struct{})
+	client.status.Transition(status.Started, nil)
+
+	client.waitGroup.Add(1)
+	go client.connectionRoutine(client.stopChannel)
+	return nil
+}
+
+func (client *Client) Stop() error {
+	client.statusMutex.Lock()
+	defer client.statusMutex.Unlock()
+
+	if !client.status.Is(status.Started, status.Failed) {
+		return errors.New("client not started")
+	}
+	client.status.Transition(status.Stopping, nil)
+	close(client.stopChannel)
+
+	client.connectionMutex.Lock()
+	if client.connection != nil {
+		client.connection.Close()
+	}
+	client.connectionMutex.Unlock()
+
+	client.waitGroup.Wait()
+	if client.status.Is(status.Failed) { // failed while stopping
+		client.status.Transition(status.Stopping, nil)
+	}
+	client.status.Transition(status.Stopped, nil)
+	return nil
+}
+
+func (client *Client) GetStatus() int {
+	return client.status.Get()
+}
+
+func (client *Client) GetStatusMachine() *status.Machine {
+	return client.status
+}
+
+func (client *Client) GetName() string {
+	return client.name
+}
+
+// returns true if the client is currently connected to and registered with the dashboard.
+func (client *Client) IsConnected() bool {
+	client.connectionMutex.Lock()
+	defer client.connectionMutex.Unlock()
+	return client.connection != nil
+}
+
+// connects, introduces and serves requests until the connection is closed, then reconnects after a delay.
+func (client *Client) connectionRoutine(stopChannel chan struct{}) {
+	defer client.waitGroup.Done()
+
+	delayNs := client.config.ReconnectDelayNs
+	failedAttempts := 0
+	for {
+		connection, err := client.connect(stopChannel)
+		if err == nil {
+			delayNs = client.config.ReconnectDelayNs
+			failedAttempts = 0
+			client.handleConnection(connection)
+		} else {
+			client.FailedConnectionAttempts.Add(1)
+			failedAttempts++
+			client.logger.Warn("connection to dashboard failed", "attempt", failedAttempts, "error", err)
+			if client.config.MaxReconnectAttempts > 0 && failedAttempts >= client.config.MaxReconnectAttempts {
+				client.status.Fail(errors.New("gave up after " + strconv.Itoa(failedAttempts) + " failed connection attempts: " + err.Error()))
+				return
+			}
+		}
+
+		select {
+		case <-stopChannel:
+			return
+		case <-time.After(time.Duration(delayNs)):
+		}
+		if err != nil {
+			delayNs = min(delayNs*2, client.config.MaxReconnectDelayNs)
+		}
+	}
+}
+
+// returns a connection that the dashboard accepted the introduction of.
+func (client *Client) connect(stopChannel chan struct{}) (systemge.Connection[*tools.Message], error) {
+	select {
+	case <-stopChannel:
+		return nil, errors.New("client stopped")
+	default:
+	}
+	client.ConnectionAttempts.Add(1)
+
+	byteConnection, err := client.connector.Connect(client.config.ConnectTimeoutNs)
+	if err != nil {
+		return nil, err
+	}
+	connection, err := typedConnection.New(byteConnection, tools.JsonMarshalMessage, tools.JsonUnmarshalMessage)
+	if err != nil {
+		byteConnection.Close()
+		return nil, err
+	}
+
+	// the stop channel is checked while holding the mutex so Stop either sees and closes the connection or the connection is never set
+	client.connectionMutex.Lock()
+	select {
+	case <-stopChannel:
+		client.connectionMutex.Unlock()
+		connection.Close()
+		return nil, errors.New("client stopped")
+	default:
+	}
+	client.connection = connection
+	client.connectionMutex.Unlock()
+
+	dashboardListenerInstanceId, err := client.introduce(connection)
+	if err != nil {
+		client.connectionMutex.Lock()
+		client.connection = nil
+		client.connectionMutex.Unlock()
+		connection.Close()
+		return nil, err
+	}
+	client.connectionMutex.Lock()
+	client.dashboardListenerInstanceId = dashboardListenerInstanceId
+	client.connectionMutex.Unlock()
+	return connection, nil
+}
+
+// returns the payload of the dashboard's success response.
+func (client *Client) introduce(connection systemge.Connection[*tools.Message]) (string, error) {
+	commands := client.commandHandlers.GetKeys()
+	sort.Strings(commands)
+	payload, err := json.Marshal(&Introduction{
+		Name:                client.name,
+		Commands:            commands,
+		CommandDescriptions: client.commandDescriptions,
+	})
+	if err != nil {
+		return "", err
+	}
+	syncToken := tools.GenerateRandomString(32, tools.ALPHA_NUMERIC)
+	if err := connection.Write(tools.NewSync(TOPIC_INTRODUCTION, string(payload), syncToken), client.config.WriteTimeoutNs); err != nil {
+		return "", err
+	}
+
+	var deadline time.Time
+	if client.config.IntroductionTimeoutNs > 0 {
+		deadline = time.Now().Add(time.Duration(client.config.IntroductionTimeoutNs))
+	}
+	for {
+		readTimeoutNs := int64(0)
+		if !deadline.IsZero() {
+			readTimeoutNs = int64(time.Until(deadline))
+			if readTimeoutNs <= 0 {
+				return "", ErrNoResponse
+			}
+		}
+		message, err := connection.Read(readTimeoutNs)
+		if err != nil {
+			return "", err
+		}
+		if !message.IsResponse() || message.GetSyncToken() != syncToken {
+			continue
+		}
+		if message.GetTopic() != tools.TOPIC_SUCCESS {
+			return "", errors.New(message.GetPayload())
+		}
+		return message.GetPayload(), nil
+	}
+}
+
+// serves requests of the dashboard until the connection is closed.
+func (client *Client) handleConnection(connection systemge.Connection[*tools.Message]) {
+	defer func() {
+		client.connectionMutex.Lock()
+		client.connection = nil
+		client.connectionMutex.Unlock()
+		connection.Close()
+	}()
+
+	for {
+		message, err := connection.Read(0)
+		if err != nil {
+			return
+		}
+		if message.IsResponse() || message.GetSyncToken() == "" {
+			continue
+		}
+		// commands may take a while and must not delay metrics requests
+		go client.handleRequest(message, connection)
+	}
+}
+
+// executes the command and returns a panic of its handler as error, so a forwarded command can not crash the process.
+func (client *Client) execute(command string, args []string) (result string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("command panicked: %v", recovered)
+		}
+	}()
+	return client.commandHandlers.Execute(command, args)
+}
+
+func (client *Client) handleRequest(message *tools.Message, connection systemge.Connection[*tools.Message]) {
+	switch message.GetTopic() {
+	case TOPIC_COMMAND:
+		command := &tools.Command{}
+		if err := json.Unmarshal([]byte(message.GetPayload()), command); err != nil {
+			client.CommandsFailed.Add(1)
+			connection.Write(message.NewFailureResponse(err.Error()), client.config.WriteTimeoutNs)
+			return
+		}
+		result, err := client.execute(command.Command, command.Args)
+		if err != nil {
+			client.CommandsFailed.Add(1)
+			connection.Write(message.NewFailureResponse(err.Error()), client.config.WriteTimeoutNs)
+			return
+		}
+		client.CommandsSucceeded.Add(1)
+		connection.Write(message.NewSuccessResponse(result), client.config.WriteTimeoutNs)
+
+	case TOPIC_GET_METRICS:
+		client.MetricsRequests.Add(1)
+		metricsTypes := tools.NewMetricsTypes()
+		for _, metricsSource := range client.metricsSources {
+			metricsTypes.Merge(metricsSource())
+		}
+		payload, err := json.Marshal(metricsTypes)
+		if err != nil {
+			connection.Write(message.NewFailureResponse(err.Error()), client.config.WriteTimeoutNs)
+			return
+		}
+		connection.Write(message.NewSuccessResponse(string(payload)), client.config.WriteTimeoutNs)
+
+	case TOPIC_GET_TOPOLOGY:
+		topology := &ComponentTopology{}
+		if client.topologySource != nil {
+			if sourceTopology := client.topologySource(); sourceTopology != nil {
+				topology.Listeners = append(topology.Listeners, sourceTopology.Listeners...)
+				topology.Connections = append(topology.Connections, sourceTopology.Connections...)
+			}
+		}
+		client.connectionMutex.Lock()
+		dashboardConnection := NewConnectionInfo(connection, "")
+		dashboardConnection.ListenerInstanceId = client.dashboardListenerInstanceId
+		client.connectionMutex.Unlock()
+		topology.Connections = append(topology.Connections, dashboardConnection)
+		payload, err := json.Marshal(topology)
+		if err != nil {
+			connection.Write(message.NewFailureResponse(err.Error()), client.config.WriteTimeoutNs)
+			return
+		}
+		connection.Write(message.NewSuccessResponse(string(payload)), client.config.WriteTimeoutNs)
+
+	default:
+		connection.Write(message.NewFailureResponse("unknown topic"), client.config.WriteTimeoutNs)
+	}
+}
+
+func (client *Client) CheckMetrics() tools.MetricsTypes {
+	return client.getMetrics(func(value *atomic.Uint64) uint64 { return value.Load() })
+}
+
+func (client *Client) GetMetrics() tools.MetricsTypes {
+	return client.getMetrics(func(value *atomic.Uint64) uint64 { return value.Swap(0) })
+}
+
+func (client *Client) getMetrics(load func(*atomic.Uint64) uint64) tools.MetricsTypes {
+	connected := uint64(0)
+	if client.IsConnected() {
+		connected = 1
+	}
+	metricsTypes := tools.NewMetricsTypes()
+	metricsTypes.AddMetrics("dashboard_client", tools.NewMetrics(
+		map[string]uint64{
+			"connected":                connected,
+			"connectionAttempts":       load(&client.ConnectionAttempts),
+			"failedConnectionAttempts": load(&client.FailedConnectionAttempts),
+			"commandsSucceeded":        load(&client.CommandsSucceeded),
+			"commandsFailed":           load(&client.CommandsFailed),
+			"metricsRequests":          load(&client.MetricsRequests),
+		},
+	))
+	return metricsTypes
+}
+
+func (client *Client) GetDefaultCommands() tools.CommandHandlers {
+	commands := tools.CommandHandlers{}
+	commands["start"] = func(args []string) (string, error) {
+		err := client.Start()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["stop"] = func(args []string) (string, error) {
+		err := client.Stop()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["getStatus"] = func(args []string) (string, error) {
+		return status.ToString(client.GetStatus()), nil
+	}
+	commands["checkMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(client.CheckMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(client.GetMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	return commands
+}
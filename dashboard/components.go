@@ -0,0 +1,457 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/reader"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+	"github.com/neutralusername/systemge/typedConnection"
+)
+
+var ErrNoResponse = errors.New("no response received")
+
+type component struct {
+	name                string
+	address             string
+	commands            []string
+	commandDescriptions tools.CommandDescriptions
+
+	executeCommand func(command string, args []string) (string, error)
+	getMetrics     func() (tools.MetricsTypes, error)
+	getTopology    func() (*ComponentTopology, error)
+
+	connection systemge.Connection[*tools.Message] // nil for local components
+
+	metricsMutex   sync.Mutex
+	metricsHistory map[string][]*tools.Metrics
+}
+
+func (component *component) getInfo() *ComponentInfo {
+	return &ComponentInfo{
+		Name:                component.name,
+		Address:             component.address,
+		Commands:            component.commands,
+		CommandDescriptions: component.commandDescriptions,
+	}
+}
+
+// registers a component of this process.
+// commandHandlers, commandDescriptions, getMetrics and getTopology may be nil.
+func (server *Server) AddLocalComponent(name string, commandHandlers tools.CommandHandlers, commandDescriptions tools.CommandDescriptions, getMetrics func() tools.MetricsTypes, getTopology TopologySource) error {
+	if commandHandlers == nil {
+		commandHandlers = tools.CommandHandlers{}
+	}
+	commands := commandHandlers.GetKeys()
+	sort.Strings(commands)
+	component := &component{
+		name:                name,
+		commands:            commands,
+		commandDescriptions: commandDescriptions,
+		executeCommand:      commandHandlers.Execute,
+		getMetrics: func() (tools.MetricsTypes, error) {
+			if getMetrics == nil {
+				return tools.NewMetricsTypes(), nil
+			}
+			return getMetrics(), nil
+		},
+		getTopology: func() (*ComponentTopology, error) {
+			if getTopology == nil {
+				return &ComponentTopology{}, nil
+			}
+			return getTopology(), nil
+		},
+		metricsHistory: make(map[string][]*tools.Metrics),
+	}
+	return server.addComponent(component)
+}
+
+// removes a local component or disconnects a remote component.
+func (server *Server) RemoveComponent(name string) error {
+	server.mutex.Lock()
+	component, ok := server.components[name]
+	if !ok {
+		server.mutex.Unlock()
+		return errors.New("component does not exist")
+	}
+	delete(server.components, name)
+	server.mutex.Unlock()
+
+	if component.connection != nil {
+		component.connection.Close()
+	}
+	server.broadcastComponents()
+	return nil
+}
+
+func (server *Server) GetComponentNames() []string {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+	names := make([]string, 0, len(server.components))
+	for name := range server.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// executes a command of the component with the provided name.
+func (server *Server) ExecuteCommand(name string, command string, args []string) (string, error) {
+	component := server.getComponent(name)
+	if component == nil {
+		server.CommandsFailed.Add(1)
+		return "", errors.New("component does not exist")
+	}
+	result, err := component.executeCommand(command, args)
+	if err != nil {
+		server.CommandsFailed.Add(1)
+		return "", err
+	}
+	server.CommandsSucceeded.Add(1)
+	return result, nil
+}
+
+// fetches the current metrics of the component with the provided name, adds them to its history and pushes them to the frontend.
+func (server *Server) FetchMetrics(name string) (tools.MetricsTypes, error) {
+	component := server.getComponent(name)
+	if component == nil {
+		return nil, errors.New("component does not exist")
+	}
+	return server.updateMetrics(component)
+}
+
+// returns a copy of the metrics history of the component with the provided name.
+func (server *Server) GetMetricsHistory(name string) (map[string][]*tools.Metrics, error) {
+	component := server.getComponent(name)
+	if component == nil {
+		return nil, errors.New("component does not exist")
+	}
+	component.metricsMutex.Lock()
+	defer component.metricsMutex.Unlock()
+	history := make(map[string][]*tools.Metrics, len(component.metricsHistory))
+	for metricsType, entries := range component.metricsHistory {
+		history[metricsType] = append([]*tools.Metrics{}, entries...)
+	}
+	return history, nil
+}
+
+// requests the listeners and connections of all components and links them to a graph.
+// components that fail to report their topology are included with an error.
+func (server *Server) GetTopology() *Topology {
+	components := server.getComponents()
+	topologyComponents := make([]*TopologyComponent, len(components))
+	waitGroup := sync.WaitGroup{}
+	for i, registeredComponent := range components {
+		waitGroup.Add(1)
+		go func(i int, registeredComponent *component) {
+			defer waitGroup.Done()
+			topologyComponent := &TopologyComponent{
+				Name:        registeredComponent.name,
+				Address:     registeredComponent.address,
+				Listeners:   []*ListenerInfo{},
+				Connections: []*ConnectionInfo{},
+			}
+			componentTopology, err := registeredComponent.getTopology()
+			if err != nil {
+				topologyComponent.Error = err.Error()
+			} else if componentTopology != nil {
+				if componentTopology.Listeners != nil {
+					topologyComponent.Listeners = componentTopology.Listeners
+				}
+				if componentTopology.Connections != nil {
+					topologyComponent.Connections = componentTopology.Connections
+				}
+			}
+			topologyComponents[i] = topologyComponent
+		}(i, registeredComponent)
+	}
+	waitGroup.Wait()
+	return newTopology(topologyComponents)
+}
+
+// reports the frontend listener and the component listener.
+func (server *Server) getOwnTopology() *ComponentTopology {
+	topology := &ComponentTopology{
+		Listeners: []*ListenerInfo{NewListenerInfo(server.websocketListener)},
+	}
+	if server.componentListener != nil {
+		topology.Listeners = append(topology.Listeners, NewListenerInfo(server.componentListener))
+	}
+	return topology
+}
+
+func (server *Server) addComponent(component *component) error {
+	if component.name == "" {
+		return errors.New("component name is empty")
+	}
+	if server.config.MaxComponentNameLength > 0 && len(component.name) > server.config.MaxComponentNameLength {
+		return errors.New("component name too long")
+	}
+	server.mutex.Lock()
+	if _, ok := server.components[component.name]; ok {
+		server.mutex.Unlock()
+		return errors.New("component already exists")
+	}
+	server.components[component.name] = component
+	server.mutex.Unlock()
+
+	server.broadcastComponents()
+	return nil
+}
+
+// removes the component only if it is still the registered instance (a component may have reconnected in the meantime).
+func (server *Server) removeComponentInstance(component *component) {
+	server.mutex.Lock()
+	if server.components[component.name] != component {
+		server.mutex.Unlock()
+		return
+	}
+	delete(server.components, component.name)
+	server.mutex.Unlock()
+
+	server.broadcastComponents()
+}
+
+func (server *Server) getComponent(name string) *component {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+	return server.components[name]
+}
+
+func (server *Server) getComponents() []*component {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+	components := make([]*component, 0, len(server.components))
+	for _, component := range server.components {
+		components = append(components, component)
+	}
+	return components
+}
+
+func (server *Server) getComponentInfos() []*ComponentInfo {
+	components := server.getComponents()
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].name < components[j].name
+	})
+	infos := make([]*ComponentInfo, 0, len(components))
+	for _, component := range components {
+		infos = append(infos, component.getInfo())
+	}
+	return infos
+}
+
+func (server *Server) updateMetrics(component *component) (tools.MetricsTypes, error) {
+	metricsTypes, err := component.getMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	component.metricsMutex.Lock()
+	for metricsType, metrics := range metricsTypes {
+		if metrics == nil {
+			continue
+		}
+		entries := append(component.metricsHistory[metricsType], metrics)
+		if len(entries) > server.config.MaxEntriesPerMetrics {
+			entries = entries[len(entries)-server.config.MaxEntriesPerMetrics:]
+		}
+		component.metricsHistory[metricsType] = entries
+	}
+	component.metricsMutex.Unlock()
+
+	payload, err := json.Marshal(&ComponentMetrics{
+		Name:    component.name,
+		Metrics: metricsTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	server.broadcast(tools.NewAsync(TOPIC_METRICS, string(payload)))
+	return metricsTypes, nil
+}
+
+// accept handler of the component listener.
+// the component has to introduce itself before it is registered.
+func (server *Server) acceptComponent(connection systemge.Connection[[]byte]) error {
+	messageConnection, err := typedConnection.New(connection, tools.JsonMarshalMessage, tools.JsonUnmarshalMessage)
+	if err != nil {
+		return err
+	}
+
+	var registeredComponent *component
+	var registeredMutex sync.Mutex
+
+	var introductionTimeout *tools.Timeout
+	if server.config.IntroductionTimeoutNs > 0 {
+		introductionTimeout = tools.NewTimeout(
+			server.config.IntroductionTimeoutNs,
+			func() {
+				registeredMutex.Lock()
+				defer registeredMutex.Unlock()
+				if registeredComponent == nil {
+					connection.Close()
+				}
+			},
+			true,
+		)
+	}
+
+	readHandler := func(message *tools.Message, messageConnection systemge.Connection[*tools.Message]) {
+		if message.IsResponse() {
+			server.requestResponseManager.AddResponse(message.GetSyncToken(), message)
+			return
+		}
+		if message.GetTopic() != TOPIC_INTRODUCTION {
+			server.logger.Warn("unexpected component topic", "topic", message.GetTopic(), "address", messageConnection.GetAddress())
+			return
+		}
+
+		registeredMutex.Lock()
+		defer registeredMutex.Unlock()
+
+		if registeredComponent != nil {
+			messageConnection.Write(message.NewFailureResponse("already introduced"), server.config.ComponentWriteTimeoutNs)
+			return
+		}
+		component, err := server.newRemoteComponent(message.GetPayload(), messageConnection)
+		if err == nil {
+			err = server.addComponent(component)
+		}
+		if err != nil {
+			server.ComponentsRejected.Add(1)
+			messageConnection.Write(message.NewFailureResponse(err.Error()), server.config.ComponentWriteTimeoutNs)
+			return
+		}
+		registeredComponent = component
+		if introductionTimeout != nil {
+			introductionTimeout.Cancel()
+		}
+		server.ComponentsRegistered.Add(1)
+		// the client reports its connection to the dashboard with the component listener's instance id
+		messageConnection.Write(message.NewSuccessResponse(server.componentListener.GetInstanceId()), server.config.ComponentWriteTimeoutNs)
+	}
+
+	reader, err := reader.NewAsync(
+		messageConnection,
+		&configs.ReaderAsync{},
+		&configs.Routine{MaxConcurrentHandlers: 1},
+		readHandler,
+	)
+	if err != nil {
+		return err
+	}
+	reader.SetEventHandler(newCloseOnReadFailedHandler(connection))
+	if err := reader.GetRoutine().Start(); err != nil {
+		return err
+	}
+
+	go func() {
+		select {
+		case <-connection.GetCloseChannel():
+		case <-server.componentAccepter.GetRoutine().GetStopChannel():
+			connection.Close()
+		}
+
+		// reader listens on connections' close channel
+
+		if introductionTimeout != nil {
+			introductionTimeout.Cancel()
+		}
+		registeredMutex.Lock()
+		component := registeredComponent
+		registeredMutex.Unlock()
+		if component != nil {
+			server.removeComponentInstance(component)
+		}
+	}()
+
+	return nil
+}
+
+func (server *Server) newRemoteComponent(payload string, connection systemge.Connection[*tools.Message]) (*component, error) {
+	introduction := &Introduction{}
+	if err := json.Unmarshal([]byte(payload), introduction); err != nil {
+		return nil, err
+	}
+	commands := append([]string{}, introduction.Commands...)
+	sort.Strings(commands)
+	return &component{
+		name:                introduction.Name,
+		address:             connection.GetAddress(),
+		commands:            commands,
+		commandDescriptions: introduction.CommandDescriptions,
+		executeCommand: func(command string, args []string) (string, error) {
+			payload, err := json.Marshal(&tools.Command{
+				Command: command,
+				Args:    args,
+			})
+			if err != nil {
+				return "", err
+			}
+			return server.requestComponent(connection, TOPIC_COMMAND, string(payload))
+		},
+		getMetrics: func() (tools.MetricsTypes, error) {
+			payload, err := server.requestComponent(connection, TOPIC_GET_METRICS, "")
+			if err != nil {
+				return nil, err
+			}
+			return tools.JsonUnmarshalMetricsTypes(payload)
+		},
+		getTopology: func() (*ComponentTopology, error) {
+			payload, err := server.requestComponent(connection, TOPIC_GET_TOPOLOGY, "")
+			if err != nil {
+				return nil, err
+			}
+			topology := &ComponentTopology{}
+			if err := json.Unmarshal([]byte(payload), topology); err != nil {
+				return nil, err
+			}
+			return topology, nil
+		},
+		connection:     connection,
+		metricsHistory: make(map[string][]*tools.Metrics),
+	}, nil
+}
+
+// writes a sync request to the component and blocks until the response is received, the request times out or the connection is closed.
+// returns the payload of a success response or the payload of a failure response as error.
+func (server *Server) requestComponent(connection systemge.Connection[*tools.Message], topic string, payload string) (string, error) {
+	syncToken := tools.GenerateRandomString(32, tools.ALPHA_NUMERIC)
+	request, err := server.requestResponseManager.NewRequest(syncToken, 1, server.config.ComponentRequestTimeoutNs, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := connection.Write(tools.NewSync(topic, payload, syncToken), server.config.ComponentWriteTimeoutNs); err != nil {
+		server.requestResponseManager.AbortRequest(syncToken)
+		return "", err
+	}
+
+	select {
+	case response, ok := <-request.GetResponseChannel():
+		if !ok {
+			return "", ErrNoResponse
+		}
+		if response.GetTopic() != tools.TOPIC_SUCCESS {
+			return "", errors.New(response.GetPayload())
+		}
+		return response.GetPayload(), nil
+	case <-connection.GetCloseChannel():
+		server.requestResponseManager.AbortRequest(syncToken)
+		return "", errors.New("component disconnected")
+	}
+}
+
+// closes the connection once a read fails instead of retrying it,
+// since a websocket connection is unusable after a read error (and panics on repeated reads).
+// the readers of the dashboard do not use read timeouts, so a failed read means the peer is gone.
+func newCloseOnReadFailedHandler[T any](connection systemge.Connection[T]) *tools.Handler {
+	return tools.NewHandler(func(event *tools.Event) {
+		if event.GetEvent() == tools.EVENT_READ_FAILED {
+			connection.Close()
+		}
+	}, nil)
+}
@@ -0,0 +1,81 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/neutralusername/systemge/status"
+	"github.com/neutralusername/systemge/tools"
+)
+
+func (server *Server) GetDefaultCommands() tools.CommandHandlers {
+	commands := tools.CommandHandlers{}
+	commands["start"] = func(args []string) (string, error) {
+		err := server.Start()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["stop"] = func(args []string) (string, error) {
+		err := server.Stop()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["getStatus"] = func(args []string) (string, error) {
+		return status.ToString(server.GetStatus()), nil
+	}
+	commands["checkMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(server.CheckMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(server.GetMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getComponents"] = func(args []string) (string, error) {
+		json, err := json.Marshal(server.getComponentInfos())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getTopology"] = func(args []string) (string, error) {
+		if len(args) > 1 {
+			return "", errors.New("expected 0 or 1 arguments (json|dot)")
+		}
+		topology := server.GetTopology()
+		if len(args) == 1 && args[0] == "dot" {
+			return topology.ToDot(), nil
+		}
+		if len(args) == 1 && args[0] != "json" {
+			return "", errors.New("unknown format")
+		}
+		json, err := json.Marshal(topology)
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["removeComponent"] = func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", errors.New("expected 1 argument (name)")
+		}
+		if args[0] == server.name {
+			return "", errors.New("cannot remove the dashboard itself")
+		}
+		if err := server.RemoveComponent(args[0]); err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	return commands
+}
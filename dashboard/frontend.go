@@ -0,0 +1,298 @@
+package dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/connectionWebsocket"
+	"github.com/neutralusername/systemge/reader"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+	"github.com/neutralusername/systemge/typedConnection"
+)
+
+//go:embed frontend
+var frontendFiles embed.FS
+
+type loginStatus struct {
+	PasswordRequired bool   `json:"passwordRequired"`
+	Authenticated    bool   `json:"authenticated"`
+	WebsocketPattern string `json:"websocketPattern"`
+}
+
+type loginRequest struct {
+	Password string `json:"password"`
+}
+
+func (server *Server) addFrontendRoutes() error {
+	files, err := fs.Sub(frontendFiles, "frontend")
+	if err != nil {
+		return err
+	}
+	fileServer := http.FileServer(http.FS(files))
+
+	server.httpServer.AddMethodRoute(http.MethodGet, "/{path*}", fileServer.ServeHTTP)
+	server.httpServer.AddMethodRoute(http.MethodGet, "/login", server.handleLoginStatus)
+	server.httpServer.AddMethodRoute(http.MethodPost, "/login", server.handleLogin)
+	server.httpServer.AddMethodRoute(http.MethodPost, "/logout", server.handleLogout)
+	server.httpServer.AddMethodRoute(http.MethodGet, "/topology", server.handleTopology)
+	server.httpServer.AddMethodRoute(http.MethodGet, "/topology.dot", server.handleTopologyDot)
+	return nil
+}
+
+func (server *Server) handleLoginStatus(w http.ResponseWriter, r *http.Request) {
+	sendJson(w, http.StatusOK, &loginStatus{
+		PasswordRequired: server.config.FrontendPasswordHash != "",
+		Authenticated:    server.isAuthenticated(r),
+		WebsocketPattern: server.config.WebsocketListenerConfig.Pattern,
+	})
+}
+
+func (server *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if server.config.FrontendPasswordHash == "" {
+		sendJson(w, http.StatusOK, &loginStatus{Authenticated: true})
+		return
+	}
+	request := &loginRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(request); err != nil {
+		sendJson(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if err := tools.ComparePassword(server.config.FrontendPasswordHash, request.Password); err != nil {
+		server.LoginsFailed.Add(1)
+		sendJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid password"})
+		return
+	}
+	identity, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		identity = r.RemoteAddr
+	}
+	session, err := server.sessionManager.CreateSession(identity, nil)
+	if err != nil {
+		server.LoginsFailed.Add(1)
+		sendJson(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	}
+	server.LoginsSucceeded.Add(1)
+	http.SetCookie(w, &http.Cookie{
+		Name:     server.config.SessionIdCookie,
+		Value:    session.GetId(),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	sendJson(w, http.StatusOK, &loginStatus{PasswordRequired: true, Authenticated: true})
+}
+
+func (server *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if session := server.getSession(r); session != nil {
+		session.GetTimeout().Trigger()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     server.config.SessionIdCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	sendJson(w, http.StatusOK, &loginStatus{PasswordRequired: server.config.FrontendPasswordHash != ""})
+}
+
+func (server *Server) handleTopology(w http.ResponseWriter, r *http.Request) {
+	if !server.isAuthenticated(r) {
+		sendJson(w, http.StatusUnauthorized, map[string]string{"error": "not authenticated"})
+		return
+	}
+	sendJson(w, http.StatusOK, server.GetTopology())
+}
+
+func (server *Server) handleTopologyDot(w http.ResponseWriter, r *http.Request) {
+	if !server.isAuthenticated(r) {
+		http.Error(w, "not authenticated", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+	w.Write([]byte(server.GetTopology().ToDot()))
+}
+
+func (server *Server) isAuthenticated(r *http.Request) bool {
+	return server.config.FrontendPasswordHash == "" || server.getSession(r) != nil
+}
+
+func (server *Server) getSession(r *http.Request) *tools.Session {
+	cookie, err := r.Cookie(server.config.SessionIdCookie)
+	if err != nil {
+		return nil
+	}
+	session := server.sessionManager.GetSession(cookie.Value)
+	if session == nil || !session.IsAccepted() {
+		return nil
+	}
+	return session
+}
+
+// accept handler of the websocket listener.
+// sends the registered components and their metrics history before pushing updates.
+func (server *Server) acceptFrontend(connection systemge.Connection[[]byte]) error {
+	messageConnection, err := typedConnection.New(connection, tools.JsonMarshalMessage, tools.JsonUnmarshalMessage)
+	if err != nil {
+		return err
+	}
+
+	componentsPayload, err := json.Marshal(server.getComponentInfos())
+	if err != nil {
+		return err
+	}
+	if err := messageConnection.Write(tools.NewAsync(TOPIC_COMPONENTS, string(componentsPayload)), server.config.FrontendWriteTimeoutNs); err != nil {
+		return err
+	}
+	for _, component := range server.getComponents() {
+		history, err := server.GetMetricsHistory(component.name)
+		if err != nil {
+			continue
+		}
+		historyPayload, err := json.Marshal(&ComponentMetricsHistory{
+			Name:    component.name,
+			Metrics: history,
+		})
+		if err != nil {
+			return err
+		}
+		if err := messageConnection.Write(tools.NewAsync(TOPIC_METRICS_HISTORY, string(historyPayload)), server.config.FrontendWriteTimeoutNs); err != nil {
+			return err
+		}
+	}
+
+	reader, err := reader.NewAsync(
+		messageConnection,
+		&configs.ReaderAsync{},
+		&configs.Routine{MaxConcurrentHandlers: 1},
+		server.handleFrontendMessage,
+	)
+	if err != nil {
+		return err
+	}
+	reader.SetEventHandler(newCloseOnReadFailedHandler(connection))
+
+	sessionId := ""
+	if websocketConnection, ok := connection.(*connectionWebsocket.WebsocketConnection); ok {
+		sessionId = websocketConnection.GetRequestMetadata().SessionId
+	}
+	server.mutex.Lock()
+	server.frontendConnections[messageConnection] = sessionId
+	server.mutex.Unlock()
+
+	// the session may have been removed since the upgrade was authorized
+	if server.config.FrontendPasswordHash != "" && server.sessionManager.GetSession(sessionId) == nil {
+		server.mutex.Lock()
+		delete(server.frontendConnections, messageConnection)
+		server.mutex.Unlock()
+		return errors.New("session expired")
+	}
+
+	if err := reader.GetRoutine().Start(); err != nil {
+		server.mutex.Lock()
+		delete(server.frontendConnections, messageConnection)
+		server.mutex.Unlock()
+		return err
+	}
+
+	go func() {
+		select {
+		case <-connection.GetCloseChannel():
+		case <-server.frontendAccepter.GetRoutine().GetStopChannel():
+			connection.Close()
+		}
+
+		// reader listens on connections' close channel
+
+		server.mutex.Lock()
+		delete(server.frontendConnections, messageConnection)
+		server.mutex.Unlock()
+	}()
+
+	return nil
+}
+
+// onRemoveSession handler of the session manager.
+// closes the frontend connections that were authorized by the session (logout or expiration).
+func (server *Server) closeSessionConnections(session *tools.Session) {
+	server.mutex.RLock()
+	connections := []systemge.Connection[*tools.Message]{}
+	for connection, sessionId := range server.frontendConnections {
+		if sessionId == session.GetId() {
+			connections = append(connections, connection)
+		}
+	}
+	server.mutex.RUnlock()
+
+	for _, connection := range connections {
+		connection.Close()
+	}
+}
+
+func (server *Server) handleFrontendMessage(message *tools.Message, connection systemge.Connection[*tools.Message]) {
+	switch message.GetTopic() {
+	case TOPIC_RUN_COMMAND:
+		// commands of remote components block until their response is received
+		go func() {
+			// a panicking command handler of a local component must not crash the dashboard
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					connection.Write(message.NewFailureResponse(fmt.Sprintf("command panicked: %v", recovered)), server.config.FrontendWriteTimeoutNs)
+				}
+			}()
+			commandRequest := &CommandRequest{}
+			if err := json.Unmarshal([]byte(message.GetPayload()), commandRequest); err != nil {
+				connection.Write(message.NewFailureResponse(err.Error()), server.config.FrontendWriteTimeoutNs)
+				return
+			}
+			result, err := server.ExecuteCommand(commandRequest.Name, commandRequest.Command.Command, commandRequest.Args)
+			if err != nil {
+				connection.Write(message.NewFailureResponse(err.Error()), server.config.FrontendWriteTimeoutNs)
+				return
+			}
+			connection.Write(message.NewSuccessResponse(result), server.config.FrontendWriteTimeoutNs)
+		}()
+
+	case TOPIC_REQUEST_METRICS:
+		go server.FetchMetrics(message.GetPayload())
+
+	default:
+		server.logger.Warn("unknown frontend topic", "topic", message.GetTopic(), "address", connection.GetAddress())
+	}
+}
+
+func (server *Server) broadcast(message *tools.Message) {
+	server.mutex.RLock()
+	connections := make([]systemge.Connection[*tools.Message], 0, len(server.frontendConnections))
+	for connection := range server.frontendConnections {
+		connections = append(connections, connection)
+	}
+	server.mutex.RUnlock()
+
+	systemge.MultiWrite(message, server.config.FrontendWriteTimeoutNs, connections)
+}
+
+func (server *Server) broadcastComponents() {
+	payload, err := json.Marshal(server.getComponentInfos())
+	if err != nil {
+		return
+	}
+	server.broadcast(tools.NewAsync(TOPIC_COMPONENTS, string(payload)))
+}
+
+func sendJson(w http.ResponseWriter, statusCode int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,357 @@
+"use strict";
+
+const SPARKLINE_WIDTH = 160;
+const SPARKLINE_HEIGHT = 24;
+
+const state = {
+  socket: null,
+  websocketPattern: "/ws",
+  passwordRequired: false,
+  components: [],
+  selected: null,
+  history: {}, // component name -> metrics type -> entries
+  pending: {}, // sync token -> callback
+  reconnectTimeout: null,
+  loggedOut: false,
+};
+
+const element = (id) => document.getElementById(id);
+
+function newSyncToken() {
+  const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789";
+  let token = "";
+  for (let i = 0; i < 32; i++) {
+    token += alphabet[Math.floor(Math.random() * alphabet.length)];
+  }
+  return token;
+}
+
+async function fetchJson(method, path, body) {
+  const response = await fetch(path, {
+    method: method,
+    headers: body !== undefined ? { "Content-Type": "application/json" } : {},
+    body: body !== undefined ? JSON.stringify(body) : undefined,
+    credentials: "same-origin",
+  });
+  let data = {};
+  try {
+    data = await response.json();
+  } catch (e) {}
+  return { status: response.status, data: data };
+}
+
+async function init() {
+  const { data } = await fetchJson("GET", "login");
+  state.websocketPattern = data.websocketPattern || state.websocketPattern;
+  state.passwordRequired = data.passwordRequired;
+  element("logout").classList.toggle("hidden", !state.passwordRequired);
+  if (data.authenticated) {
+    showDashboard();
+  } else {
+    showLogin("");
+  }
+}
+
+function showLogin(error) {
+  closeSocket();
+  element("dashboard").classList.add("hidden");
+  element("login").classList.remove("hidden");
+  element("loginError").textContent = error;
+  element("password").focus();
+}
+
+function showDashboard() {
+  element("login").classList.add("hidden");
+  element("dashboard").classList.remove("hidden");
+  state.loggedOut = false;
+  connect();
+}
+
+element("loginForm").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const { status, data } = await fetchJson("POST", "login", { password: element("password").value });
+  element("password").value = "";
+  if (status === 200 && data.authenticated) {
+    showDashboard();
+  } else {
+    showLogin(data.error || "login failed");
+  }
+});
+
+element("logout").addEventListener("click", async () => {
+  state.loggedOut = true;
+  await fetchJson("POST", "logout");
+  showLogin("");
+});
+
+function connect() {
+  closeSocket();
+  const protocol = location.protocol === "https:" ? "wss://" : "ws://";
+  const socket = new WebSocket(protocol + location.host + state.websocketPattern);
+  state.socket = socket;
+  socket.onopen = () => setConnectionStatus(true);
+  socket.onmessage = (event) => handleMessage(JSON.parse(event.data));
+  socket.onclose = async () => {
+    if (state.socket !== socket) {
+      return;
+    }
+    state.socket = null;
+    setConnectionStatus(false);
+    for (const token in state.pending) {
+      state.pending[token]({ topic: "failure", payload: "connection closed" });
+    }
+    state.pending = {};
+    if (state.loggedOut) {
+      return;
+    }
+    const { data } = await fetchJson("GET", "login");
+    if (!data.authenticated) {
+      showLogin("session expired");
+      return;
+    }
+    state.reconnectTimeout = setTimeout(connect, 2000);
+  };
+}
+
+function closeSocket() {
+  clearTimeout(state.reconnectTimeout);
+  if (state.socket) {
+    const socket = state.socket;
+    state.socket = null;
+    socket.close();
+  }
+  setConnectionStatus(false);
+}
+
+function setConnectionStatus(connected) {
+  const status = element("connectionStatus");
+  status.textContent = connected ? "connected" : "disconnected";
+  status.classList.toggle("connected", connected);
+}
+
+function send(topic, payload, onResponse) {
+  if (!state.socket || state.socket.readyState !== WebSocket.OPEN) {
+    if (onResponse) {
+      onResponse({ topic: "failure", payload: "not connected" });
+    }
+    return;
+  }
+  const message = { topic: topic, payload: payload, syncToken: "", isResponse: false };
+  if (onResponse) {
+    message.syncToken = newSyncToken();
+    state.pending[message.syncToken] = onResponse;
+  }
+  state.socket.send(JSON.stringify(message));
+}
+
+function handleMessage(message) {
+  if (message.isResponse) {
+    const onResponse = state.pending[message.syncToken];
+    delete state.pending[message.syncToken];
+    if (onResponse) {
+      onResponse(message);
+    }
+    return;
+  }
+  switch (message.topic) {
+    case "components":
+      state.components = JSON.parse(message.payload) || [];
+      for (const name in state.history) {
+        if (!state.components.some((component) => component.name === name)) {
+          delete state.history[name];
+        }
+      }
+      if (state.selected && !state.components.some((component) => component.name === state.selected)) {
+        state.selected = null;
+      }
+      renderComponents();
+      renderComponent();
+      break;
+    case "metricsHistory": {
+      const history = JSON.parse(message.payload);
+      state.history[history.name] = history.metrics || {};
+      if (history.name === state.selected) {
+        renderMetrics();
+      }
+      break;
+    }
+    case "metrics": {
+      const metrics = JSON.parse(message.payload);
+      addMetrics(metrics.name, metrics.metrics || {});
+      if (metrics.name === state.selected) {
+        renderMetrics();
+      }
+      break;
+    }
+  }
+}
+
+function addMetrics(name, metricsTypes) {
+  const history = state.history[name] || (state.history[name] = {});
+  for (const metricsType in metricsTypes) {
+    const entries = history[metricsType] || (history[metricsType] = []);
+    entries.push(metricsTypes[metricsType]);
+    if (entries.length > 100) {
+      entries.splice(0, entries.length - 100);
+    }
+  }
+}
+
+function getSelectedComponent() {
+  return state.components.find((component) => component.name === state.selected);
+}
+
+function renderComponents() {
+  const list = element("components");
+  list.textContent = "";
+  for (const component of state.components) {
+    const item = document.createElement("li");
+    item.textContent = component.name;
+    item.title = component.address ? component.name + " (" + component.address + ")" : component.name;
+    item.classList.toggle("selected", component.name === state.selected);
+    item.addEventListener("click", () => {
+      state.selected = component.name;
+      element("commandResult").textContent = "";
+      renderComponents();
+      renderComponent();
+      send("requestMetrics", component.name);
+    });
+    list.appendChild(item);
+  }
+}
+
+function renderComponent() {
+  const component = getSelectedComponent();
+  element("empty").classList.toggle("hidden", !!component);
+  element("component").classList.toggle("hidden", !component);
+  if (!component) {
+    return;
+  }
+  element("componentName").textContent = component.name;
+  element("componentAddress").textContent = component.address || "local";
+
+  const select = element("command");
+  const previous = select.value;
+  select.textContent = "";
+  for (const command of component.commands || []) {
+    const option = document.createElement("option");
+    option.value = command;
+    option.textContent = command;
+    select.appendChild(option);
+  }
+  if ((component.commands || []).includes(previous)) {
+    select.value = previous;
+  }
+  renderUsage();
+  renderMetrics();
+}
+
+function renderUsage() {
+  const component = getSelectedComponent();
+  const description = component && component.commandDescriptions ? component.commandDescriptions[element("command").value] : null;
+  if (!description) {
+    element("commandUsage").textContent = "";
+    return;
+  }
+  const args = (description.args || []).map((arg) => {
+    let usage = arg.name + ":" + (arg.type || "string") + (arg.variadic ? "..." : "");
+    return arg.optional ? "[" + usage + "]" : "<" + usage + ">";
+  });
+  element("commandUsage").textContent = [description.description, args.join(" ")].filter((part) => part).join(" - ");
+}
+
+element("command").addEventListener("change", renderUsage);
+
+element("commandForm").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const component = getSelectedComponent();
+  const command = element("command").value;
+  if (!component || !command) {
+    return;
+  }
+  const args = element("args").value.split(" ").filter((arg) => arg !== "");
+  const result = element("commandResult");
+  result.classList.remove("failure");
+  result.textContent = "...";
+  send("runCommand", JSON.stringify({ name: component.name, command: command, args: args }), (response) => {
+    result.classList.toggle("failure", response.topic !== "success");
+    result.textContent = formatResult(response.payload);
+  });
+});
+
+element("refreshMetrics").addEventListener("click", () => {
+  if (state.selected) {
+    send("requestMetrics", state.selected);
+  }
+});
+
+function formatResult(payload) {
+  try {
+    return JSON.stringify(JSON.parse(payload), null, 2);
+  } catch (e) {
+    return payload;
+  }
+}
+
+function renderMetrics() {
+  const container = element("metrics");
+  container.textContent = "";
+  const history = state.history[state.selected] || {};
+  for (const metricsType of Object.keys(history).sort()) {
+    const entries = history[metricsType];
+    if (!entries || entries.length === 0) {
+      continue;
+    }
+    const latest = entries[entries.length - 1];
+    const section = document.createElement("div");
+    section.className = "metricsType";
+
+    const title = document.createElement("h4");
+    title.textContent = metricsType;
+    section.appendChild(title);
+
+    const time = document.createElement("div");
+    time.className = "metricsTime";
+    time.textContent = new Date(latest.time).toLocaleString();
+    section.appendChild(time);
+
+    const table = document.createElement("table");
+    for (const key of Object.keys(latest.keyValuePairs || {}).sort()) {
+      const row = document.createElement("tr");
+      const keyCell = document.createElement("td");
+      keyCell.textContent = key;
+      const valueCell = document.createElement("td");
+      valueCell.className = "value";
+      valueCell.textContent = latest.keyValuePairs[key];
+      const chartCell = document.createElement("td");
+      chartCell.appendChild(sparkline(entries.map((entry) => (entry.keyValuePairs || {})[key] || 0)));
+      row.append(keyCell, valueCell, chartCell);
+      table.appendChild(row);
+    }
+    section.appendChild(table);
+    container.appendChild(section);
+  }
+}
+
+function sparkline(values) {
+  const svg = document.createElementNS("http://www.w3.org/2000/svg", "svg");
+  svg.setAttribute("width", SPARKLINE_WIDTH);
+  svg.setAttribute("height", SPARKLINE_HEIGHT);
+  if (values.length < 2) {
+    return svg;
+  }
+  const min = Math.min(...values);
+  const max = Math.max(...values);
+  const range = max - min || 1;
+  const points = values.map((value, i) => {
+    const x = (i / (values.length - 1)) * SPARKLINE_WIDTH;
+    const y = SPARKLINE_HEIGHT - 2 - ((value - min) / range) * (SPARKLINE_HEIGHT - 4);
+    return x.toFixed(1) + "," + y.toFixed(1);
+  });
+  const polyline = document.createElementNS("http://www.w3.org/2000/svg", "polyline");
+  polyline.setAttribute("points", points.join(" "));
+  svg.appendChild(polyline);
+  return svg;
+}
+
+init();
@@ -0,0 +1,37 @@
+package dashboard
+
+import (
+	"sync/atomic"
+
+	"github.com/neutralusername/systemge/tools"
+)
+
+func (server *Server) CheckMetrics() tools.MetricsTypes {
+	return server.getMetrics(func(value *atomic.Uint64) uint64 { return value.Load() })
+}
+
+func (server *Server) GetMetrics() tools.MetricsTypes {
+	return server.getMetrics(func(value *atomic.Uint64) uint64 { return value.Swap(0) })
+}
+
+func (server *Server) getMetrics(load func(*atomic.Uint64) uint64) tools.MetricsTypes {
+	server.mutex.RLock()
+	components := len(server.components)
+	frontendConnections := len(server.frontendConnections)
+	server.mutex.RUnlock()
+
+	metricsTypes := tools.NewMetricsTypes()
+	metricsTypes.AddMetrics("dashboard_server", tools.NewMetrics(
+		map[string]uint64{
+			"components":           uint64(components),
+			"frontendConnections":  uint64(frontendConnections),
+			"componentsRegistered": load(&server.ComponentsRegistered),
+			"componentsRejected":   load(&server.ComponentsRejected),
+			"commandsSucceeded":    load(&server.CommandsSucceeded),
+			"commandsFailed":       load(&server.CommandsFailed),
+			"loginsSucceeded":      load(&server.LoginsSucceeded),
+			"loginsFailed":         load(&server.LoginsFailed),
+		},
+	))
+	return metricsTypes
+}
@@ -0,0 +1,280 @@
+package dashboard
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/neutralusername/systemge/accepter"
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/httpServer"
+	"github.com/neutralusername/systemge/listenerWebsocket"
+	"github.com/neutralusername/systemge/status"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// serves the embedded frontend, pushes metrics of the registered components to it and executes their commands.
+// components are either added locally or connect through the component listener and introduce themselves (see Client).
+type Server struct {
+	name   string
+	config *configs.DashboardServer
+
+	status      *status.Machine
+	statusMutex sync.Mutex // serializes Start and Stop
+	stopChannel chan struct{}
+	waitGroup   sync.WaitGroup
+
+	httpServer        *httpServer.HTTPServer
+	sessionManager    *tools.SessionManager
+	websocketListener systemge.Listener[[]byte]
+	frontendAccepter  *accepter.Accepter[[]byte]
+	componentListener systemge.Listener[[]byte]
+	componentAccepter *accepter.Accepter[[]byte]
+
+	requestResponseManager *tools.RequestResponseManager[*tools.Message]
+
+	logger *tools.Logger
+
+	mutex               sync.RWMutex
+	components          map[string]*component
+	frontendConnections map[systemge.Connection[*tools.Message]]string // connection -> session id ("" without password)
+
+	// metrics
+
+	ComponentsRegistered atomic.Uint64
+	ComponentsRejected   atomic.Uint64
+	CommandsSucceeded    atomic.Uint64
+	CommandsFailed       atomic.Uint64
+	LoginsSucceeded      atomic.Uint64
+	LoginsFailed         atomic.Uint64
+}
+
+// componentListener accepts remote components and may be nil if only local components are used.
+// the listener is started and stopped together with the server.
+// the dashboard registers itself as a local component under its name.
+func New(name string, config *configs.DashboardServer, componentListener systemge.Listener[[]byte]) (*Server, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if config.HTTPServerConfig == nil {
+		return nil, errors.New("httpServerConfig is nil")
+	}
+	if config.WebsocketListenerConfig == nil {
+		return nil, errors.New("websocketListenerConfig is nil")
+	}
+	if config.SessionManagerConfig == nil {
+		return nil, errors.New("sessionManagerConfig is nil")
+	}
+	if config.FrontendPasswordHash == "" && !config.AllowUnauthenticated {
+		return nil, errors.New("frontendPasswordHash is empty (set allowUnauthenticated to run the frontend without a password)")
+	}
+	if config.SessionIdCookie == "" {
+		config.SessionIdCookie = "systemge_dashboard_session"
+	}
+	if config.MaxEntriesPerMetrics <= 0 {
+		config.MaxEntriesPerMetrics = 100
+	}
+	config.WebsocketListenerConfig.SessionIdCookie = config.SessionIdCookie
+
+	server := &Server{
+		name:                   name,
+		config:                 config,
+		status:                 status.NewMachine(),
+		componentListener:      componentListener,
+		requestResponseManager: tools.NewRequestResponseManager[*tools.Message](nil),
+		components:             make(map[string]*component),
+		frontendConnections:    make(map[systemge.Connection[*tools.Message]]string),
+	}
+	server.sessionManager = tools.NewSessionManager(config.SessionManagerConfig, nil, server.closeSessionConnections)
+
+	httpServer, err := httpServer.New(name+"_httpServer", config.HTTPServerConfig, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	server.httpServer = httpServer
+	if err := server.addFrontendRoutes(); err != nil {
+		return nil, err
+	}
+
+	websocketListener, err := listenerWebsocket.NewOnHTTPServer(name+"_websocketListener", httpServer, config.WebsocketListenerConfig, config.IncomingMessageByteLimit)
+	if err != nil {
+		return nil, err
+	}
+	if config.FrontendPasswordHash != "" {
+		websocketListener.(*listenerWebsocket.WebsocketListener).SetUpgradeAuthorizer(listenerWebsocket.NewSessionAuthorizer(server.sessionManager))
+	}
+	server.websocketListener = websocketListener
+
+	frontendAccepter, err := accepter.New(
+		websocketListener,
+		&configs.Accepter{HandleAcceptsConcurrently: true},
+		&configs.Routine{MaxConcurrentHandlers: 1},
+		server.acceptFrontend,
+	)
+	if err != nil {
+		return nil, err
+	}
+	server.frontendAccepter = frontendAccepter
+
+	if componentListener != nil {
+		componentAccepter, err := accepter.New(
+			componentListener,
+			&configs.Accepter{HandleAcceptsConcurrently: true},
+			&configs.Routine{MaxConcurrentHandlers: 1},
+			server.acceptComponent,
+		)
+		if err != nil {
+			return nil, err
+		}
+		server.componentAccepter = componentAccepter
+	}
+
+	if err := server.AddLocalComponent(name, server.GetDefaultCommands(), nil, server.CheckMetrics, server.getOwnTopology); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// unexpected messages are logged to logger. it is passed on to the http server and the accepters.
+// should be called before the server is started.
+func (server *Server) SetLogger(logger *tools.Logger) {
+	server.logger = logger
+	server.httpServer.SetLogger(logger)
+	server.frontendAccepter.SetLogger(logger)
+	if server.componentAccepter != nil {
+		server.componentAccepter.SetLogger(logger)
+	}
+}
+
+func (server *Server) Start() error {
+	server.statusMutex.Lock()
+	defer server.statusMutex.Unlock()
+
+	if err := server.status.Transition(status.Starting, nil); err != nil {
+		return errors.New("dashboard not stopped")
+	}
+
+	// a failed start stops the components that were already started
+	if err := server.sessionManager.Start(); err != nil {
+		return server.status.Fail(err)
+	}
+	if server.componentListener != nil {
+		if err := server.componentListener.Start(); err != nil {
+			server.sessionManager.Stop()
+			return server.status.Fail(err)
+		}
+	}
+	if err := server.websocketListener.Start(); err != nil {
+		server.stopComponentListener()
+		server.sessionManager.Stop()
+		return server.status.Fail(err)
+	}
+	if err := server.httpServer.Start(); err != nil {
+		server.websocketListener.Stop()
+		server.stopComponentListener()
+		server.sessionManager.Stop()
+		return server.status.Fail(err)
+	}
+
+	server.stopChannel = make(chan struct{})
+	server.frontendAccepter.GetRoutine().Start()
+	if server.componentAccepter != nil {
+		server.componentAccepter.GetRoutine().Start()
+	}
+	if server.config.UpdateIntervalNs > 0 {
+		server.waitGroup.Add(1)
+		go server.updateRoutine(server.stopChannel)
+	}
+
+	server.status.Transition(status.Started, nil)
+	return nil
+}
+
+func (server *Server) Stop() error {
+	server.statusMutex.Lock()
+	defer server.statusMutex.Unlock()
+
+	if !server.status.Is(status.Started) {
+		return errors.New("dashboard not started")
+	}
+	server.status.Transition(status.Stopping, nil)
+
+	close(server.stopChannel)
+	server.frontendAccepter.GetRoutine().Stop()
+	if server.componentAccepter != nil {
+		server.componentAccepter.GetRoutine().Stop()
+	}
+
+	// closing the component connections unblocks pending metrics requests of the update routine
+	server.mutex.RLock()
+	for connection := range server.frontendConnections {
+		connection.Close()
+	}
+	for _, component := range server.components {
+		if component.connection != nil {
+			component.connection.Close()
+		}
+	}
+	server.mutex.RUnlock()
+	server.waitGroup.Wait()
+
+	server.websocketListener.Stop()
+	server.stopComponentListener()
+	server.httpServer.Stop()
+	server.sessionManager.Stop()
+
+	server.status.Transition(status.Stopped, nil)
+	return nil
+}
+
+func (server *Server) stopComponentListener() {
+	if server.componentListener != nil {
+		server.componentListener.Stop()
+	}
+}
+
+func (server *Server) GetStatus() int {
+	return server.status.Get()
+}
+
+func (server *Server) GetStatusMachine() *status.Machine {
+	return server.status
+}
+
+func (server *Server) GetName() string {
+	return server.name
+}
+
+func (server *Server) GetHTTPServer() *httpServer.HTTPServer {
+	return server.httpServer
+}
+
+func (server *Server) GetSessionManager() *tools.SessionManager {
+	return server.sessionManager
+}
+
+// fetches the metrics of all components every UpdateIntervalNs and pushes them to the frontend.
+func (server *Server) updateRoutine(stopChannel <-chan struct{}) {
+	defer server.waitGroup.Done()
+
+	ticker := time.NewTicker(time.Duration(server.config.UpdateIntervalNs))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopChannel:
+			return
+		case <-ticker.C:
+			waitGroup := sync.WaitGroup{}
+			for _, registeredComponent := range server.getComponents() {
+				waitGroup.Add(1)
+				go func(registeredComponent *component) {
+					defer waitGroup.Done()
+					server.updateMetrics(registeredComponent)
+				}(registeredComponent)
+			}
+			waitGroup.Wait()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package dashboard
+
+import (
+	"github.com/neutralusername/systemge/tools"
+)
+
+// component <-> dashboard
+const (
+	TOPIC_INTRODUCTION = "introduction" // sync, component -> dashboard. payload: Introduction. response payload: instance id of the component listener
+	TOPIC_COMMAND      = "command"      // sync, dashboard -> component. payload: tools.Command
+	TOPIC_GET_METRICS  = "getMetrics"   // sync, dashboard -> component. response payload: tools.MetricsTypes
+	TOPIC_GET_TOPOLOGY = "getTopology"  // sync, dashboard -> component. response payload: ComponentTopology
+)
+
+// frontend <-> dashboard
+const (
+	TOPIC_COMPONENTS      = "components"     // async, dashboard -> frontend. payload: []*ComponentInfo
+	TOPIC_METRICS         = "metrics"        // async, dashboard -> frontend. payload: ComponentMetrics
+	TOPIC_METRICS_HISTORY = "metricsHistory" // async, dashboard -> frontend. payload: ComponentMetricsHistory
+	TOPIC_RUN_COMMAND     = "runCommand"     // sync, frontend -> dashboard. payload: CommandRequest
+	TOPIC_REQUEST_METRICS = "requestMetrics" // async, frontend -> dashboard. payload: component name
+)
+
+type Introduction struct {
+	Name                string                    `json:"name"`
+	Commands            []string                  `json:"commands"`
+	CommandDescriptions tools.CommandDescriptions `json:"commandDescriptions"`
+}
+
+type ComponentInfo struct {
+	Name                string                    `json:"name"`
+	Address             string                    `json:"address"` // empty for local components
+	Commands            []string                  `json:"commands"`
+	CommandDescriptions tools.CommandDescriptions `json:"commandDescriptions"`
+}
+
+type ComponentMetrics struct {
+	Name    string             `json:"name"`
+	Metrics tools.MetricsTypes `json:"metrics"`
+}
+
+type ComponentMetricsHistory struct {
+	Name    string                      `json:"name"`
+	Metrics map[string][]*tools.Metrics `json:"metrics"` // metrics type -> entries, oldest first
+}
+
+type CommandRequest struct {
+	Name string `json:"name"`
+	tools.Command
+}
@@ -0,0 +1,318 @@
+package dashboard
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neutralusername/systemge/systemge"
+)
+
+// types of the issues detected while assembling a topology.
+const (
+	TOPOLOGY_ISSUE_DANGLING  = "dangling"  // the target of a connection is not a listener of any registered component
+	TOPOLOGY_ISSUE_AMBIGUOUS = "ambiguous" // the address of a connection matches several listeners (set ListenerName to resolve it)
+	TOPOLOGY_ISSUE_DUPLICATE = "duplicate" // a component has several connections to the same listener or several listeners share an instance id
+)
+
+// returns the listeners and outbound connections of a component.
+type TopologySource func() *ComponentTopology
+
+type ListenerInfo struct {
+	Name       string `json:"name"`
+	InstanceId string `json:"instanceId"`
+	SessionId  string `json:"sessionId"`
+	Address    string `json:"address"` // empty if the listener is not reachable through an address (e.g. channel listeners)
+}
+
+type ConnectionInfo struct {
+	InstanceId         string `json:"instanceId"`
+	Address            string `json:"address"`            // remote address of the connection
+	ListenerName       string `json:"listenerName"`       // optional. narrows down the listeners matching the address
+	ListenerInstanceId string `json:"listenerInstanceId"` // optional. identifies the target listener regardless of the address
+}
+
+// reported by a component. payload of the response to TOPIC_GET_TOPOLOGY.
+type ComponentTopology struct {
+	Listeners   []*ListenerInfo   `json:"listeners"`
+	Connections []*ConnectionInfo `json:"connections"` // outbound connections
+}
+
+type TopologyComponent struct {
+	Name        string            `json:"name"`
+	Address     string            `json:"address"` // empty for local components
+	Listeners   []*ListenerInfo   `json:"listeners"`
+	Connections []*ConnectionInfo `json:"connections"`
+	Error       string            `json:"error"` // set if the component did not report its topology
+}
+
+// a link from a component to the listener of another (or the same) component.
+// Target and Listener are empty for dangling links.
+type TopologyLink struct {
+	Source             string `json:"source"`
+	Target             string `json:"target"`
+	Listener           string `json:"listener"`
+	ListenerInstanceId string `json:"listenerInstanceId"`
+	Address            string `json:"address"`
+	Connections        int    `json:"connections"`
+}
+
+type TopologyIssue struct {
+	Type      string `json:"type"`
+	Component string `json:"component"`
+	Listener  string `json:"listener"`
+	Address   string `json:"address"`
+	Message   string `json:"message"`
+}
+
+type Topology struct {
+	Components []*TopologyComponent `json:"components"`
+	Links      []*TopologyLink      `json:"links"`
+	Issues     []*TopologyIssue     `json:"issues"`
+}
+
+// the address is taken from the listener's GetAddress method if it has one.
+func NewListenerInfo[T any](listener systemge.Listener[T]) *ListenerInfo {
+	info := &ListenerInfo{
+		Name:       listener.GetName(),
+		InstanceId: listener.GetInstanceId(),
+		SessionId:  listener.GetSessionId(),
+	}
+	if addressListener, ok := listener.(interface{ GetAddress() string }); ok {
+		info.Address = addressListener.GetAddress()
+	}
+	return info
+}
+
+// listenerName may be empty.
+func NewConnectionInfo[T any](connection systemge.Connection[T], listenerName string) *ConnectionInfo {
+	return &ConnectionInfo{
+		InstanceId:   connection.GetInstanceId(),
+		Address:      connection.GetAddress(),
+		ListenerName: listenerName,
+	}
+}
+
+type topologyListener struct {
+	component string
+	info      *ListenerInfo
+}
+
+// links the connections of the components to the listeners of the components and detects dangling and duplicate links.
+func newTopology(components []*TopologyComponent) *Topology {
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
+	topology := &Topology{
+		Components: components,
+		Links:      []*TopologyLink{},
+		Issues:     []*TopologyIssue{},
+	}
+
+	listeners := []*topologyListener{}
+	listenersByInstanceId := map[string]*topologyListener{}
+	for _, component := range components {
+		for _, info := range component.Listeners {
+			if info == nil {
+				continue
+			}
+			listener := &topologyListener{component: component.Name, info: info}
+			if info.InstanceId != "" {
+				if existing, ok := listenersByInstanceId[info.InstanceId]; ok {
+					topology.Issues = append(topology.Issues, &TopologyIssue{
+						Type:      TOPOLOGY_ISSUE_DUPLICATE,
+						Component: component.Name,
+						Listener:  info.Name,
+						Address:   info.Address,
+						Message:   "listener instance id is also reported by \"" + existing.component + "\"",
+					})
+					continue
+				}
+				listenersByInstanceId[info.InstanceId] = listener
+			}
+			listeners = append(listeners, listener)
+		}
+	}
+
+	links := map[[2]string]*TopologyLink{}
+	for _, component := range components {
+		for _, connection := range component.Connections {
+			if connection == nil {
+				continue
+			}
+			targets := findListeners(listeners, listenersByInstanceId, connection)
+			switch len(targets) {
+			case 1:
+				key := [2]string{component.Name, targets[0].info.InstanceId}
+				if link, ok := links[key]; ok {
+					link.Connections++
+					continue
+				}
+				link := &TopologyLink{
+					Source:             component.Name,
+					Target:             targets[0].component,
+					Listener:           targets[0].info.Name,
+					ListenerInstanceId: targets[0].info.InstanceId,
+					Address:            targets[0].info.Address,
+					Connections:        1,
+				}
+				links[key] = link
+				topology.Links = append(topology.Links, link)
+
+			case 0:
+				key := [2]string{component.Name, "\x00" + connection.Address}
+				if link, ok := links[key]; ok {
+					link.Connections++
+					continue
+				}
+				link := &TopologyLink{
+					Source:      component.Name,
+					Address:     connection.Address,
+					Connections: 1,
+				}
+				links[key] = link
+				topology.Links = append(topology.Links, link)
+				topology.Issues = append(topology.Issues, &TopologyIssue{
+					Type:      TOPOLOGY_ISSUE_DANGLING,
+					Component: component.Name,
+					Listener:  connection.ListenerName,
+					Address:   connection.Address,
+					Message:   "no registered component reports a matching listener",
+				})
+
+			default:
+				names := make([]string, 0, len(targets))
+				for _, target := range targets {
+					names = append(names, target.component+"/"+target.info.Name)
+				}
+				topology.Issues = append(topology.Issues, &TopologyIssue{
+					Type:      TOPOLOGY_ISSUE_AMBIGUOUS,
+					Component: component.Name,
+					Listener:  connection.ListenerName,
+					Address:   connection.Address,
+					Message:   "address matches the listeners " + strings.Join(names, ", "),
+				})
+			}
+		}
+	}
+
+	for _, link := range topology.Links {
+		if link.Target != "" && link.Connections > 1 {
+			topology.Issues = append(topology.Issues, &TopologyIssue{
+				Type:      TOPOLOGY_ISSUE_DUPLICATE,
+				Component: link.Source,
+				Listener:  link.Listener,
+				Address:   link.Address,
+				Message:   strconv.Itoa(link.Connections) + " connections to the same listener",
+			})
+		}
+	}
+	return topology
+}
+
+// exact address matches take precedence over listeners bound to all interfaces.
+func findListeners(listeners []*topologyListener, listenersByInstanceId map[string]*topologyListener, connection *ConnectionInfo) []*topologyListener {
+	if connection.ListenerInstanceId != "" {
+		if listener, ok := listenersByInstanceId[connection.ListenerInstanceId]; ok {
+			return []*topologyListener{listener}
+		}
+		return nil
+	}
+	exact := []*topologyListener{}
+	unspecified := []*topologyListener{}
+	for _, listener := range listeners {
+		if connection.ListenerName != "" && listener.info.Name != connection.ListenerName {
+			continue
+		}
+		switch matchAddress(listener.info.Address, connection.Address) {
+		case addressMatchExact:
+			exact = append(exact, listener)
+		case addressMatchPort:
+			unspecified = append(unspecified, listener)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return unspecified
+}
+
+const (
+	addressMatchNone = iota
+	addressMatchExact
+	addressMatchPort // the listener is bound to all interfaces and the ports are equal
+)
+
+func matchAddress(listenerAddress string, connectionAddress string) int {
+	if listenerAddress == "" || connectionAddress == "" {
+		return addressMatchNone
+	}
+	if listenerAddress == connectionAddress {
+		return addressMatchExact
+	}
+	listenerHost, listenerPort, err := net.SplitHostPort(listenerAddress)
+	if err != nil {
+		return addressMatchNone
+	}
+	connectionHost, connectionPort, err := net.SplitHostPort(connectionAddress)
+	if err != nil || listenerPort != connectionPort {
+		return addressMatchNone
+	}
+	listenerIp := net.ParseIP(listenerHost)
+	connectionIp := net.ParseIP(connectionHost)
+	if listenerIp != nil && connectionIp != nil && listenerIp.Equal(connectionIp) {
+		return addressMatchExact
+	}
+	if listenerHost == "" || (listenerIp != nil && listenerIp.IsUnspecified()) {
+		return addressMatchPort
+	}
+	return addressMatchNone
+}
+
+// returns the topology in the graphviz dot language.
+// dangling links point to a node of their address and are drawn dashed, duplicate links are drawn red.
+func (topology *Topology) ToDot() string {
+	builder := strings.Builder{}
+	builder.WriteString("digraph systemge {\n")
+	builder.WriteString("\trankdir=LR;\n")
+	builder.WriteString("\tnode [shape=box];\n")
+	for _, component := range topology.Components {
+		label := component.Name
+		if component.Address != "" {
+			label += "\n" + component.Address
+		}
+		for _, listener := range component.Listeners {
+			if listener == nil {
+				continue
+			}
+			label += "\n[" + listener.Name
+			if listener.Address != "" {
+				label += " " + listener.Address
+			}
+			label += "]"
+		}
+		attributes := "label=" + strconv.Quote(label)
+		if component.Error != "" {
+			attributes += ", color=gray, fontcolor=gray, tooltip=" + strconv.Quote(component.Error)
+		}
+		builder.WriteString("\t" + strconv.Quote(component.Name) + " [" + attributes + "];\n")
+	}
+	for _, link := range topology.Links {
+		if link.Target == "" {
+			node := strconv.Quote("dangling:" + link.Address)
+			builder.WriteString("\t" + node + " [label=" + strconv.Quote(link.Address) + ", shape=plaintext, fontcolor=red];\n")
+			builder.WriteString("\t" + strconv.Quote(link.Source) + " -> " + node + " [style=dashed, color=red];\n")
+			continue
+		}
+		label := link.Listener
+		attributes := ""
+		if link.Connections > 1 {
+			label += " (x" + strconv.Itoa(link.Connections) + ")"
+			attributes = ", color=red"
+		}
+		builder.WriteString("\t" + strconv.Quote(link.Source) + " -> " + strconv.Quote(link.Target) + " [label=" + strconv.Quote(label) + attributes + "];\n")
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
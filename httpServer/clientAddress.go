@@ -0,0 +1,79 @@
+package httpServer
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/neutralusername/systemge/tools"
+)
+
+// resolves the client address of httpRequest. trustedProxies may be nil, in which case httpRequest.RemoteAddr is returned.
+// walks the chain of forwarded addresses from the right and skips every trusted proxy.
+// returns httpRequest.RemoteAddr if the direct peer is not a trusted proxy.
+// forwarded addresses carry no usable port, so resolved addresses use port 0.
+func ResolveClientAddress(httpRequest *http.Request, trustedProxies *tools.AccessControlList) string {
+	if trustedProxies == nil {
+		return httpRequest.RemoteAddr
+	}
+	ip, _, err := net.SplitHostPort(httpRequest.RemoteAddr)
+	if err != nil || !trustedProxies.Contains(ip) {
+		return httpRequest.RemoteAddr
+	}
+
+	chain := getForwardedFor(httpRequest.Header)
+	if len(chain) == 0 {
+		return httpRequest.RemoteAddr
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip = chain[i]
+		if !trustedProxies.Contains(ip) {
+			break
+		}
+	}
+	return net.JoinHostPort(ip, "0")
+}
+
+// returns the forwarded client ips in order of the hops.
+// the "Forwarded" header takes precedence over "X-Forwarded-For".
+func getForwardedFor(header http.Header) []string {
+	ips := []string{}
+	if forwardedHeaders := header.Values("Forwarded"); len(forwardedHeaders) > 0 {
+		for _, forwardedHeader := range forwardedHeaders {
+			for _, element := range strings.Split(forwardedHeader, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+					if !ok || !strings.EqualFold(key, "for") {
+						continue
+					}
+					if ip := parseForwardedNode(value); ip != "" {
+						ips = append(ips, ip)
+					}
+				}
+			}
+		}
+		return ips
+	}
+	for _, forwardedForHeader := range header.Values("X-Forwarded-For") {
+		for _, value := range strings.Split(forwardedForHeader, ",") {
+			if ip := parseForwardedNode(value); ip != "" {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips
+}
+
+// parses values such as `192.0.2.60`, `"[2001:db8:cafe::17]:4711"` or `198.51.100.17:80`.
+// returns an empty string for obfuscated identifiers such as "unknown" or "_hidden".
+func parseForwardedNode(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), "\"")
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	if net.ParseIP(value) == nil {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,277 @@
+package httpServer
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// exposes named sets of command handlers as a json api.
+// names may contain "/" to form a tree (e.g. "node1/httpServer").
+//
+// routes (relative to the prefix the api is mounted on):
+//   - GET  /        lists the commands of all sets
+//   - GET  /{name*} lists the commands of the set name and of all sets below it
+//   - POST /{name*} executes a command of the set name. body: {"command": "...", "args": [...]}
+//
+// args may be any json values. strings are passed as they are, everything else as its json representation.
+// every request must be authenticated by an api key or an accepted session.
+type CommandApi struct {
+	config         *configs.CommandApi
+	sessionManager *tools.SessionManager
+	auditLogger    *tools.Logger
+
+	mutex               sync.RWMutex
+	commandHandlers     map[string]tools.CommandHandlers
+	commandDescriptions map[string]tools.CommandDescriptions
+}
+
+type CommandApiRequest struct {
+	Command string            `json:"command"`
+	Args    []json.RawMessage `json:"args"`
+}
+
+type CommandApiResponse struct {
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type CommandApiList struct {
+	CommandHandlers     map[string][]string                  `json:"commandHandlers"`               // name -> sorted command names
+	CommandDescriptions map[string]tools.CommandDescriptions `json:"commandDescriptions,omitempty"` // name -> descriptions of the commands that have one
+}
+
+// sessionManager may be nil if no session authentication is required.
+func NewCommandApi(config *configs.CommandApi, sessionManager *tools.SessionManager) (*CommandApi, error) {
+	if config == nil {
+		config = &configs.CommandApi{}
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = 1 << 20
+	}
+	var auditLogger *tools.Logger
+	if config.AuditLogger != nil {
+		logger, err := tools.NewLogger(config.AuditLogger, "component", "commandApi")
+		if err != nil {
+			return nil, err
+		}
+		auditLogger = logger
+	}
+	return &CommandApi{
+		config:              config,
+		sessionManager:      sessionManager,
+		auditLogger:         auditLogger,
+		commandHandlers:     make(map[string]tools.CommandHandlers),
+		commandDescriptions: make(map[string]tools.CommandDescriptions),
+	}, nil
+}
+
+func (api *CommandApi) AddCommandHandlers(name string, commandHandlers tools.CommandHandlers) error {
+	return api.AddDescribedCommandHandlers(name, commandHandlers, nil)
+}
+
+// commandDescriptions are included in the list output, e.g. to render forms for the commands.
+func (api *CommandApi) AddDescribedCommandHandlers(name string, commandHandlers tools.CommandHandlers, commandDescriptions tools.CommandDescriptions) error {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	if _, ok := api.commandHandlers[name]; ok {
+		return errors.New("command handlers already exist")
+	}
+	api.commandHandlers[name] = commandHandlers
+	if len(commandDescriptions) > 0 {
+		api.commandDescriptions[name] = commandDescriptions
+	}
+	return nil
+}
+
+func (api *CommandApi) RemoveCommandHandlers(name string) error {
+	name = strings.Trim(name, "/")
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	if _, ok := api.commandHandlers[name]; !ok {
+		return errors.New("command handlers do not exist")
+	}
+	delete(api.commandHandlers, name)
+	delete(api.commandDescriptions, name)
+	return nil
+}
+
+// adds the api's routes to server below prefix.
+func (api *CommandApi) Mount(server *HTTPServer, prefix string) {
+	group := server.Group(prefix)
+	group.AddMethodRoute(http.MethodGet, "/{name*}", api.handleList)
+	group.AddMethodRoute(http.MethodPost, "/{name*}", api.handleExecute)
+}
+
+func (api *CommandApi) Unmount(server *HTTPServer, prefix string) {
+	group := server.Group(prefix)
+	group.RemoveMethodRoute(http.MethodGet, "/{name*}")
+	group.RemoveMethodRoute(http.MethodPost, "/{name*}")
+}
+
+func (api *CommandApi) handleList(w http.ResponseWriter, r *http.Request) {
+	identity, err := api.authenticate(r)
+	if err != nil {
+		api.audit(r, "", "list", "", nil, err)
+		sendJson(w, http.StatusUnauthorized, &CommandApiResponse{Error: err.Error()})
+		return
+	}
+
+	name := strings.Trim(r.PathValue("name"), "/")
+	list := &CommandApiList{
+		CommandHandlers:     make(map[string][]string),
+		CommandDescriptions: make(map[string]tools.CommandDescriptions),
+	}
+	api.mutex.RLock()
+	for handlersName, commandHandlers := range api.commandHandlers {
+		if name != "" && handlersName != name && !strings.HasPrefix(handlersName, name+"/") {
+			continue
+		}
+		keys := commandHandlers.GetKeys()
+		sort.Strings(keys)
+		list.CommandHandlers[handlersName] = keys
+		if commandDescriptions, ok := api.commandDescriptions[handlersName]; ok {
+			list.CommandDescriptions[handlersName] = commandDescriptions
+		}
+	}
+	api.mutex.RUnlock()
+
+	if name != "" && len(list.CommandHandlers) == 0 {
+		err := errors.New("command handlers do not exist")
+		api.audit(r, identity, "list", name, nil, err)
+		sendJson(w, http.StatusNotFound, &CommandApiResponse{Error: err.Error()})
+		return
+	}
+	api.audit(r, identity, "list", name, nil, nil)
+	sendJson(w, http.StatusOK, list)
+}
+
+func (api *CommandApi) handleExecute(w http.ResponseWriter, r *http.Request) {
+	identity, err := api.authenticate(r)
+	if err != nil {
+		api.audit(r, "", "execute", "", nil, err)
+		sendJson(w, http.StatusUnauthorized, &CommandApiResponse{Error: err.Error()})
+		return
+	}
+
+	name := strings.Trim(r.PathValue("name"), "/")
+	request := &CommandApiRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, api.config.MaxBodyBytes)).Decode(request); err != nil {
+		err = errors.New("invalid request body: " + err.Error())
+		api.audit(r, identity, "execute", name, nil, err)
+		sendJson(w, http.StatusBadRequest, &CommandApiResponse{Error: err.Error()})
+		return
+	}
+	args := make([]string, len(request.Args))
+	for i, arg := range request.Args {
+		if err := json.Unmarshal(arg, &args[i]); err != nil {
+			args[i] = string(arg)
+		}
+	}
+	command := &tools.Command{
+		Command: request.Command,
+		Args:    args,
+	}
+
+	api.mutex.RLock()
+	commandHandlers, ok := api.commandHandlers[name]
+	api.mutex.RUnlock()
+	if !ok {
+		err := errors.New("command handlers do not exist")
+		api.audit(r, identity, "execute", name, command, err)
+		sendJson(w, http.StatusNotFound, &CommandApiResponse{Error: err.Error()})
+		return
+	}
+	handler, ok := commandHandlers.Get(command.Command)
+	if !ok {
+		err := errors.New("command not found")
+		api.audit(r, identity, "execute", name, command, err)
+		sendJson(w, http.StatusNotFound, &CommandApiResponse{Error: err.Error()})
+		return
+	}
+
+	result, err := handler(command.Args)
+	api.audit(r, identity, "execute", name, command, err)
+	if err != nil {
+		sendJson(w, http.StatusUnprocessableEntity, &CommandApiResponse{Error: err.Error()})
+		return
+	}
+	sendJson(w, http.StatusOK, &CommandApiResponse{Result: result})
+}
+
+// returns the identity of the caller.
+func (api *CommandApi) authenticate(r *http.Request) (string, error) {
+	apiKey := r.Header.Get("X-Api-Key")
+	if apiKey == "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			apiKey = token
+		}
+	}
+	if apiKey != "" {
+		for identity, key := range api.config.ApiKeys {
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+				return identity, nil
+			}
+		}
+		return "", errors.New("invalid api key")
+	}
+
+	if api.sessionManager != nil && api.config.SessionIdCookie != "" {
+		cookie, err := r.Cookie(api.config.SessionIdCookie)
+		if err == nil {
+			session := api.sessionManager.GetSession(cookie.Value)
+			if session == nil || !session.IsAccepted() {
+				return "", errors.New("invalid session id")
+			}
+			return session.GetIdentity(), nil
+		}
+	}
+	return "", errors.New("unauthorized")
+}
+
+func (api *CommandApi) audit(r *http.Request, identity string, action string, name string, command *tools.Command, err error) {
+	if api.auditLogger == nil {
+		return
+	}
+	args := []any{
+		"remoteAddr", r.RemoteAddr,
+		"identity", identity,
+		"action", action,
+		"name", name,
+	}
+	if command != nil {
+		args = append(args, "command", command.Command)
+		if api.config.AuditLogArgs {
+			args = append(args, "args", command.Args)
+		} else {
+			args = append(args, "argCount", len(command.Args))
+		}
+	}
+	if err != nil {
+		api.auditLogger.Warn("audit", append(args, "error", err.Error())...)
+	} else {
+		api.auditLogger.Info("audit", args...)
+	}
+}
+
+func sendJson(w http.ResponseWriter, statusCode int, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(data)
+}
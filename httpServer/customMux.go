@@ -1,63 +1,312 @@
 package httpServer
 
 import (
+	"errors"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
+// routes requests by path segments and method.
+//
+// pattern syntax:
+//   - "/users"          matches exactly "/users"
+//   - "/users/{id}"     matches one segment, readable through httpRequest.PathValue("id")
+//   - "/static/{file*}" matches all remaining segments (including none), readable through httpRequest.PathValue("file")
+//   - "/static/"        trailing slash: matches "/static" and everything below it (shorthand for "/static/{*}")
+//
+// patterns without a trailing slash or wildcard match exactly (as in http.ServeMux).
+// before this router, every pattern was a prefix match, so "/ws" also matched "/ws/foo". use "/ws/" for that.
+//
+// the most specific route wins, independent of registration order:
+// static segments are preferred over parameters, which are preferred over wildcards.
+// a route without a handler for the request method is skipped in favour of less specific routes that have one.
+// requests matching only routes without a handler for the request method are answered with 405.
 type CustomMux struct {
-	routes  map[string]http.Handler
+	root    *routeNode
 	mutex   sync.RWMutex
 	delayNs int64
 }
 
+const anyMethod = ""
+
+type routeNode struct {
+	static   map[string]*routeNode
+	param    *routeNode
+	wildcard *routeNode
+	routes   map[string]*route // method -> route
+}
+
+type route struct {
+	handler    http.Handler
+	paramNames []string
+}
+
 func NewCustomMux(delayNs int64) *CustomMux {
 	return &CustomMux{
-		routes:  make(map[string]http.Handler),
+		root:    newRouteNode(),
 		delayNs: delayNs,
 	}
 }
 
+func newRouteNode() *routeNode {
+	return &routeNode{
+		static: make(map[string]*routeNode),
+		routes: make(map[string]*route),
+	}
+}
+
+// handles requests of any method.
 func (c *CustomMux) AddRoute(pattern string, handler http.Handler) {
+	c.AddMethodRoute(anyMethod, pattern, handler)
+}
+
+// handles requests of method only. an empty method handles any method.
+// method routes take precedence over any-method routes of the same pattern.
+func (c *CustomMux) AddMethodRoute(method string, pattern string, handler http.Handler) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.routes[pattern] = handler
+	c.addMethodRoute(method, pattern, handler)
 }
 
+// like AddRoute, but fails if pattern already has a handler of any method.
+// checking and adding happen atomically, so concurrent callers cannot both claim pattern.
+func (c *CustomMux) AddRouteIfAbsent(pattern string, handler http.Handler) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if node := c.findNode(pattern); node != nil && len(node.routes) > 0 {
+		return errors.New("pattern is already in use")
+	}
+	c.addMethodRoute(anyMethod, pattern, handler)
+	return nil
+}
+
+// must be called with mutex locked.
+func (c *CustomMux) addMethodRoute(method string, pattern string, handler http.Handler) {
+	node := c.root
+	paramNames := []string{}
+	for _, segment := range splitPattern(pattern) {
+		switch {
+		case isWildcardSegment(segment):
+			if node.wildcard == nil {
+				node.wildcard = newRouteNode()
+			}
+			node = node.wildcard
+			paramNames = append(paramNames, strings.TrimSuffix(segment[1:len(segment)-1], "*"))
+		case isParamSegment(segment):
+			if node.param == nil {
+				node.param = newRouteNode()
+			}
+			node = node.param
+			paramNames = append(paramNames, segment[1:len(segment)-1])
+		default:
+			child, ok := node.static[segment]
+			if !ok {
+				child = newRouteNode()
+				node.static[segment] = child
+			}
+			node = child
+		}
+	}
+	node.routes[method] = &route{
+		handler:    handler,
+		paramNames: paramNames,
+	}
+}
+
+// removes the handlers of all methods of pattern.
 func (c *CustomMux) RemoveRoute(pattern string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	delete(c.routes, pattern)
+
+	if node := c.findNode(pattern); node != nil {
+		node.routes = make(map[string]*route)
+		c.prune(pattern)
+	}
+}
+
+func (c *CustomMux) RemoveMethodRoute(method string, pattern string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if node := c.findNode(pattern); node != nil {
+		delete(node.routes, method)
+		c.prune(pattern)
+	}
+}
+
+func (c *CustomMux) HasRoute(pattern string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	node := c.findNode(pattern)
+	return node != nil && len(node.routes) > 0
 }
 
 func (c *CustomMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	time.Sleep(time.Duration(c.delayNs) * time.Nanosecond)
 
+	segments := splitPath(r.URL.Path)
 	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	node, paramValues := c.root.match(segments, nil, func(node *routeNode) bool {
+		return node.getRoute(r.Method) != nil
+	})
+	var matchedRoute *route
+	var allowedMethods []string
+	if node != nil {
+		matchedRoute = node.getRoute(r.Method)
+	} else if node, _ = c.root.match(segments, nil, func(node *routeNode) bool {
+		return len(node.routes) > 0
+	}); node != nil {
+		allowedMethods = node.getMethods()
+	}
+	c.mutex.RUnlock()
 
-	if handler, ok := c.matchRoute(r.URL.Path); ok {
-		handler.ServeHTTP(w, r)
+	if node == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if matchedRoute == nil {
+		w.Header().Set("Allow", strings.Join(allowedMethods, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 		return
 	}
+	for i, paramName := range matchedRoute.paramNames {
+		if paramName != "" && i < len(paramValues) {
+			r.SetPathValue(paramName, paramValues[i])
+		}
+	}
+	matchedRoute.handler.ServeHTTP(w, r)
+}
 
-	http.NotFound(w, r)
+// must be called with mutex locked.
+func (c *CustomMux) findNode(pattern string) *routeNode {
+	node := c.root
+	for _, segment := range splitPattern(pattern) {
+		node = node.child(segment)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
 }
 
-func (c *CustomMux) matchRoute(path string) (http.Handler, bool) {
-	if handler, ok := c.routes[path]; ok {
-		return handler, true
+// removes the nodes of pattern that lead to no route anymore.
+// must be called with mutex locked.
+func (c *CustomMux) prune(pattern string) {
+	segments := splitPattern(pattern)
+	path := []*routeNode{c.root}
+	for _, segment := range segments {
+		node := path[len(path)-1].child(segment)
+		if node == nil {
+			return
+		}
+		path = append(path, node)
+	}
+	for i := len(segments); i > 0; i-- {
+		node := path[i]
+		if len(node.routes) > 0 || len(node.static) > 0 || node.param != nil || node.wildcard != nil {
+			return
+		}
+		parent := path[i-1]
+		switch segment := segments[i-1]; {
+		case isWildcardSegment(segment):
+			parent.wildcard = nil
+		case isParamSegment(segment):
+			parent.param = nil
+		default:
+			delete(parent.static, segment)
+		}
 	}
+}
 
-	for pattern, handler := range c.routes {
-		if strings.HasPrefix(path, pattern) {
-			return handler, true
+// returns the child of a pattern segment (not of a path segment).
+func (node *routeNode) child(segment string) *routeNode {
+	switch {
+	case isWildcardSegment(segment):
+		return node.wildcard
+	case isParamSegment(segment):
+		return node.param
+	default:
+		return node.static[segment]
+	}
+}
+
+// depth first search with backtracking. static > param > wildcard.
+// only nodes for which accepts returns true are matched.
+// returns the matched node and the values of its parameters in order.
+func (node *routeNode) match(segments []string, paramValues []string, accepts func(*routeNode) bool) (*routeNode, []string) {
+	if len(segments) == 0 {
+		if accepts(node) {
+			return node, paramValues
+		}
+		if node.wildcard != nil && accepts(node.wildcard) {
+			return node.wildcard, append(paramValues, "")
+		}
+		return nil, nil
+	}
+	if child, ok := node.static[segments[0]]; ok {
+		if matched, values := child.match(segments[1:], paramValues, accepts); matched != nil {
+			return matched, values
+		}
+	}
+	if node.param != nil {
+		if matched, values := node.param.match(segments[1:], append(paramValues, segments[0]), accepts); matched != nil {
+			return matched, values
 		}
 	}
+	if node.wildcard != nil && accepts(node.wildcard) {
+		return node.wildcard, append(paramValues, strings.Join(segments, "/"))
+	}
+	return nil, nil
+}
+
+func (node *routeNode) getRoute(method string) *route {
+	if route, ok := node.routes[method]; ok {
+		return route
+	}
+	if method == http.MethodHead {
+		if route, ok := node.routes[http.MethodGet]; ok {
+			return route
+		}
+	}
+	return node.routes[anyMethod]
+}
+
+func (node *routeNode) getMethods() []string {
+	methods := make([]string, 0, len(node.routes))
+	for method := range node.routes {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func splitPattern(pattern string) []string {
+	segments := splitPath(pattern)
+	if strings.HasSuffix(pattern, "/") {
+		segments = append(segments, "{*}")
+	}
+	return segments
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
+
+func isParamSegment(segment string) bool {
+	return len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}'
+}
 
-	return nil, false
+func isWildcardSegment(segment string) bool {
+	return isParamSegment(segment) && segment[len(segment)-2] == '*'
 }
@@ -0,0 +1,348 @@
+package httpServer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/neutralusername/systemge/accepter"
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/status"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+)
+
+const (
+	HEALTH_OK       = "ok"
+	HEALTH_DEGRADED = "degraded"
+	HEALTH_FAILING  = "failing"
+)
+
+// kinds of a check. a check may be of both kinds.
+const (
+	HEALTH_CHECK_LIVENESS  = 1 << iota // reported by /healthz (the process works and should not be restarted)
+	HEALTH_CHECK_READINESS             // reported by /readyz (the process can serve traffic)
+)
+
+const (
+	HEALTHZ_PATTERN = "/healthz"
+	READYZ_PATTERN  = "/readyz"
+)
+
+// returns nil if healthy, a DegradedError if degraded and any other error if failing.
+// ctx is canceled once the check timeout expires.
+type HealthCheck func(ctx context.Context) error
+
+type DegradedError struct {
+	message string
+}
+
+func NewDegradedError(message string) *DegradedError {
+	return &DegradedError{
+		message: message,
+	}
+}
+
+func (err *DegradedError) Error() string {
+	return err.message
+}
+
+// aggregates the results of registered checks into liveness and readiness reports.
+// the report is failing if a critical check fails, degraded if a non-critical check fails or any check is degraded
+// and ok otherwise. failing reports are served with 503, all others with 200.
+type HealthRegistry struct {
+	config *configs.HealthRegistry
+
+	mutex  sync.RWMutex
+	checks map[string]*healthCheck
+}
+
+type healthCheck struct {
+	kinds     int
+	critical  bool
+	timeoutNs int64
+	check     HealthCheck
+
+	mutex  sync.Mutex // only one evaluation of the check at a time
+	result *HealthCheckResult
+}
+
+type HealthCheckResult struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message,omitempty"`
+	Critical   bool      `json:"critical"`
+	DurationNs int64     `json:"durationNs"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+type HealthReport struct {
+	Status string               `json:"status"`
+	Checks []*HealthCheckResult `json:"checks"` // sorted by name
+	Time   time.Time            `json:"time"`
+}
+
+func NewHealthRegistry(config *configs.HealthRegistry) *HealthRegistry {
+	if config == nil {
+		config = &configs.HealthRegistry{}
+	}
+	if config.CheckTimeoutNs <= 0 {
+		config.CheckTimeoutNs = int64(5 * time.Second)
+	}
+	return &HealthRegistry{
+		config: config,
+		checks: make(map[string]*healthCheck),
+	}
+}
+
+// kinds is a combination of HEALTH_CHECK_LIVENESS and HEALTH_CHECK_READINESS.
+// timeoutNs <= 0 == the registry's CheckTimeoutNs.
+func (registry *HealthRegistry) AddCheck(name string, kinds int, critical bool, timeoutNs int64, check HealthCheck) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if kinds&(HEALTH_CHECK_LIVENESS|HEALTH_CHECK_READINESS) == 0 {
+		return errors.New("invalid kinds")
+	}
+	if check == nil {
+		return errors.New("check is nil")
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if _, ok := registry.checks[name]; ok {
+		return errors.New("check already exists")
+	}
+	registry.checks[name] = &healthCheck{
+		kinds:     kinds,
+		critical:  critical,
+		timeoutNs: timeoutNs,
+		check:     check,
+	}
+	return nil
+}
+
+func (registry *HealthRegistry) RemoveCheck(name string) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if _, ok := registry.checks[name]; !ok {
+		return errors.New("check does not exist")
+	}
+	delete(registry.checks, name)
+	return nil
+}
+
+// readiness check "routine/<name>" that is ok while the routine is started.
+func (registry *HealthRegistry) AddRoutineCheck(name string, routine *tools.Routine, critical bool) error {
+	if routine == nil {
+		return errors.New("routine is nil")
+	}
+	return registry.AddCheck("routine/"+name, HEALTH_CHECK_READINESS, critical, 0, NewMachineHealthCheck(routine.GetStatusMachine()))
+}
+
+// readiness check "sessionManager/<name>" that is ok while the session manager is started.
+func (registry *HealthRegistry) AddSessionManagerCheck(name string, sessionManager *tools.SessionManager, critical bool) error {
+	if sessionManager == nil {
+		return errors.New("sessionManager is nil")
+	}
+	return registry.AddCheck("sessionManager/"+name, HEALTH_CHECK_READINESS, critical, 0, NewMachineHealthCheck(sessionManager.GetStatusMachine()))
+}
+
+// readiness check "listener/<listener name>" that is ok while the listener is started.
+func AddListenerHealthCheck[T any](registry *HealthRegistry, listener systemge.Listener[T], critical bool) error {
+	if listener == nil {
+		return errors.New("listener is nil")
+	}
+	return registry.AddCheck("listener/"+listener.GetName(), HEALTH_CHECK_READINESS, critical, 0, NewMachineHealthCheck(listener.GetStatusMachine()))
+}
+
+// readiness check "accepter/<listener name>" that is ok while the accept routine and its listener are started.
+func AddAccepterHealthCheck[T any](registry *HealthRegistry, accepter *accepter.Accepter[T], critical bool) error {
+	if accepter == nil {
+		return errors.New("accepter is nil")
+	}
+	listener := accepter.GetListener()
+	routineCheck := NewMachineHealthCheck(accepter.GetRoutine().GetStatusMachine())
+	listenerCheck := NewMachineHealthCheck(listener.GetStatusMachine())
+	return registry.AddCheck("accepter/"+listener.GetName(), HEALTH_CHECK_READINESS, critical, 0, func(ctx context.Context) error {
+		if err := routineCheck(ctx); err != nil {
+			return errors.New("routine " + err.Error())
+		}
+		if err := listenerCheck(ctx); err != nil {
+			return errors.New("listener " + err.Error())
+		}
+		return nil
+	})
+}
+
+// ok if started, degraded while starting or stopping and failing otherwise.
+func NewStatusHealthCheck(getStatus func() int) HealthCheck {
+	return func(ctx context.Context) error {
+		currentStatus := getStatus()
+		switch {
+		case currentStatus == status.Started:
+			return nil
+		case status.IsTransitional(currentStatus):
+			return NewDegradedError(status.ToString(currentStatus))
+		default:
+			return errors.New(status.ToString(currentStatus))
+		}
+	}
+}
+
+// like NewStatusHealthCheck, but reports the last error of a failed machine.
+func NewMachineHealthCheck(machine *status.Machine) HealthCheck {
+	statusCheck := NewStatusHealthCheck(machine.Get)
+	return func(ctx context.Context) error {
+		err := statusCheck(ctx)
+		if err != nil && machine.Is(status.Failed) {
+			if lastError := machine.GetLastError(); lastError != nil {
+				return errors.New("failed: " + lastError.Error())
+			}
+		}
+		return err
+	}
+}
+
+// runs all checks of kind concurrently (or returns their cached results).
+func (registry *HealthRegistry) Check(kind int) *HealthReport {
+	registry.mutex.RLock()
+	names := []string{}
+	checks := []*healthCheck{}
+	for name, check := range registry.checks {
+		if check.kinds&kind != 0 {
+			names = append(names, name)
+			checks = append(checks, check)
+		}
+	}
+	registry.mutex.RUnlock()
+
+	results := make([]*HealthCheckResult, len(checks))
+	waitGroup := sync.WaitGroup{}
+	for i := range checks {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			results[i] = registry.runCheck(names[i], checks[i])
+		}(i)
+	}
+	waitGroup.Wait()
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	report := &HealthReport{
+		Status: HEALTH_OK,
+		Checks: results,
+		Time:   time.Now(),
+	}
+	for _, result := range results {
+		switch {
+		case result.Status == HEALTH_FAILING && result.Critical:
+			report.Status = HEALTH_FAILING
+		case result.Status != HEALTH_OK && report.Status == HEALTH_OK:
+			report.Status = HEALTH_DEGRADED
+		}
+	}
+	return report
+}
+
+func (registry *HealthRegistry) runCheck(name string, check *healthCheck) *HealthCheckResult {
+	check.mutex.Lock()
+	defer check.mutex.Unlock()
+	if check.result != nil && registry.config.CacheDurationNs > 0 && time.Since(check.result.CheckedAt) < time.Duration(registry.config.CacheDurationNs) {
+		return check.result
+	}
+
+	timeoutNs := check.timeoutNs
+	if timeoutNs <= 0 {
+		timeoutNs = registry.config.CheckTimeoutNs
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutNs))
+	defer cancel()
+	startTime := time.Now()
+	errChannel := make(chan error, 1)
+	go func() {
+		// a panicking check is reported as failing instead of crashing the process
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errChannel <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+		errChannel <- check.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errChannel:
+	case <-ctx.Done():
+		err = errors.New("check timed out")
+	}
+
+	result := &HealthCheckResult{
+		Name:       name,
+		Status:     HEALTH_OK,
+		Critical:   check.critical,
+		DurationNs: time.Since(startTime).Nanoseconds(),
+		CheckedAt:  startTime,
+	}
+	if err != nil {
+		result.Message = err.Error()
+		var degradedError *DegradedError
+		if errors.As(err, &degradedError) {
+			result.Status = HEALTH_DEGRADED
+		} else {
+			result.Status = HEALTH_FAILING
+		}
+	}
+	check.result = result
+	return result
+}
+
+// serves the liveness report on HEALTHZ_PATTERN and the readiness report on READYZ_PATTERN.
+func (registry *HealthRegistry) Mount(server *HTTPServer) {
+	server.AddMethodRoute(http.MethodGet, HEALTHZ_PATTERN, registry.GetHandler(HEALTH_CHECK_LIVENESS))
+	server.AddMethodRoute(http.MethodGet, READYZ_PATTERN, registry.GetHandler(HEALTH_CHECK_READINESS))
+}
+
+func (registry *HealthRegistry) Unmount(server *HTTPServer) {
+	server.RemoveMethodRoute(http.MethodGet, HEALTHZ_PATTERN)
+	server.RemoveMethodRoute(http.MethodGet, READYZ_PATTERN)
+}
+
+// serves the report of kind as json.
+func (registry *HealthRegistry) GetHandler(kind int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := registry.Check(kind)
+		statusCode := http.StatusOK
+		if report.Status == HEALTH_FAILING {
+			statusCode = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		sendJson(w, statusCode, report)
+	}
+}
+
+// commands:
+//   - checkLiveness, checkReadiness: returns the report as json
+func (registry *HealthRegistry) GetDefaultCommands() tools.CommandHandlers {
+	commands := tools.CommandHandlers{}
+	commands["checkLiveness"] = func(args []string) (string, error) {
+		json, err := json.Marshal(registry.Check(HEALTH_CHECK_LIVENESS))
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["checkReadiness"] = func(args []string) (string, error) {
+		json, err := json.Marshal(registry.Check(HEALTH_CHECK_READINESS))
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	return commands
+}
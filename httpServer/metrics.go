@@ -1,22 +1,223 @@
 package httpServer
 
-import "github.com/neutralusername/systemge/tools"
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/neutralusername/systemge/tools"
+)
+
+// upper bounds of the latency buckets. requests slower than the last bound are counted in "latency_bucket_inf".
+var latencyBuckets = []struct {
+	bound time.Duration
+	name  string
+}{
+	{time.Millisecond, "latency_bucket_1ms"},
+	{5 * time.Millisecond, "latency_bucket_5ms"},
+	{10 * time.Millisecond, "latency_bucket_10ms"},
+	{50 * time.Millisecond, "latency_bucket_50ms"},
+	{100 * time.Millisecond, "latency_bucket_100ms"},
+	{500 * time.Millisecond, "latency_bucket_500ms"},
+	{time.Second, "latency_bucket_1s"},
+	{5 * time.Second, "latency_bucket_5s"},
+}
+
+// metrics of a single route.
+// latency buckets are not cumulative, i.e. every request is counted in exactly one bucket.
+type RouteMetrics struct {
+	RequestCounter atomic.Uint64
+	Status1xx      atomic.Uint64
+	Status2xx      atomic.Uint64
+	Status3xx      atomic.Uint64
+	Status4xx      atomic.Uint64
+	Status5xx      atomic.Uint64
+	BytesReceived  atomic.Uint64
+	BytesSent      atomic.Uint64
+	LatencySumNs   atomic.Uint64
+	LatencyBuckets [9]atomic.Uint64 // len(latencyBuckets) + 1
+}
+
+func (routeMetrics *RouteMetrics) record(statusCode int, bytesReceived uint64, bytesSent uint64, latency time.Duration) {
+	routeMetrics.RequestCounter.Add(1)
+	switch statusCode / 100 {
+	case 1:
+		routeMetrics.Status1xx.Add(1)
+	case 2:
+		routeMetrics.Status2xx.Add(1)
+	case 3:
+		routeMetrics.Status3xx.Add(1)
+	case 4:
+		routeMetrics.Status4xx.Add(1)
+	case 5:
+		routeMetrics.Status5xx.Add(1)
+	}
+	routeMetrics.BytesReceived.Add(bytesReceived)
+	routeMetrics.BytesSent.Add(bytesSent)
+	routeMetrics.LatencySumNs.Add(uint64(latency))
+	bucket := len(latencyBuckets)
+	for i, latencyBucket := range latencyBuckets {
+		if latency <= latencyBucket.bound {
+			bucket = i
+			break
+		}
+	}
+	routeMetrics.LatencyBuckets[bucket].Add(1)
+}
+
+func (routeMetrics *RouteMetrics) toMetrics(load func(*atomic.Uint64) uint64) *tools.Metrics {
+	metrics := tools.NewMetrics(map[string]uint64{
+		"request_counter": load(&routeMetrics.RequestCounter),
+		"status_1xx":      load(&routeMetrics.Status1xx),
+		"status_2xx":      load(&routeMetrics.Status2xx),
+		"status_3xx":      load(&routeMetrics.Status3xx),
+		"status_4xx":      load(&routeMetrics.Status4xx),
+		"status_5xx":      load(&routeMetrics.Status5xx),
+		"bytes_received":  load(&routeMetrics.BytesReceived),
+		"bytes_sent":      load(&routeMetrics.BytesSent),
+		"latency_sum_ns":  load(&routeMetrics.LatencySumNs),
+	})
+	for i, latencyBucket := range latencyBuckets {
+		metrics.Add(latencyBucket.name, load(&routeMetrics.LatencyBuckets[i]))
+	}
+	metrics.Add("latency_bucket_inf", load(&routeMetrics.LatencyBuckets[len(latencyBuckets)]))
+	return metrics
+}
+
+// "pattern" for routes of any method, "METHOD pattern" for method routes.
+func getRouteKey(method string, pattern string) string {
+	if method == anyMethod {
+		return pattern
+	}
+	return method + " " + pattern
+}
+
+// returns the existing metrics of the route if it is re-registered, so its counters are not lost.
+func (server *HTTPServer) addRouteMetrics(routeKey string) *RouteMetrics {
+	server.routeMetricsMutex.Lock()
+	defer server.routeMetricsMutex.Unlock()
+
+	if routeMetrics, ok := server.routeMetrics[routeKey]; ok {
+		return routeMetrics
+	}
+	routeMetrics := &RouteMetrics{}
+	server.routeMetrics[routeKey] = routeMetrics
+	return routeMetrics
+}
+
+// if allMethods is true, routeKey is treated as a pattern and the metrics of all its methods are removed.
+func (server *HTTPServer) removeRouteMetrics(routeKey string, allMethods bool) {
+	server.routeMetricsMutex.Lock()
+	defer server.routeMetricsMutex.Unlock()
+
+	delete(server.routeMetrics, routeKey)
+	if !allMethods {
+		return
+	}
+	for key := range server.routeMetrics {
+		if _, pattern, ok := strings.Cut(key, " "); ok && pattern == routeKey {
+			delete(server.routeMetrics, key)
+		}
+	}
+}
+
+// returns the metrics of a route. routeKey is "pattern" for routes of any method and "METHOD pattern" for method routes.
+func (server *HTTPServer) GetRouteMetrics(routeKey string) *RouteMetrics {
+	server.routeMetricsMutex.RLock()
+	defer server.routeMetricsMutex.RUnlock()
+	return server.routeMetrics[routeKey]
+}
 
 func (server *HTTPServer) CheckMetrics() tools.MetricsTypes {
-	metricsTypes := tools.NewMetricsTypes()
-	metricsTypes.AddMetrics("http_server", tools.NewMetrics(
-		map[string]uint64{
-			"request_counter": server.RequestCounter.Load(),
-		},
-	))
-	return metricsTypes
+	return server.getMetrics(func(value *atomic.Uint64) uint64 {
+		return value.Load()
+	})
 }
 func (server *HTTPServer) GetMetrics() tools.MetricsTypes {
+	return server.getMetrics(func(value *atomic.Uint64) uint64 {
+		return value.Swap(0)
+	})
+}
+
+// per route metrics are added as "http_server_route_<routeKey>".
+// (the metrics exporter uses checkLabeledMetrics instead, see AddHTTPServerMetricsSource)
+func (server *HTTPServer) getMetrics(load func(*atomic.Uint64) uint64) tools.MetricsTypes {
 	metricsTypes := tools.NewMetricsTypes()
 	metricsTypes.AddMetrics("http_server", tools.NewMetrics(
 		map[string]uint64{
-			"request_counter": server.RequestCounter.Swap(0),
+			"request_counter": load(&server.RequestCounter),
 		},
 	))
+
+	server.routeMetricsMutex.RLock()
+	defer server.routeMetricsMutex.RUnlock()
+	for routeKey, routeMetrics := range server.routeMetrics {
+		metricsTypes.AddMetrics("http_server_route_"+routeKey, routeMetrics.toMetrics(load))
+	}
 	return metricsTypes
 }
+
+// like CheckMetrics, but the per route metrics share the metrics type "http_server_route" and are labeled by route and method.
+// the latency buckets are cumulative in the format of tools.Histogram (keys "latency_ns_bucket_<bound in ns>").
+func (server *HTTPServer) checkLabeledMetrics() []*labeledMetrics {
+	load := func(value *atomic.Uint64) uint64 {
+		return value.Load()
+	}
+	labeledMetricsList := []*labeledMetrics{{
+		metricsType: "http_server",
+		metrics: tools.NewMetrics(map[string]uint64{
+			"request_counter": load(&server.RequestCounter),
+		}),
+	}}
+
+	server.routeMetricsMutex.RLock()
+	defer server.routeMetricsMutex.RUnlock()
+	for routeKey, routeMetrics := range server.routeMetrics {
+		method, pattern, ok := strings.Cut(routeKey, " ")
+		if !ok {
+			method, pattern = "*", routeKey
+		}
+		metrics := tools.NewMetrics(map[string]uint64{
+			"request_counter": load(&routeMetrics.RequestCounter),
+			"status_1xx":      load(&routeMetrics.Status1xx),
+			"status_2xx":      load(&routeMetrics.Status2xx),
+			"status_3xx":      load(&routeMetrics.Status3xx),
+			"status_4xx":      load(&routeMetrics.Status4xx),
+			"status_5xx":      load(&routeMetrics.Status5xx),
+			"bytes_received":  load(&routeMetrics.BytesReceived),
+			"bytes_sent":      load(&routeMetrics.BytesSent),
+			"latency_ns_sum":  load(&routeMetrics.LatencySumNs),
+		})
+		count := uint64(0)
+		for i, latencyBucket := range latencyBuckets {
+			count += load(&routeMetrics.LatencyBuckets[i])
+			metrics.Add("latency_ns_bucket_"+strconv.FormatInt(int64(latencyBucket.bound), 10), count)
+		}
+		count += load(&routeMetrics.LatencyBuckets[len(latencyBuckets)])
+		metrics.Add("latency_ns_bucket_inf", count)
+		metrics.Add("latency_ns_count", count)
+		labeledMetricsList = append(labeledMetricsList, &labeledMetrics{
+			metricsType: "http_server_route",
+			labels: map[string]string{
+				"route":  pattern,
+				"method": method,
+			},
+			metrics: metrics,
+		})
+	}
+	return labeledMetricsList
+}
+
+// counts the bytes read from a request body.
+type countingReadCloser struct {
+	io.ReadCloser
+	bytesRead uint64
+}
+
+func (reader *countingReadCloser) Read(data []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(data)
+	reader.bytesRead += uint64(n)
+	return n, err
+}
@@ -0,0 +1,509 @@
+package httpServer
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// renders the metrics of registered sources in the prometheus text exposition format.
+//
+// every key of every metrics type becomes the metric <namespace>_<metrics type>_<key> in snake case,
+// e.g. "clientsAccepted" of "accepter_server" becomes "systemge_accepter_server_clients_accepted".
+// the samples of a source carry the labels it was added with.
+// values are exposed as untyped, since a metrics type may mix counters and gauges.
+// keys in the format of tools.Histogram (<name>_bucket_<bound>, <name>_bucket_inf, <name>_sum and <name>_count) are exposed as histograms.
+// samples whose name and labels collide with an earlier sample (e.g. "a-b" and "a_b") are dropped and counted in
+// <namespace>_metrics_exporter_dropped_samples. sources that panic are skipped and counted in <namespace>_metrics_exporter_failed_sources.
+//
+// sources should be CheckMetrics methods. GetMetrics resets the counters on every scrape.
+type MetricsExporter struct {
+	config *configs.MetricsExporter
+
+	mutex   sync.RWMutex
+	sources map[string]*metricsExporterSource
+}
+
+type metricsExporterSource struct {
+	labels    map[string]string
+	labelsKey string
+	collect   func() []*labeledMetrics
+}
+
+// a metrics type of a source and the labels of its samples in addition to the labels of the source.
+type labeledMetrics struct {
+	metricsType string
+	labels      map[string]string
+	metrics     *tools.Metrics
+}
+
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func NewMetricsExporter(config *configs.MetricsExporter) *MetricsExporter {
+	if config == nil {
+		config = &configs.MetricsExporter{}
+	}
+	config.Namespace = toMetricName(config.Namespace)
+	if config.Namespace == "" {
+		config.Namespace = "systemge"
+	}
+	return &MetricsExporter{
+		config:  config,
+		sources: make(map[string]*metricsExporterSource),
+	}
+}
+
+// name identifies the source for RemoveSource and is not exposed.
+// labels may be nil, but no two sources may have the same labels (their samples would collide).
+// "le" is reserved for histogram buckets.
+func (exporter *MetricsExporter) AddSource(name string, labels map[string]string, checkMetrics func() tools.MetricsTypes) error {
+	if checkMetrics == nil {
+		return errors.New("checkMetrics is nil")
+	}
+	return exporter.addSource(name, labels, func() []*labeledMetrics {
+		labeledMetricsList := []*labeledMetrics{}
+		for metricsType, metrics := range checkMetrics() {
+			labeledMetricsList = append(labeledMetricsList, &labeledMetrics{
+				metricsType: metricsType,
+				metrics:     metrics,
+			})
+		}
+		// collisions are resolved in the same order on every scrape
+		sort.Slice(labeledMetricsList, func(i, j int) bool {
+			return labeledMetricsList[i].metricsType < labeledMetricsList[j].metricsType
+		})
+		return labeledMetricsList
+	})
+}
+
+func (exporter *MetricsExporter) addSource(name string, labels map[string]string, collect func() []*labeledMetrics) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	for label := range labels {
+		if !labelNameRegexp.MatchString(label) || strings.HasPrefix(label, "__") || label == "le" {
+			return errors.New("invalid label name \"" + label + "\"")
+		}
+	}
+	source := &metricsExporterSource{
+		labels:    labels,
+		labelsKey: formatLabels(labels),
+		collect:   collect,
+	}
+
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	if _, ok := exporter.sources[name]; ok {
+		return errors.New("source already exists")
+	}
+	for _, existing := range exporter.sources {
+		if existing.labelsKey == source.labelsKey {
+			return errors.New("labels are already used by another source")
+		}
+	}
+	exporter.sources[name] = source
+	return nil
+}
+
+func (exporter *MetricsExporter) RemoveSource(name string) error {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	if _, ok := exporter.sources[name]; !ok {
+		return errors.New("source does not exist")
+	}
+	delete(exporter.sources, name)
+	return nil
+}
+
+// adds a listener with the labels listener (name) and instance_id.
+// the listener's instance id is the name of the source.
+func AddListenerMetricsSource[T any](exporter *MetricsExporter, listener systemge.Listener[T]) error {
+	return exporter.AddSource(
+		listener.GetInstanceId(),
+		map[string]string{
+			"listener":    listener.GetName(),
+			"instance_id": listener.GetInstanceId(),
+		},
+		listener.CheckMetrics,
+	)
+}
+
+// adds an http server with the label http_server (name). the server's name is the name of the source.
+// per route metrics are exported as "<namespace>_http_server_route_<key>" with the labels route (pattern) and method ("*" for routes of any method)
+// and their latencies as the histogram "<namespace>_http_server_route_latency_ns".
+func AddHTTPServerMetricsSource(exporter *MetricsExporter, server *HTTPServer) error {
+	return exporter.addSource(
+		server.GetName(),
+		map[string]string{
+			"http_server": server.GetName(),
+		},
+		server.checkLabeledMetrics,
+	)
+}
+
+// adds a connection with the labels connection (instance id), address and listener (if listenerName is not empty).
+// the connection's instance id is the name of the source. the source is removed once the connection is closed.
+func AddConnectionMetricsSource[T any](exporter *MetricsExporter, connection systemge.Connection[T], listenerName string) error {
+	labels := map[string]string{
+		"connection": connection.GetInstanceId(),
+		"address":    connection.GetAddress(),
+	}
+	if listenerName != "" {
+		labels["listener"] = listenerName
+	}
+	if err := exporter.AddSource(connection.GetInstanceId(), labels, connection.CheckMetrics); err != nil {
+		return err
+	}
+	go func() {
+		<-connection.GetCloseChannel()
+		exporter.RemoveSource(connection.GetInstanceId())
+	}()
+	return nil
+}
+
+// adds the exporter's route to server.
+func (exporter *MetricsExporter) Mount(server *HTTPServer, pattern string) {
+	server.AddMethodRoute(http.MethodGet, pattern, exporter.ServeHTTP)
+}
+
+func (exporter *MetricsExporter) Unmount(server *HTTPServer, pattern string) {
+	server.RemoveMethodRoute(http.MethodGet, pattern)
+}
+
+func (exporter *MetricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !exporter.authenticate(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(exporter.Render()))
+}
+
+func (exporter *MetricsExporter) authenticate(r *http.Request) bool {
+	if len(exporter.config.ApiKeys) == 0 {
+		return true
+	}
+	apiKey := r.Header.Get("X-Api-Key")
+	if apiKey == "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			apiKey = token
+		}
+	}
+	if apiKey == "" {
+		return false
+	}
+	for _, key := range exporter.config.ApiKeys {
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the current metrics of all sources in the prometheus text exposition format.
+func (exporter *MetricsExporter) Render() string {
+	exporter.mutex.RLock()
+	names := make([]string, 0, len(exporter.sources))
+	for name := range exporter.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sources := make([]*metricsExporterSource, 0, len(names))
+	for _, name := range names {
+		sources = append(sources, exporter.sources[name])
+	}
+	exporter.mutex.RUnlock()
+
+	// all samples of a metric have to be grouped below one TYPE line
+	families := map[string]*metricsFamily{}
+	failedSources := uint64(0)
+	droppedSamples := uint64(0)
+	for _, source := range sources {
+		labeledMetricsList, ok := source.collectSafely()
+		if !ok {
+			failedSources++
+			continue
+		}
+		for _, labeledMetrics := range labeledMetricsList {
+			if labeledMetrics.metrics == nil {
+				continue
+			}
+			prefix := exporter.config.Namespace + "_" + toMetricName(labeledMetrics.metricsType) + "_"
+			labels := mergeLabels(source.labels, labeledMetrics.labels)
+			labelsKey := formatLabels(labels)
+			keyValuePairs := make(map[string]uint64, len(labeledMetrics.metrics.KeyValuePairs))
+			for key, value := range labeledMetrics.metrics.KeyValuePairs {
+				keyValuePairs[key] = value
+			}
+
+			for _, histogram := range extractHistograms(keyValuePairs) {
+				name := prefix + toMetricName(histogram.name)
+				family := getFamily(families, name, "histogram")
+				if family == nil || !family.addSeries(labelsKey, histogram.format(name, labels)) {
+					droppedSamples++
+				}
+			}
+			keys := make([]string, 0, len(keyValuePairs))
+			for key := range keyValuePairs {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				name := prefix + toMetricName(key)
+				family := getFamily(families, name, "untyped")
+				if family == nil || !family.addSeries(labelsKey, []string{name + labelsKey + " " + strconv.FormatUint(keyValuePairs[key], 10)}) {
+					droppedSamples++
+				}
+			}
+		}
+	}
+	getFamily(families, exporter.config.Namespace+"_metrics_exporter_failed_sources", "gauge").addSeries("", []string{
+		exporter.config.Namespace + "_metrics_exporter_failed_sources " + strconv.FormatUint(failedSources, 10),
+	})
+	getFamily(families, exporter.config.Namespace+"_metrics_exporter_dropped_samples", "gauge").addSeries("", []string{
+		exporter.config.Namespace + "_metrics_exporter_dropped_samples " + strconv.FormatUint(droppedSamples, 10),
+	})
+
+	familyNames := make([]string, 0, len(families))
+	for name := range families {
+		familyNames = append(familyNames, name)
+	}
+	sort.Strings(familyNames)
+
+	builder := strings.Builder{}
+	for _, name := range familyNames {
+		family := families[name]
+		builder.WriteString("# TYPE " + name + " " + family.metricType + "\n")
+		labelsKeys := make([]string, 0, len(family.series))
+		for labelsKey := range family.series {
+			labelsKeys = append(labelsKeys, labelsKey)
+		}
+		sort.Strings(labelsKeys)
+		for _, labelsKey := range labelsKeys {
+			for _, line := range family.series[labelsKey] {
+				builder.WriteString(line + "\n")
+			}
+		}
+	}
+	return builder.String()
+}
+
+// the samples of one metric name (or of one histogram) grouped by their labels.
+type metricsFamily struct {
+	metricType string
+	series     map[string][]string // labels -> sample lines
+}
+
+// returns nil if the family exists with a different type.
+func getFamily(families map[string]*metricsFamily, name string, metricType string) *metricsFamily {
+	family, ok := families[name]
+	if !ok {
+		family = &metricsFamily{
+			metricType: metricType,
+			series:     map[string][]string{},
+		}
+		families[name] = family
+	}
+	if family.metricType != metricType {
+		return nil
+	}
+	return family
+}
+
+// returns false if the family already has a series with the labels,
+// e.g. because two keys or metrics types of a source are converted to the same name.
+func (family *metricsFamily) addSeries(labelsKey string, lines []string) bool {
+	if _, ok := family.series[labelsKey]; ok {
+		return false
+	}
+	family.series[labelsKey] = lines
+	return true
+}
+
+// returns ok == false if the source panics.
+func (source *metricsExporterSource) collectSafely() (labeledMetricsList []*labeledMetrics, ok bool) {
+	defer func() {
+		if recover() != nil {
+			labeledMetricsList, ok = nil, false
+		}
+	}()
+	return source.collect(), true
+}
+
+type histogramBucket struct {
+	bound uint64
+	value uint64
+}
+
+// a histogram in the format of tools.Histogram, i.e. the keys <name>_bucket_<bound> (cumulative),
+// <name>_bucket_inf, <name>_sum and <name>_count.
+type exportedHistogram struct {
+	name    string
+	buckets []histogramBucket // sorted by bound
+	inf     uint64
+	sum     uint64
+	count   uint64
+}
+
+// removes the keys of histograms from keyValuePairs and returns the histograms sorted by name.
+// bounds are integers or durations (e.g. "1ms", exported in nanoseconds).
+func extractHistograms(keyValuePairs map[string]uint64) []*exportedHistogram {
+	histograms := []*exportedHistogram{}
+	for key := range keyValuePairs {
+		name, ok := strings.CutSuffix(key, "_bucket_inf")
+		if !ok {
+			continue
+		}
+		count, hasCount := keyValuePairs[name+"_count"]
+		sum, hasSum := keyValuePairs[name+"_sum"]
+		if !hasCount || !hasSum {
+			continue
+		}
+		histogram := &exportedHistogram{
+			name:  name,
+			inf:   keyValuePairs[key],
+			sum:   sum,
+			count: count,
+		}
+		bucketKeys := []string{}
+		valid := true
+		for bucketKey, value := range keyValuePairs {
+			boundName, ok := strings.CutPrefix(bucketKey, name+"_bucket_")
+			if !ok || boundName == "inf" {
+				continue
+			}
+			bound, err := parseBound(boundName)
+			if err != nil {
+				valid = false
+				break
+			}
+			histogram.buckets = append(histogram.buckets, histogramBucket{bound, value})
+			bucketKeys = append(bucketKeys, bucketKey)
+		}
+		if !valid {
+			continue
+		}
+		sort.Slice(histogram.buckets, func(i, j int) bool {
+			return histogram.buckets[i].bound < histogram.buckets[j].bound
+		})
+		for _, bucketKey := range bucketKeys {
+			delete(keyValuePairs, bucketKey)
+		}
+		delete(keyValuePairs, key)
+		delete(keyValuePairs, name+"_count")
+		delete(keyValuePairs, name+"_sum")
+		histograms = append(histograms, histogram)
+	}
+	sort.Slice(histograms, func(i, j int) bool {
+		return histograms[i].name < histograms[j].name
+	})
+	return histograms
+}
+
+func parseBound(boundName string) (uint64, error) {
+	if bound, err := strconv.ParseUint(boundName, 10, 64); err == nil {
+		return bound, nil
+	}
+	duration, err := time.ParseDuration(boundName)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, errors.New("negative bound")
+	}
+	return uint64(duration), nil
+}
+
+// returns the sample lines <name>_bucket{le="<bound>"} (including le="+Inf"), <name>_sum and <name>_count.
+func (histogram *exportedHistogram) format(name string, labels map[string]string) []string {
+	lines := make([]string, 0, len(histogram.buckets)+3)
+	for _, bucket := range histogram.buckets {
+		bucketLabels := mergeLabels(labels, map[string]string{"le": strconv.FormatUint(bucket.bound, 10)})
+		lines = append(lines, name+"_bucket"+formatLabels(bucketLabels)+" "+strconv.FormatUint(bucket.value, 10))
+	}
+	infLabels := mergeLabels(labels, map[string]string{"le": "+Inf"})
+	lines = append(lines, name+"_bucket"+formatLabels(infLabels)+" "+strconv.FormatUint(histogram.inf, 10))
+	labelsKey := formatLabels(labels)
+	lines = append(lines, name+"_sum"+labelsKey+" "+strconv.FormatUint(histogram.sum, 10))
+	lines = append(lines, name+"_count"+labelsKey+" "+strconv.FormatUint(histogram.count, 10))
+	return lines
+}
+
+// returns a new map with the labels of both maps. labels of b take precedence.
+func mergeLabels(a map[string]string, b map[string]string) map[string]string {
+	labels := make(map[string]string, len(a)+len(b))
+	for name, value := range a {
+		labels[name] = value
+	}
+	for name, value := range b {
+		labels[name] = value
+	}
+	return labels
+}
+
+// returns the labels sorted by name as {name="value",...}, or an empty string if there are none.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	builder := strings.Builder{}
+	builder.WriteString("{")
+	for i, name := range names {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(name + "=\"" + escapeLabelValue(labels[name]) + "\"")
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
+
+// converts camel case to snake case and replaces characters that are not allowed in metric names with "_".
+func toMetricName(value string) string {
+	builder := strings.Builder{}
+	lastUnderscore := true
+	for i, char := range value {
+		switch {
+		case char >= 'A' && char <= 'Z':
+			if !lastUnderscore && i > 0 && !isUpper(value[i-1]) {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(char + ('a' - 'A'))
+			lastUnderscore = false
+		case (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9'):
+			builder.WriteRune(char)
+			lastUnderscore = false
+		default:
+			if !lastUnderscore {
+				builder.WriteByte('_')
+				lastUnderscore = true
+			}
+		}
+	}
+	return strings.Trim(builder.String(), "_")
+}
+
+func isUpper(char byte) bool {
+	return char >= 'A' && char <= 'Z'
+}
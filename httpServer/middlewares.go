@@ -0,0 +1,408 @@
+package httpServer
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// answers preflight requests and adds cors headers to responses of allowed origins.
+func NewCorsMiddleware(config *configs.Cors) Middleware {
+	if config == nil {
+		config = &configs.Cors{}
+	}
+	allowedOrigins := map[string]bool{}
+	for _, origin := range config.AllowedOrigins {
+		allowedOrigins[origin] = true
+	}
+	allowedMethods := strings.Join(config.AllowedMethods, ", ")
+	if allowedMethods == "" {
+		allowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	}
+	allowedHeaders := strings.Join(config.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(config.ExposedHeaders, ", ")
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next(w, r)
+				return
+			}
+			if len(allowedOrigins) > 0 && !allowedOrigins[origin] && !allowedOrigins["*"] {
+				if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+					Send403(w, r)
+					return
+				}
+				next(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Add("Vary", "Origin")
+			if len(allowedOrigins) == 0 && !config.AllowCredentials {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if config.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				next(w, r)
+				return
+			}
+			header.Set("Access-Control-Allow-Methods", allowedMethods)
+			if allowedHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowedHeaders)
+			} else if requestedHeaders := r.Header.Get("Access-Control-Request-Headers"); requestedHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", requestedHeaders)
+			}
+			if config.MaxAgeSeconds > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAgeSeconds))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// Content-Encoding is only set once the first body byte is written,
+// so responses without a body (e.g. 204, 304 or an empty redirect) are sent unencoded.
+type compressionResponseWriter struct {
+	*ResponseRecorder
+	encoding      string
+	level         int
+	writer        io.WriteCloser // created on first write, so responses without a body stay empty
+	statusCode    int            // held back until the first body byte decides whether the response is encoded
+	uncompressed  bool           // the header was sent without Content-Encoding
+	headerWritten bool
+}
+
+func (writer *compressionResponseWriter) WriteHeader(statusCode int) {
+	if writer.headerWritten || writer.statusCode != 0 {
+		return
+	}
+	if statusCode >= 100 && statusCode < 200 { // informational responses precede the final one
+		writer.ResponseRecorder.WriteHeader(statusCode)
+		return
+	}
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		writer.uncompressed = true
+		writer.headerWritten = true
+		writer.ResponseRecorder.WriteHeader(statusCode)
+		return
+	}
+	writer.statusCode = statusCode
+}
+
+func (writer *compressionResponseWriter) Write(data []byte) (int, error) {
+	if writer.uncompressed {
+		return writer.ResponseRecorder.Write(data)
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if writer.writer == nil {
+		if writer.Header().Get("Content-Type") == "" {
+			writer.Header().Set("Content-Type", http.DetectContentType(data))
+		}
+		if err := writer.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return writer.writer.Write(data)
+}
+
+func (writer *compressionResponseWriter) startCompression() error {
+	var err error
+	switch writer.encoding {
+	case "gzip":
+		writer.writer, err = gzip.NewWriterLevel(writer.ResponseRecorder, writer.level)
+	default:
+		writer.writer, err = flate.NewWriter(writer.ResponseRecorder, writer.level)
+	}
+	if err != nil {
+		return err
+	}
+	header := writer.Header()
+	header.Set("Content-Encoding", writer.encoding)
+	header.Del("Content-Length")
+	writer.writeHeader()
+	return nil
+}
+
+// sends the held back status code (200 if none was set).
+func (writer *compressionResponseWriter) writeHeader() {
+	if writer.headerWritten {
+		return
+	}
+	writer.headerWritten = true
+	if writer.statusCode == 0 {
+		writer.statusCode = http.StatusOK
+	}
+	writer.ResponseRecorder.WriteHeader(writer.statusCode)
+}
+
+// flushing commits the response to being encoded, since the header has to be sent.
+func (writer *compressionResponseWriter) Flush() {
+	if !writer.uncompressed && writer.writer == nil {
+		if err := writer.startCompression(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := writer.writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	writer.ResponseRecorder.Flush()
+}
+
+// sends a held back status code of a response without body unencoded.
+func (writer *compressionResponseWriter) Close() error {
+	if writer.writer == nil {
+		if writer.statusCode != 0 {
+			writer.writeHeader()
+		}
+		return nil
+	}
+	return writer.writer.Close()
+}
+
+// compresses responses with gzip or deflate, depending on the request's Accept-Encoding header.
+// upgrade and HEAD requests are not compressed, nor are responses without a body.
+// level is one of the compress/flate levels (e.g. flate.DefaultCompression).
+func NewCompressionMiddleware(level int) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Upgrade") != "" || r.Method == http.MethodHead {
+				next(w, r)
+				return
+			}
+			encoding := getCompressionEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				next(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			writer := &compressionResponseWriter{
+				ResponseRecorder: NewResponseRecorder(w),
+				encoding:         encoding,
+				level:            level,
+			}
+			defer writer.Close()
+			next(writer, r)
+		}
+	}
+}
+
+func getCompressionEncoding(acceptEncoding string) string {
+	deflate := false
+	for _, encoding := range strings.Split(acceptEncoding, ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if isZeroQuality(params) {
+			continue
+		}
+		switch strings.TrimSpace(encoding) {
+		case "gzip":
+			return "gzip"
+		case "deflate":
+			deflate = true
+		}
+	}
+	if deflate {
+		return "deflate"
+	}
+	return ""
+}
+
+// returns true if params (e.g. "q=0.0") give a quality of 0, which disables the encoding.
+func isZeroQuality(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return err == nil && quality <= 0
+	}
+	return false
+}
+
+// sets common security related response headers.
+func NewSecurityHeadersMiddleware(config *configs.SecurityHeaders) Middleware {
+	if config == nil {
+		config = &configs.SecurityHeaders{}
+	}
+	frameOptions := config.FrameOptions
+	if frameOptions == "" {
+		frameOptions = "DENY"
+	}
+	referrerPolicy := config.ReferrerPolicy
+	if referrerPolicy == "" {
+		referrerPolicy = "no-referrer"
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("X-Frame-Options", frameOptions)
+			header.Set("Referrer-Policy", referrerPolicy)
+			if config.HstsMaxAgeSeconds > 0 {
+				header.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(config.HstsMaxAgeSeconds)+"; includeSubDomains")
+			}
+			if config.ContentSecurityPolicy != "" {
+				header.Set("Content-Security-Policy", config.ContentSecurityPolicy)
+			}
+			next(w, r)
+		}
+	}
+}
+
+const RequestIdHeader = "X-Request-Id"
+
+// assigns a request id to every request that does not carry one in the X-Request-Id header.
+// the id is set on both the request and the response header.
+func NewRequestIdMiddleware(idLength uint32) Middleware {
+	if idLength == 0 {
+		idLength = 16
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			requestId := r.Header.Get(RequestIdHeader)
+			if requestId == "" {
+				requestId = tools.GenerateRandomString(idLength, tools.ALPHA_NUMERIC)
+				r.Header.Set(RequestIdHeader, requestId)
+			}
+			w.Header().Set(RequestIdHeader, requestId)
+			next(w, r)
+		}
+	}
+}
+
+func GetRequestId(r *http.Request) string {
+	return r.Header.Get(RequestIdHeader)
+}
+
+// logs one entry per request: remote address, method, path, status code, bytes written, duration and request id.
+func NewAccessLogMiddleware(logger *tools.Logger) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			startTime := time.Now()
+			recorder := NewResponseRecorder(w)
+			next(recorder, r)
+			logger.Info("request",
+				"remoteAddr", r.RemoteAddr,
+				"method", r.Method,
+				"uri", r.URL.RequestURI(),
+				"status", recorder.GetStatusCode(),
+				"bytesWritten", recorder.GetBytesWritten(),
+				"duration", time.Since(startTime),
+				"requestId", GetRequestId(r),
+			)
+		}
+	}
+}
+
+// rejects requests with 429 once an ip exceeds the attempts allowed by ipRateLimiter.
+// the ip is resolved through the forwarded headers of trustedProxies (see ResolveClientAddress). trustedProxies may be nil.
+func NewIpRateLimitMiddleware(ipRateLimiter *tools.IpRateLimiter, trustedProxies *tools.AccessControlList) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !ipRateLimiter.RegisterConnectionAttempt(getClientIp(r, trustedProxies)) {
+				sendTooManyRequests(w)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+type ipTokenBucket struct {
+	rateLimiter *tools.TokenBucketRateLimiter
+	lastUsed    time.Time
+}
+
+// rejects requests with 429 once an ip's token bucket is empty. each request consumes one token.
+// every ip gets its own bucket, which is discarded after being unused for idleTimeoutNs (default: 1 minute).
+// the ip is resolved through the forwarded headers of trustedProxies (see ResolveClientAddress). trustedProxies may be nil.
+func NewIpTokenBucketMiddleware(config *configs.TokenBucketRateLimiter, idleTimeoutNs int64, trustedProxies *tools.AccessControlList) Middleware {
+	buckets := map[string]*ipTokenBucket{}
+	mutex := sync.Mutex{}
+	idleTimeout := time.Duration(idleTimeoutNs)
+	if idleTimeout <= 0 {
+		idleTimeout = time.Minute
+	}
+
+	// runs while there are buckets, so that idle buckets are discarded without requests arriving
+	cleanupRoutine := func() {
+		ticker := time.NewTicker(idleTimeout)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			mutex.Lock()
+			for key, bucket := range buckets {
+				if now.Sub(bucket.lastUsed) > idleTimeout {
+					bucket.rateLimiter.Close()
+					delete(buckets, key)
+				}
+			}
+			if len(buckets) == 0 {
+				mutex.Unlock()
+				return
+			}
+			mutex.Unlock()
+		}
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ip := getClientIp(r, trustedProxies)
+
+			mutex.Lock()
+			bucket, ok := buckets[ip]
+			if !ok {
+				if len(buckets) == 0 {
+					go cleanupRoutine()
+				}
+				bucket = &ipTokenBucket{
+					rateLimiter: tools.NewTokenBucketRateLimiter(config),
+				}
+				buckets[ip] = bucket
+			}
+			bucket.lastUsed = time.Now()
+			mutex.Unlock()
+
+			if !bucket.rateLimiter.Consume(1) {
+				sendTooManyRequests(w)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+func getClientIp(r *http.Request, trustedProxies *tools.AccessControlList) string {
+	address := ResolveClientAddress(r, trustedProxies)
+	ip, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return ip
+}
+
+func sendTooManyRequests(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write([]byte("429 too many requests"))
+}
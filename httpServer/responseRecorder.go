@@ -0,0 +1,74 @@
+package httpServer
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// records the status code and the number of bytes written to a http.ResponseWriter.
+// supports hijacking and flushing if the underlying http.ResponseWriter does.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	statusCode   int
+	bytesWritten uint64
+}
+
+func NewResponseRecorder(responseWriter http.ResponseWriter) *ResponseRecorder {
+	if recorder, ok := responseWriter.(*ResponseRecorder); ok {
+		return recorder
+	}
+	return &ResponseRecorder{
+		ResponseWriter: responseWriter,
+	}
+}
+
+func (recorder *ResponseRecorder) WriteHeader(statusCode int) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = statusCode
+	}
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *ResponseRecorder) Write(data []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+	n, err := recorder.ResponseWriter.Write(data)
+	recorder.bytesWritten += uint64(n)
+	return n, err
+}
+
+func (recorder *ResponseRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (recorder *ResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := recorder.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+func (recorder *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+// returns 200 if nothing was written yet, since that is what net/http will send.
+func (recorder *ResponseRecorder) GetStatusCode() int {
+	if recorder.statusCode == 0 {
+		return http.StatusOK
+	}
+	return recorder.statusCode
+}
+
+func (recorder *ResponseRecorder) GetBytesWritten() uint64 {
+	return recorder.bytesWritten
+}
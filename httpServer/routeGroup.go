@@ -0,0 +1,85 @@
+package httpServer
+
+import (
+	"net/http"
+	"strings"
+)
+
+// wraps a handler. middlewares are executed in the order they are provided.
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// converts a WrapperHandler into a middleware, which stops the chain if the WrapperHandler returns an error.
+func NewWrapperMiddleware(wrapperHandler WrapperHandler) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if err := wrapperHandler(w, r); err != nil {
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// applies middlewares to handlerFunc. the first middleware is the outermost one.
+func ChainMiddlewares(handlerFunc http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handlerFunc = middlewares[i](handlerFunc)
+	}
+	return handlerFunc
+}
+
+// registers routes below a common prefix and wraps them with the group's middlewares.
+// middlewares are applied when a route is added, so Use only affects routes added afterwards.
+type RouteGroup struct {
+	server      *HTTPServer
+	prefix      string
+	middlewares []Middleware
+}
+
+func (server *HTTPServer) Group(prefix string, middlewares ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		server:      server,
+		prefix:      prefix,
+		middlewares: middlewares,
+	}
+}
+
+// creates a nested group. the parent's middlewares are executed before the nested group's middlewares.
+func (group *RouteGroup) Group(prefix string, middlewares ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		server:      group.server,
+		prefix:      joinPattern(group.prefix, prefix),
+		middlewares: append(append([]Middleware{}, group.middlewares...), middlewares...),
+	}
+}
+
+func (group *RouteGroup) Use(middlewares ...Middleware) {
+	group.middlewares = append(group.middlewares, middlewares...)
+}
+
+func (group *RouteGroup) AddRoute(pattern string, handlerFunc http.HandlerFunc) {
+	group.server.AddRoute(joinPattern(group.prefix, pattern), ChainMiddlewares(handlerFunc, group.middlewares...))
+}
+
+func (group *RouteGroup) AddMethodRoute(method string, pattern string, handlerFunc http.HandlerFunc) {
+	group.server.AddMethodRoute(method, joinPattern(group.prefix, pattern), ChainMiddlewares(handlerFunc, group.middlewares...))
+}
+
+func (group *RouteGroup) RemoveRoute(pattern string) {
+	group.server.RemoveRoute(joinPattern(group.prefix, pattern))
+}
+
+func (group *RouteGroup) RemoveMethodRoute(method string, pattern string) {
+	group.server.RemoveMethodRoute(method, joinPattern(group.prefix, pattern))
+}
+
+func (group *RouteGroup) GetPrefix() string {
+	return group.prefix
+}
+
+func joinPattern(prefix string, pattern string) string {
+	if pattern == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(pattern, "/")
+}
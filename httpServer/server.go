@@ -3,9 +3,11 @@ package httpServer
 import (
 	"errors"
 	"log"
+	"log/slog"
 	"net/http"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/neutralusername/systemge/configs"
 	"github.com/neutralusername/systemge/constants"
@@ -25,16 +27,22 @@ type HTTPServer struct {
 	instanceId string
 	sessionId  string
 
-	status      int
-	statusMutex sync.RWMutex
+	status      *status.Machine
+	statusMutex sync.RWMutex // held by requests (read) and by Start/Stop (write)
 
 	httpServer     *http.Server
 	wrapperHandler WrapperHandler
 	mux            *CustomMux
 
+	logger   *tools.Logger
+	errorLog *log.Logger
+
 	// metrics
 
 	RequestCounter atomic.Uint64
+
+	routeMetrics      map[string]*RouteMetrics // route key -> metrics
+	routeMetricsMutex sync.RWMutex
 }
 
 func New(name string, config *configs.HTTPServer, wrapperHandler WrapperHandler, requestHandlers HandlerFuncs) (*HTTPServer, error) {
@@ -51,6 +59,8 @@ func New(name string, config *configs.HTTPServer, wrapperHandler WrapperHandler,
 		config:         config,
 		wrapperHandler: wrapperHandler,
 		instanceId:     tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
+		routeMetrics:   make(map[string]*RouteMetrics),
+		status:         status.NewMachine(),
 	}
 	for pattern, handler := range requestHandlers {
 		server.AddRoute(pattern, handler)
@@ -58,40 +68,94 @@ func New(name string, config *configs.HTTPServer, wrapperHandler WrapperHandler,
 
 	if config.HttpErrorLogPath != "" {
 		file := helpers.OpenFileAppend(config.HttpErrorLogPath)
-		server.httpServer.ErrorLog = log.New(file, "[Error: \""+server.GetName()+"\"] ", log.Ldate|log.Ltime|log.Lmicroseconds)
+		server.errorLog = log.New(file, "[Error: \""+server.GetName()+"\"] ", log.Ldate|log.Ltime|log.Lmicroseconds)
 	}
 	return server, nil
 }
 
+// rejected requests are logged to logger. errors of the underlying http.Server are logged to it as well unless HttpErrorLogPath is set.
+// should be called before the server is started.
+func (server *HTTPServer) SetLogger(logger *tools.Logger) {
+	server.logger = logger
+}
+
+func (server *HTTPServer) getErrorLog() *log.Logger {
+	if server.errorLog != nil {
+		return server.errorLog
+	}
+	return server.logger.NewLogLogger(slog.LevelError)
+}
+
+// handles requests of any method. see CustomMux for the pattern syntax.
 func (server *HTTPServer) AddRoute(pattern string, handlerFunc http.HandlerFunc) {
-	server.mux.AddRoute(pattern, server.httpRequestWrapper(handlerFunc))
+	server.mux.AddRoute(pattern, server.httpRequestWrapper(server.addRouteMetrics(getRouteKey(anyMethod, pattern)), handlerFunc))
+}
+
+// fails if pattern already has a handler. see CustomMux.AddRouteIfAbsent.
+func (server *HTTPServer) AddRouteIfAbsent(pattern string, handlerFunc http.HandlerFunc) error {
+	return server.mux.AddRouteIfAbsent(pattern, server.httpRequestWrapper(server.addRouteMetrics(getRouteKey(anyMethod, pattern)), handlerFunc))
 }
-func (server *HTTPServer) httpRequestWrapper(handler func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+
+// handles requests of method only. see CustomMux for the pattern syntax.
+func (server *HTTPServer) AddMethodRoute(method string, pattern string, handlerFunc http.HandlerFunc) {
+	server.mux.AddMethodRoute(method, pattern, server.httpRequestWrapper(server.addRouteMetrics(getRouteKey(method, pattern)), handlerFunc))
+}
+
+func (server *HTTPServer) httpRequestWrapper(routeMetrics *RouteMetrics, handler func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		server.statusMutex.RLock()
 		defer server.statusMutex.RUnlock()
-		if server.status != status.Started {
+		if !server.status.Is(status.Started) {
 			Send403(w, r)
 			return
 		}
 
 		server.RequestCounter.Add(1)
-		r.Body = http.MaxBytesReader(w, r.Body, server.config.MaxBodyBytes)
+		if server.config.MaxBodyBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, server.config.MaxBodyBytes)
+		}
+
+		startTime := time.Now()
+		recorder := NewResponseRecorder(w)
+		body := &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+		defer func() {
+			routeMetrics.record(recorder.GetStatusCode(), body.bytesRead, recorder.GetBytesWritten(), time.Since(startTime))
+		}()
 
 		if server.wrapperHandler != nil {
-			if err := server.wrapperHandler(w, r); err != nil {
-				// do something with the error
+			if err := server.wrapperHandler(recorder, r); err != nil {
+				server.logger.Warn("request rejected", "remoteAddr", r.RemoteAddr, "method", r.Method, "uri", r.URL.RequestURI(), "error", err)
 				return
 			}
 		}
 
-		handler(w, r)
+		handler(recorder, r)
 
 	}
 }
 
 func (server *HTTPServer) RemoveRoute(pattern string) {
 	server.mux.RemoveRoute(pattern)
+	server.removeRouteMetrics(pattern, true)
+}
+
+func (server *HTTPServer) RemoveMethodRoute(method string, pattern string) {
+	server.mux.RemoveMethodRoute(method, pattern)
+	server.removeRouteMetrics(getRouteKey(method, pattern), false)
+}
+
+func (server *HTTPServer) HasRoute(pattern string) bool {
+	return server.mux.HasRoute(pattern)
+}
+
+func (server *HTTPServer) GetTcpListenerConfig() *configs.TcpListener {
+	return server.config.TcpListenerConfig
+}
+
+// returns the address the server listens on (ip:port).
+func (server *HTTPServer) GetAddress() string {
+	return server.config.TcpListenerConfig.Ip + ":" + helpers.Uint16ToString(server.config.TcpListenerConfig.Port)
 }
 
 func (server *HTTPServer) GetName() string {
@@ -99,5 +163,9 @@ func (server *HTTPServer) GetName() string {
 }
 
 func (server *HTTPServer) GetStatus() int {
+	return server.status.Get()
+}
+
+func (server *HTTPServer) GetStatusMachine() *status.Machine {
 	return server.status
 }
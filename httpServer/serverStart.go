@@ -18,15 +18,19 @@ func (server *HTTPServer) Start() error {
 
 	server.sessionId = tools.GenerateRandomString(constants.SessionIdLength, tools.ALPHA_NUMERIC)
 
-	if server.status != status.Stopped {
+	if err := server.status.Transition(status.Starting, nil); err != nil {
 		return errors.New("failed to start http server")
 	}
-	server.status = status.Pending
+	if server.httpServer != nil {
+		// left over by a server that failed while running
+		server.httpServer.Close()
+		server.httpServer = nil
+	}
 
 	if server.config.TcpListenerConfig.Ip == "" {
 		ip, err := net.LookupIP(server.config.TcpListenerConfig.Domain)
 		if err != nil {
-			return err
+			return server.status.Fail(err)
 		}
 		server.config.TcpListenerConfig.Ip = ip[0].String()
 	}
@@ -36,43 +40,45 @@ func (server *HTTPServer) Start() error {
 		ReadHeaderTimeout: time.Duration(server.config.ReadHeaderTimeoutMs) * time.Millisecond,
 		WriteTimeout:      time.Duration(server.config.WriteTimeoutMs) * time.Millisecond,
 
-		Addr:    server.config.TcpListenerConfig.Ip + ":" + helpers.Uint16ToString(server.config.TcpListenerConfig.Port),
-		Handler: server.mux,
+		Addr:     server.config.TcpListenerConfig.Ip + ":" + helpers.Uint16ToString(server.config.TcpListenerConfig.Port),
+		Handler:  server.mux,
+		ErrorLog: server.getErrorLog(),
 	}
 
-	errorChannel := make(chan error)
-	ended := false
+	// errors within the first 10ms fail the start. later errors fail the running server
+	errorChannel := make(chan error, 1)
+	httpServer := server.httpServer
 	go func() {
+		var err error
 		if server.config.TcpListenerConfig.TlsCertPath != "" && server.config.TcpListenerConfig.TlsKeyPath != "" {
-			err := server.httpServer.ListenAndServeTLS(server.config.TcpListenerConfig.TlsCertPath, server.config.TcpListenerConfig.TlsKeyPath)
-			if err != nil {
-				if !ended {
-					errorChannel <- err
-				} else if http.ErrServerClosed != err {
-					panic(err)
-				}
-			}
+			err = httpServer.ListenAndServeTLS(server.config.TcpListenerConfig.TlsCertPath, server.config.TcpListenerConfig.TlsKeyPath)
 		} else {
-			err := server.httpServer.ListenAndServe()
-			if err != nil {
-				if !ended {
-					errorChannel <- err
-				} else if http.ErrServerClosed != err {
-					panic(err)
-				}
+			err = httpServer.ListenAndServe()
+		}
+		select {
+		case errorChannel <- err:
+		default:
+			if err != http.ErrServerClosed {
+				server.logger.Error("http server failed", "error", err)
+				server.status.Fail(err)
 			}
 		}
 	}()
-	time.Sleep(10 * time.Millisecond)
-	ended = true
+	var err error
 	select {
-	case err := <-errorChannel:
-		server.status = status.Stopped
+	case err = <-errorChannel:
+	case <-time.After(10 * time.Millisecond):
+		// occupy the buffer so that later errors fail the running server instead
+		select {
+		case errorChannel <- nil:
+		case err = <-errorChannel:
+		}
+	}
+	if err != nil {
 		server.httpServer = nil
-		return err
-	default:
+		return server.status.Fail(err)
 	}
-	server.status = status.Started
+	server.status.Transition(status.Started, nil)
 
 	return nil
 }
@@ -10,17 +10,21 @@ func (server *HTTPServer) Stop() error {
 	server.statusMutex.Lock()
 	defer server.statusMutex.Unlock()
 
-	if server.status != status.Started {
+	// a failed server is stopped to release its resources
+	if !server.status.Is(status.Started, status.Failed) {
 		return errors.New("http server not started")
 	}
-	server.status = status.Pending
+	if err := server.status.Transition(status.Stopping, nil); err != nil {
+		return err
+	}
 
-	err := server.httpServer.Close()
-	if err != nil {
-		// something (shoudln't happen)
+	if server.httpServer != nil {
+		if err := server.httpServer.Close(); err != nil {
+			server.logger.Warn("failed to close http server", "error", err)
+		}
 	}
 	server.httpServer = nil
-	server.status = status.Stopped
+	server.status.Transition(status.Stopped, nil)
 
 	return nil
 }
@@ -28,11 +28,25 @@ func (listener *ChannelListener[T]) Accept(timeoutNs int64) (systemge.Connection
 		return nil, errors.New("accept canceled")
 
 	case connectionRequest := <-listener.connectionChannel:
+		connection := connectionChannel.New(connectionRequest.SendToListener, connectionRequest.ReceiveFromListener)
+		if listener.eventHandler.Emit(
+			tools.EVENT_LISTENER_ACCEPT_SUCCEEDED,
+			systemge.NewEventContext[T](listener, connection, nil),
+			tools.Continue, tools.Cancel,
+		) == tools.Cancel {
+			listener.ClientsFailed.Add(1)
+			connection.Close()
+			return nil, errors.New("accept canceled by event handler")
+		}
 		listener.ClientsAccepted.Add(1)
-		return connectionChannel.New(connectionRequest.SendToListener, connectionRequest.ReceiveFromListener), nil
+		return connection, nil
 	}
 }
 
+func (listener *ChannelListener[T]) SetEventHandler(eventHandler *tools.Handler) {
+	listener.eventHandler = eventHandler
+}
+
 func (listener *ChannelListener[T]) SetAcceptDeadline(timeoutNs int64) {
 	if timeout := listener.timeout; timeout != nil {
 		timeout.Refresh(timeoutNs)
@@ -11,6 +11,8 @@ import (
 	"github.com/neutralusername/systemge/tools"
 )
 
+// emits the following events if an event handler is set (the first option is the default):
+//   - EVENT_LISTENER_ACCEPT_SUCCEEDED: Continue (return the connection), Cancel (close the connection and return an error)
 type ChannelListener[T any] struct {
 	name string
 
@@ -18,13 +20,15 @@ type ChannelListener[T any] struct {
 	sessionId  string
 
 	statusMutex sync.Mutex
-	status      int
+	status      *status.Machine
 	stopChannel chan struct{}
 
 	connectionChannel chan *connectionChannel.ConnectionRequest[T]
 	timeout           *tools.Timeout
 	mutex             sync.Mutex
 
+	eventHandler *tools.Handler
+
 	// metrics
 
 	ClientsAccepted atomic.Uint64
@@ -34,7 +38,7 @@ type ChannelListener[T any] struct {
 func New[T any](name string) (systemge.Listener[T], error) {
 	listener := &ChannelListener[T]{
 		name:       name,
-		status:     status.Stopped,
+		status:     status.NewMachine(),
 		instanceId: tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
 	}
 
@@ -60,6 +64,10 @@ func (listener *ChannelListener[T]) GetSessionId() string {
 }
 
 func (listener *ChannelListener[T]) GetStatus() int {
+	return listener.status.Get()
+}
+
+func (listener *ChannelListener[T]) GetStatusMachine() *status.Machine {
 	return listener.status
 }
 
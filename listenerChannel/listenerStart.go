@@ -13,15 +13,14 @@ func (listener *ChannelListener[T]) Start() error {
 	listener.statusMutex.Lock()
 	defer listener.statusMutex.Unlock()
 
-	if listener.status == status.Started {
-		return errors.New("tcpSystemgeListener is already started")
+	if err := listener.status.Transition(status.Starting, nil); err != nil {
+		return errors.New("channelListener is already started")
 	}
 	listener.sessionId = tools.GenerateRandomString(constants.SessionIdLength, tools.ALPHA_NUMERIC)
-	listener.status = status.Pending
 	listener.stopChannel = make(chan struct{})
 	listener.connectionChannel = make(chan *connectionChannel.ConnectionRequest[T])
 
-	listener.status = status.Started
+	listener.status.Transition(status.Started, nil)
 
 	return nil
 }
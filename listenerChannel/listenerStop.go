@@ -10,14 +10,14 @@ func (listener *ChannelListener[T]) Stop() error {
 	listener.statusMutex.Lock()
 	defer listener.statusMutex.Unlock()
 
-	if listener.status == status.Stopped {
-		return errors.New("websocketListener is already stopped")
+	if !listener.status.Is(status.Started) {
+		return errors.New("channelListener is already stopped")
 	}
 
-	listener.status = status.Pending
+	listener.status.Transition(status.Stopping, nil)
 	close(listener.stopChannel)
 	listener.connectionChannel = nil
 
-	listener.status = status.Stopped
+	listener.status.Transition(status.Stopped, nil)
 	return nil
 }
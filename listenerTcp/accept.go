@@ -7,6 +7,7 @@ import (
 
 	"github.com/neutralusername/systemge/connectionTcp"
 	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
 )
 
 func (listener *TcpListener) Accept(timeoutNs int64) (systemge.Connection[[]byte], error) {
@@ -15,42 +16,95 @@ func (listener *TcpListener) Accept(timeoutNs int64) (systemge.Connection[[]byte
 
 	listener.SetAcceptDeadline(timeoutNs)
 
-	l := listener.tcpListener
+	l, _ := listener.getListeners()
 	if l == nil {
 		return nil, errors.New("tcpSystemgeListener is not started")
 	}
 
-	if tlsListener := listener.tlsListener; tlsListener != nil {
-		l = tlsListener
-	}
+	for {
+		netConn, err := l.Accept()
+		if err != nil {
+			listener.ClientsFailed.Add(1)
+			options := []int8{tools.Panic}
+			var netErr net.Error
+			if !errors.Is(err, net.ErrClosed) && !(errors.As(err, &netErr) && netErr.Timeout()) {
+				options = append(options, tools.Retry)
+			}
+			if listener.emitAcceptFailed(err, options...) == tools.Retry {
+				continue
+			}
+			return nil, err
+		}
 
-	netConn, err := l.Accept()
-	if err != nil {
-		listener.ClientsFailed.Add(1)
-		return nil, err
-	}
+		tcpSystemgeConnection, err := connectionTcp.New(listener.tcpBufferedReaderConfig, netConn)
+		if err != nil {
+			listener.ClientsFailed.Add(1)
+			netConn.Close()
+			if listener.emitAcceptFailed(err, tools.Panic, tools.Retry) == tools.Retry {
+				continue
+			}
+			return nil, err
+		}
 
-	tcpSystemgeConnection, err := connectionTcp.New(listener.tcpBufferedReaderConfig, netConn)
-	if err != nil {
-		listener.ClientsFailed.Add(1)
-		netConn.Close()
-		return nil, err
+		if listener.eventHandler.Emit(
+			tools.EVENT_LISTENER_ACCEPT_SUCCEEDED,
+			systemge.NewEventContext[[]byte](listener, tcpSystemgeConnection, nil),
+			tools.Continue, tools.Cancel,
+		) == tools.Cancel {
+			listener.ClientsFailed.Add(1)
+			tcpSystemgeConnection.Close()
+			return nil, errors.New("accept canceled by event handler")
+		}
+
+		listener.ClientsAccepted.Add(1)
+		return tcpSystemgeConnection, nil
 	}
+}
 
-	listener.ClientsAccepted.Add(1)
-	return tcpSystemgeConnection, nil
+func (listener *TcpListener) emitAcceptFailed(err error, options ...int8) int8 {
+	return listener.eventHandler.Emit(
+		tools.EVENT_LISTENER_ACCEPT_FAILED,
+		systemge.NewEventContext[[]byte](listener, nil, err),
+		tools.Continue, options...,
+	)
+}
+
+func (listener *TcpListener) SetEventHandler(eventHandler *tools.Handler) {
+	listener.eventHandler = eventHandler
 }
 
 func (listener *TcpListener) SetAcceptDeadline(timeoutNs int64) {
-	l := listener.tcpListener
-	if l == nil {
+	_, deadlineListener := listener.getListeners()
+	if deadlineListener == nil {
 		return
 	}
-	tcpListener := l.(*net.TCPListener)
-
 	if timeoutNs == 0 {
-		tcpListener.SetDeadline(time.Time{})
+		deadlineListener.SetDeadline(time.Time{})
 		return
 	}
-	tcpListener.SetDeadline(time.Now().Add(time.Duration(timeoutNs)))
+	deadlineListener.SetDeadline(time.Now().Add(time.Duration(timeoutNs)))
+}
+
+type deadlineListener interface {
+	SetDeadline(time.Time) error
+}
+
+// returns the listener to accept from and the listener accept deadlines are set on (nil, nil if not started).
+// the listeners are written by Start and Stop, so they are read under statusMutex.
+func (listener *TcpListener) getListeners() (net.Listener, deadlineListener) {
+	listener.statusMutex.Lock()
+	defer listener.statusMutex.Unlock()
+	if listener.tcpListener == nil {
+		return nil, nil
+	}
+	var acceptListener net.Listener = listener.tcpListener
+	var deadlineSetter deadlineListener = listener.tcpListener.(*net.TCPListener)
+	if listener.proxyListener != nil {
+		acceptListener = listener.proxyListener
+		deadlineSetter = listener.proxyListener
+	}
+	if listener.tlsListener != nil {
+		acceptListener = listener.tlsListener
+	}
+	return acceptListener, deadlineSetter
 }
@@ -39,7 +39,7 @@ func Connect(
 }
 
 func NewTcpClient(config *configs.TcpClient, timeoutNs int64) (net.Conn, error) {
-	if config.Ip != "" {
+	if config.Ip == "" {
 		ip, err := net.LookupIP(config.Domain)
 		if err != nil {
 			return nil, err
@@ -48,7 +48,7 @@ func NewTcpClient(config *configs.TcpClient, timeoutNs int64) (net.Conn, error)
 	}
 
 	if config.TlsCert == "" {
-		return net.Dial("tcp", config.Ip+":"+helpers.Uint16ToString(config.Port))
+		return net.DialTimeout("tcp", config.Ip+":"+helpers.Uint16ToString(config.Port), time.Duration(timeoutNs)*time.Nanosecond)
 	}
 	rootCAs := x509.NewCertPool()
 	if !rootCAs.AppendCertsFromPEM([]byte(config.TlsCert)) {
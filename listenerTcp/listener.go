@@ -9,25 +9,34 @@ import (
 	"github.com/neutralusername/systemge/configs"
 	"github.com/neutralusername/systemge/constants"
 	"github.com/neutralusername/systemge/helpers"
+	"github.com/neutralusername/systemge/status"
 	"github.com/neutralusername/systemge/systemge"
 	"github.com/neutralusername/systemge/tools"
 )
 
+// emits the following events if an event handler is set (the first option is the default):
+//   - EVENT_LISTENER_ACCEPT_SUCCEEDED: Continue (return the connection), Cancel (close the connection and return an error)
+//   - EVENT_LISTENER_ACCEPT_FAILED: Continue (return the error), Retry (accept again. not offered for timeouts and a closed listener), Panic
 type TcpListener struct {
 	name string
 
 	instanceId string
 	sessionId  string
 
-	status      int
+	status      *status.Machine
 	stopChannel chan struct{}
 	statusMutex sync.Mutex
 
 	config                  *configs.TcpListener
 	tcpBufferedReaderConfig *configs.TcpBufferedReader
 
-	tcpListener net.Listener
-	tlsListener net.Listener
+	tcpListener   net.Listener
+	proxyListener *proxyProtocolListener
+	tlsListener   net.Listener
+
+	trustedProxies *tools.AccessControlList
+
+	eventHandler *tools.Handler
 
 	mutex sync.Mutex
 
@@ -46,6 +55,15 @@ func New(name string, config *configs.TcpListener, bufferedReaderConfig *configs
 		config:                  config,
 		tcpBufferedReaderConfig: bufferedReaderConfig,
 		instanceId:              tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
+		status:                  status.NewMachine(),
+	}
+	if len(config.TrustedProxies) > 0 {
+		server.trustedProxies = tools.NewAccessControlList(nil, nil)
+		for _, trustedProxy := range config.TrustedProxies {
+			if err := server.trustedProxies.AddRange(trustedProxy); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return server, nil
@@ -71,6 +89,10 @@ func (listener *TcpListener) SetTcpBufferedReaderConfig(tcpBufferedReaderConfig
 }
 
 func (listener *TcpListener) GetStatus() int {
+	return listener.status.Get()
+}
+
+func (listener *TcpListener) GetStatusMachine() *status.Machine {
 	return listener.status
 }
 
@@ -86,7 +108,13 @@ func (server *TcpListener) GetSessionId() string {
 	return server.sessionId
 }
 
+// returns the address the listener is bound to, or the configured address if it is not started.
 func (listener *TcpListener) GetAddress() string {
+	listener.statusMutex.Lock()
+	defer listener.statusMutex.Unlock()
+	if listener.tcpListener == nil {
+		return listener.config.Ip + ":" + helpers.Uint16ToString(listener.config.Port)
+	}
 	return listener.tcpListener.Addr().String()
 }
 
@@ -15,7 +15,7 @@ func (listener *TcpListener) Start() error {
 	listener.statusMutex.Lock()
 	defer listener.statusMutex.Unlock()
 
-	if listener.status != status.Stopped {
+	if err := listener.status.Transition(status.Starting, nil); err != nil {
 		return errors.New("tcpSystemgeListener is already started")
 	}
 
@@ -24,29 +24,39 @@ func (listener *TcpListener) Start() error {
 	if listener.config.Ip == "" {
 		ip, err := net.LookupIP(listener.config.Domain)
 		if err != nil {
-			return err
+			return listener.status.Fail(err)
 		}
 		listener.config.Ip = ip[0].String()
 	}
 
 	tcpListener, err := NewTcpListener(listener.config.Ip + ":" + helpers.Uint16ToString(listener.config.Port))
 	if err != nil {
-		return err
+		return listener.status.Fail(err)
+	}
+
+	// the proxy protocol header precedes the tls handshake
+	var proxyListener *proxyProtocolListener
+	var innerListener net.Listener = tcpListener
+	if listener.config.ProxyProtocol {
+		proxyListener = newProxyProtocolListener(tcpListener, listener.trustedProxies, listener.config.ProxyHeaderTimeoutNs)
+		innerListener = proxyListener
 	}
-	listener.tcpListener = tcpListener
 
+	var tlsListener net.Listener
 	if listener.config.TlsCertPath != "" && listener.config.TlsKeyPath != "" {
-		tlsListener, err := NewTlsListener(tcpListener, listener.config.TlsCertPath, listener.config.TlsKeyPath)
+		tlsListener, err = NewTlsListener(innerListener, listener.config.TlsCertPath, listener.config.TlsKeyPath)
 		if err != nil {
-			tcpListener.Close()
-			return err
+			innerListener.Close()
+			return listener.status.Fail(err)
 		}
-		listener.tlsListener = tlsListener
 	}
 
+	listener.tcpListener = tcpListener
+	listener.proxyListener = proxyListener
+	listener.tlsListener = tlsListener
 	listener.stopChannel = make(chan struct{})
 
-	listener.status = status.Started
+	listener.status.Transition(status.Started, nil)
 	return nil
 }
 
@@ -11,16 +11,19 @@ func (listener *TcpListener) Stop() error {
 	listener.statusMutex.Lock()
 	defer listener.statusMutex.Unlock()
 
-	if listener.status != status.Started {
+	if !listener.status.Is(status.Started) {
 		return errors.New("tcpSystemgeListener is already stopped")
 	}
+	listener.status.Transition(status.Stopping, nil)
 
-	listener.tcpListener.Close()
-
-	if listener.tlsListener != nil {
-		listener.tlsListener.Close()
+	if listener.proxyListener != nil {
+		listener.proxyListener.Close()
 	}
+	listener.tcpListener.Close()
+	listener.tcpListener = nil
+	listener.proxyListener = nil
+	listener.tlsListener = nil
 
-	listener.status = status.Stopped
+	listener.status.Transition(status.Stopped, nil)
 	return nil
 }
@@ -0,0 +1,287 @@
+package listenerTcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neutralusername/systemge/tools"
+)
+
+var proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+const proxyProtocolV1MaxLength = 107
+
+const defaultProxyHeaderTimeout = 5 * time.Second
+
+// connections whose header is being read or which wait for Accept. further connections are not accepted until one is handed off.
+const maxPendingProxyConns = 128
+
+// wraps a net.Listener and parses a PROXY protocol v1/v2 header on every accepted connection.
+// the returned connections report the client address from the header as their remote address.
+// headers are read in a goroutine per connection, so clients that do not send a header cannot block Accept.
+type proxyProtocolListener struct {
+	net.Listener
+	trustedProxies *tools.AccessControlList // nil == all trusted
+	headerTimeout  time.Duration
+
+	results      chan *proxyProtocolResult
+	pending      chan struct{}
+	closeOnce    sync.Once
+	closeChannel chan struct{}
+
+	deadlineMutex sync.Mutex
+	deadline      time.Time
+}
+
+type proxyProtocolResult struct {
+	conn net.Conn
+	err  error
+}
+
+type proxyProtocolConn struct {
+	net.Conn
+	reader     *bufio.Reader
+	remoteAddr net.Addr
+}
+
+// headerTimeoutNs <= 0 == 5 seconds.
+func newProxyProtocolListener(listener net.Listener, trustedProxies *tools.AccessControlList, headerTimeoutNs int64) *proxyProtocolListener {
+	headerTimeout := time.Duration(headerTimeoutNs)
+	if headerTimeout <= 0 {
+		headerTimeout = defaultProxyHeaderTimeout
+	}
+	proxyListener := &proxyProtocolListener{
+		Listener:       listener,
+		trustedProxies: trustedProxies,
+		headerTimeout:  headerTimeout,
+		results:        make(chan *proxyProtocolResult),
+		pending:        make(chan struct{}, maxPendingProxyConns),
+		closeChannel:   make(chan struct{}),
+	}
+	go proxyListener.acceptRoutine()
+	return proxyListener
+}
+
+// returns the next connection whose header was read successfully or the error of a failed connection.
+// respects the deadline set by SetDeadline.
+func (listener *proxyProtocolListener) Accept() (net.Conn, error) {
+	listener.deadlineMutex.Lock()
+	deadline := listener.deadline
+	listener.deadlineMutex.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case result := <-listener.results:
+		return result.conn, result.err
+	case <-listener.closeChannel:
+		return nil, net.ErrClosed
+	case <-timeout:
+		return nil, os.ErrDeadlineExceeded
+	}
+}
+
+// sets the deadline of Accept. the wrapped listener has no deadline, since it is accepted from continuously.
+func (listener *proxyProtocolListener) SetDeadline(deadline time.Time) error {
+	listener.deadlineMutex.Lock()
+	defer listener.deadlineMutex.Unlock()
+	listener.deadline = deadline
+	return nil
+}
+
+func (listener *proxyProtocolListener) Close() error {
+	err := net.ErrClosed
+	listener.closeOnce.Do(func() {
+		close(listener.closeChannel)
+		err = listener.Listener.Close()
+	})
+	return err
+}
+
+func (listener *proxyProtocolListener) acceptRoutine() {
+	for {
+		select {
+		case listener.pending <- struct{}{}:
+		case <-listener.closeChannel:
+			return
+		}
+		netConn, err := listener.Listener.Accept()
+		if err != nil {
+			<-listener.pending
+			if errors.Is(err, net.ErrClosed) {
+				listener.Close()
+				return
+			}
+			listener.handOff(&proxyProtocolResult{err: err})
+			continue
+		}
+		go func() {
+			defer func() { <-listener.pending }()
+			conn, err := listener.readHeader(netConn)
+			if !listener.handOff(&proxyProtocolResult{conn: conn, err: err}) && conn != nil {
+				conn.Close()
+			}
+		}()
+	}
+}
+
+// returns false if the listener was closed before the result was accepted.
+func (listener *proxyProtocolListener) handOff(result *proxyProtocolResult) bool {
+	select {
+	case listener.results <- result:
+		return true
+	case <-listener.closeChannel:
+		return false
+	}
+}
+
+func (listener *proxyProtocolListener) readHeader(netConn net.Conn) (net.Conn, error) {
+	if listener.trustedProxies != nil {
+		ip, _, err := net.SplitHostPort(netConn.RemoteAddr().String())
+		if err != nil {
+			netConn.Close()
+			return nil, err
+		}
+		if !listener.trustedProxies.Contains(ip) {
+			netConn.Close()
+			return nil, errors.New("untrusted proxy")
+		}
+	}
+
+	netConn.SetReadDeadline(time.Now().Add(listener.headerTimeout))
+	reader := bufio.NewReader(netConn)
+	remoteAddr, err := readProxyProtocolHeader(reader)
+	if err != nil {
+		netConn.Close()
+		// not returned as is, so that a timed out header is not mistaken for an expired accept deadline
+		return nil, errors.New("failed to read proxy protocol header: " + err.Error())
+	}
+	netConn.SetReadDeadline(time.Time{})
+
+	if remoteAddr == nil {
+		remoteAddr = netConn.RemoteAddr()
+	}
+	return &proxyProtocolConn{
+		Conn:       netConn,
+		reader:     reader,
+		remoteAddr: remoteAddr,
+	}, nil
+}
+
+func (conn *proxyProtocolConn) Read(b []byte) (int, error) {
+	return conn.reader.Read(b)
+}
+
+func (conn *proxyProtocolConn) RemoteAddr() net.Addr {
+	return conn.remoteAddr
+}
+
+// returns nil address if the header does not carry a client address (v1 "UNKNOWN" or v2 "LOCAL").
+func readProxyProtocolHeader(reader *bufio.Reader) (net.Addr, error) {
+	signature, err := reader.Peek(len(proxyProtocolV2Signature))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(signature, proxyProtocolV2Signature) {
+		return readProxyProtocolV2Header(reader)
+	}
+	if bytes.HasPrefix(signature, []byte("PROXY ")) {
+		return readProxyProtocolV1Header(reader)
+	}
+	return nil, errors.New("missing proxy protocol header")
+}
+
+func readProxyProtocolV1Header(reader *bufio.Reader) (net.Addr, error) {
+	line := make([]byte, 0, proxyProtocolV1MaxLength)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+		if len(line) >= proxyProtocolV1MaxLength {
+			return nil, errors.New("proxy protocol v1 header too long")
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("invalid proxy protocol v1 header")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errors.New("invalid proxy protocol v1 header")
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil {
+		return nil, errors.New("invalid proxy protocol v1 source address")
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, errors.New("invalid proxy protocol v1 source port")
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readProxyProtocolV2Header(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	if header[12]>>4 != 2 {
+		return nil, errors.New("unsupported proxy protocol version")
+	}
+	command := header[12] & 0x0F
+	family := header[13]
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+
+	switch command {
+	case 0x0: // LOCAL (e.g. health checks of the proxy itself)
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, errors.New("invalid proxy protocol v2 command")
+	}
+
+	switch family {
+	case 0x11: // TCP over IPv4
+		if len(payload) < 12 {
+			return nil, errors.New("invalid proxy protocol v2 address length")
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case 0x21: // TCP over IPv6
+		if len(payload) < 36 {
+			return nil, errors.New("invalid proxy protocol v2 address length")
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	default: // UNSPEC, UDP and unix sockets carry no usable client address
+		return nil, nil
+	}
+}
@@ -17,32 +17,69 @@ func (listener *WebsocketListener) Accept(timeoutNs int64) (systemge.Connection[
 		listener.timeout.Trigger()
 		listener.timeout = nil
 	}()
-	select {
-	case <-listener.stopChannel:
-		return nil, errors.New("listener stopped")
+	for {
+		select {
+		case <-listener.stopChannel:
+			return nil, errors.New("listener stopped")
 
-	case <-listener.timeout.GetIsExpiredChannel():
-		return nil, errors.New("accept canceled")
+		case <-listener.timeout.GetIsExpiredChannel():
+			return nil, errors.New("accept canceled")
 
-	case upgraderResponseChannel := <-listener.upgradeRequests:
-		upgraderResponse := <-upgraderResponseChannel
+		case upgraderResponseChannel := <-listener.upgradeRequests:
+			upgraderResponse := <-upgraderResponseChannel
 
-		if upgraderResponse.err != nil {
-			listener.ClientsFailed.Add(1)
-			return nil, upgraderResponse.err
-		}
-		websocketClient, err := connectionWebsocket.New(upgraderResponse.websocketConn, listener.incomingMessageByteLimit)
-		if err != nil {
-			listener.ClientsFailed.Add(1)
-			upgraderResponse.websocketConn.Close()
-			return nil, err
-		}
-		listener.ClientsAccepted.Add(1)
-		return websocketClient, nil
+			if upgraderResponse.err != nil {
+				listener.ClientsFailed.Add(1)
+				if listener.emitAcceptFailed(upgraderResponse.err) == tools.Retry {
+					continue
+				}
+				return nil, upgraderResponse.err
+			}
+			websocketClient, err := connectionWebsocket.New(upgraderResponse.websocketConn, listener.incomingMessageByteLimit)
+			if err != nil {
+				listener.ClientsFailed.Add(1)
+				upgraderResponse.websocketConn.Close()
+				if listener.emitAcceptFailed(err) == tools.Retry {
+					continue
+				}
+				return nil, err
+			}
+			websocketClient.SetAddress(upgraderResponse.address)
+			websocketClient.SetRequestMetadata(upgraderResponse.metadata)
+
+			if listener.eventHandler.Emit(
+				tools.EVENT_LISTENER_ACCEPT_SUCCEEDED,
+				systemge.NewEventContext[[]byte](listener, websocketClient, nil),
+				tools.Continue, tools.Cancel,
+			) == tools.Cancel {
+				listener.ClientsFailed.Add(1)
+				websocketClient.Close()
+				return nil, errors.New("accept canceled by event handler")
+			}
 
+			listener.ClientsAccepted.Add(1)
+			return websocketClient, nil
+		}
 	}
 }
 
+func (listener *WebsocketListener) emitAcceptFailed(err error) int8 {
+	return listener.eventHandler.Emit(
+		tools.EVENT_LISTENER_ACCEPT_FAILED,
+		systemge.NewEventContext[[]byte](listener, nil, err),
+		tools.Continue, tools.Retry, tools.Panic,
+	)
+}
+
+func (listener *WebsocketListener) SetEventHandler(eventHandler *tools.Handler) {
+	listener.eventHandler = eventHandler
+}
+
+// a failure to stop an owned http server is logged to logger.
+func (listener *WebsocketListener) SetLogger(logger *tools.Logger) {
+	listener.logger = logger
+}
+
 func (listener *WebsocketListener) SetAcceptDeadline(timeoutNs int64) {
 	if timeout := listener.timeout; timeout != nil {
 		timeout.Refresh(timeoutNs)
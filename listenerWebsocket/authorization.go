@@ -0,0 +1,63 @@
+package listenerWebsocket
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/connectionWebsocket"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// executed before the http request is upgraded.
+// metadata may be modified and will be attached to the resulting connection.
+// returning an error rejects the request with statusCode (403 if statusCode is 0).
+type UpgradeAuthorizer func(httpRequest *http.Request, metadata *connectionWebsocket.RequestMetadata) (statusCode int, err error)
+
+// sets the authorizer that is executed before every upgrade. nil (default) == no authorization.
+// should be called before the listener is started.
+// the listener returned by New and NewOnHTTPServer can be asserted to *WebsocketListener to call this.
+func (listener *WebsocketListener) SetUpgradeAuthorizer(upgradeAuthorizer UpgradeAuthorizer) {
+	listener.upgradeAuthorizer = upgradeAuthorizer
+}
+
+// rejects requests that do not carry the id of an accepted session of sessionManager.
+// the session id is read from metadata.SessionId, which is populated from the configured SessionIdCookie.
+func NewSessionAuthorizer(sessionManager *tools.SessionManager) UpgradeAuthorizer {
+	return func(httpRequest *http.Request, metadata *connectionWebsocket.RequestMetadata) (int, error) {
+		if metadata.SessionId == "" {
+			return http.StatusUnauthorized, errors.New("no session id")
+		}
+		session := sessionManager.GetSession(metadata.SessionId)
+		if session == nil || !session.IsAccepted() {
+			return http.StatusUnauthorized, errors.New("invalid session id")
+		}
+		return 0, nil
+	}
+}
+
+func newRequestMetadata(httpRequest *http.Request, config *configs.WebsocketListener) *connectionWebsocket.RequestMetadata {
+	metadata := connectionWebsocket.NewRequestMetadata()
+	for _, header := range config.MetadataHeaders {
+		if value := httpRequest.Header.Get(header); value != "" {
+			metadata.Headers[header] = value
+		}
+	}
+	for _, cookieName := range config.MetadataCookies {
+		if cookie, err := httpRequest.Cookie(cookieName); err == nil {
+			metadata.Cookies[cookieName] = cookie.Value
+		}
+	}
+	query := httpRequest.URL.Query()
+	for _, queryParam := range config.MetadataQueryParams {
+		if query.Has(queryParam) {
+			metadata.QueryParams[queryParam] = query.Get(queryParam)
+		}
+	}
+	if config.SessionIdCookie != "" {
+		if cookie, err := httpRequest.Cookie(config.SessionIdCookie); err == nil {
+			metadata.SessionId = cookie.Value
+		}
+	}
+	return metadata
+}
@@ -16,11 +16,22 @@ import (
 )
 
 // wip
+// connects to the root path. use ConnectPattern for listeners mounted on another pattern.
 func Connect(
 	tcpClientConfig *configs.TcpClient,
 	incomingDataByteLimit uint64,
 	timeoutNs int64,
 ) (systemge.Connection[[]byte], error) {
+	return ConnectPattern(tcpClientConfig, "", incomingDataByteLimit, timeoutNs)
+}
+
+// pattern is the path the listener is mounted on (e.g. "/ws").
+func ConnectPattern(
+	tcpClientConfig *configs.TcpClient,
+	pattern string,
+	incomingDataByteLimit uint64,
+	timeoutNs int64,
+) (systemge.Connection[[]byte], error) {
 
 	dialer := websocket.DefaultDialer
 	dialer.HandshakeTimeout = time.Duration(timeoutNs)
@@ -54,7 +65,7 @@ func Connect(
 		tcpClientConfig.Ip = ip[0].String()
 	}
 
-	url := fmt.Sprintf("%s://%s", scheme, tcpClientConfig.Ip+":"+helpers.Uint16ToString(tcpClientConfig.Port))
+	url := fmt.Sprintf("%s://%s%s", scheme, tcpClientConfig.Ip+":"+helpers.Uint16ToString(tcpClientConfig.Port), pattern)
 
 	headers := http.Header{}
 
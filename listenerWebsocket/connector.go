@@ -7,19 +7,31 @@ import (
 
 type connector struct {
 	tcpClientConfig       *configs.TcpClient
+	pattern               string
 	incomingDataByteLimit uint64
 }
 
+// connects to the root path. use NewPatternConnector for listeners mounted on another pattern.
 func NewConnector(
 	tcpClientConfig *configs.TcpClient,
 	incomingDataByteLimit uint64,
+) systemge.Connector[[]byte] {
+	return NewPatternConnector(tcpClientConfig, "", incomingDataByteLimit)
+}
+
+// pattern is the path the listener is mounted on (e.g. "/ws").
+func NewPatternConnector(
+	tcpClientConfig *configs.TcpClient,
+	pattern string,
+	incomingDataByteLimit uint64,
 ) systemge.Connector[[]byte] {
 	return &connector{
 		tcpClientConfig:       tcpClientConfig,
+		pattern:               pattern,
 		incomingDataByteLimit: incomingDataByteLimit,
 	}
 }
 
 func (connector *connector) Connect(timeoutNs int64) (systemge.Connection[[]byte], error) {
-	return Connect(connector.tcpClientConfig, connector.incomingDataByteLimit, timeoutNs)
+	return ConnectPattern(connector.tcpClientConfig, connector.pattern, connector.incomingDataByteLimit, timeoutNs)
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/connectionWebsocket"
 	"github.com/neutralusername/systemge/constants"
 	"github.com/neutralusername/systemge/helpers"
 	"github.com/neutralusername/systemge/httpServer"
@@ -16,6 +17,9 @@ import (
 	"github.com/neutralusername/systemge/tools"
 )
 
+// emits the following events if an event handler is set (the first option is the default):
+//   - EVENT_LISTENER_ACCEPT_SUCCEEDED: Continue (return the connection), Cancel (close the connection and return an error)
+//   - EVENT_LISTENER_ACCEPT_FAILED: Continue (return the error), Retry (accept again. waits for the next upgrade request. only offered for failed upgrades), Panic
 type WebsocketListener struct {
 	config *configs.WebsocketListener
 	name   string
@@ -24,10 +28,11 @@ type WebsocketListener struct {
 	sessionId  string
 
 	statusMutex sync.Mutex
-	status      int
+	status      *status.Machine
 	stopChannel chan struct{}
 
-	httpServer *httpServer.HTTPServer
+	httpServer     *httpServer.HTTPServer
+	ownsHttpServer bool
 
 	upgradeRequests chan (<-chan *upgraderResponse)
 	timeout         *tools.Timeout
@@ -35,6 +40,12 @@ type WebsocketListener struct {
 
 	incomingMessageByteLimit uint64
 
+	trustedProxies    *tools.AccessControlList
+	upgradeAuthorizer UpgradeAuthorizer
+
+	eventHandler *tools.Handler
+	logger       *tools.Logger
+
 	// metrics
 
 	ClientsAccepted atomic.Uint64
@@ -45,8 +56,11 @@ type WebsocketListener struct {
 type upgraderResponse struct {
 	err           error
 	websocketConn *websocket.Conn
+	address       string
+	metadata      *connectionWebsocket.RequestMetadata
 }
 
+// creates a listener with its own http server, which is started and stopped together with the listener.
 func New(name string, httpWrapperHandler httpServer.WrapperHandler, config *configs.WebsocketListener, incomingMessageByteLimit uint64, connectionLifetimeNs int64) (systemge.Listener[[]byte], error) {
 	if config == nil {
 		return nil, errors.New("config is nil")
@@ -54,6 +68,37 @@ func New(name string, httpWrapperHandler httpServer.WrapperHandler, config *conf
 	if config.HttpTcpListenerConfig == nil {
 		return nil, errors.New("tcpServiceConfig is nil")
 	}
+	httpServer, err := httpServer.New(name+"_httpServer",
+		&configs.HTTPServer{
+			TcpListenerConfig: config.HttpTcpListenerConfig,
+		},
+		httpWrapperHandler,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return newListener(name, httpServer, true, config, incomingMessageByteLimit)
+}
+
+// creates a listener that mounts its pattern onto an existing http server (e.g. to share a port and tls certificate with other routes).
+// starting the listener adds its route, stopping it removes the route. the http server itself is neither started nor stopped.
+// several listeners with distinct patterns may share one http server.
+// config.HttpTcpListenerConfig is ignored.
+func NewOnHTTPServer(name string, httpServer *httpServer.HTTPServer, config *configs.WebsocketListener, incomingMessageByteLimit uint64) (systemge.Listener[[]byte], error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if httpServer == nil {
+		return nil, errors.New("httpServer is nil")
+	}
+	return newListener(name, httpServer, false, config, incomingMessageByteLimit)
+}
+
+func newListener(name string, httpServer *httpServer.HTTPServer, ownsHttpServer bool, config *configs.WebsocketListener, incomingMessageByteLimit uint64) (*WebsocketListener, error) {
+	if config.Pattern == "" {
+		return nil, errors.New("pattern is empty")
+	}
 	if config.Upgrader == nil {
 		config.Upgrader = &websocket.Upgrader{
 			ReadBufferSize:  4096,
@@ -67,44 +112,51 @@ func New(name string, httpWrapperHandler httpServer.WrapperHandler, config *conf
 	listener := &WebsocketListener{
 		name:                     name,
 		config:                   config,
-		status:                   status.Stopped,
+		status:                   status.NewMachine(),
 		instanceId:               tools.GenerateRandomString(constants.InstanceIdLength, tools.ALPHA_NUMERIC),
 		upgradeRequests:          make(chan (<-chan *upgraderResponse)),
 		incomingMessageByteLimit: incomingMessageByteLimit,
+		httpServer:               httpServer,
+		ownsHttpServer:           ownsHttpServer,
 	}
-	httpServer, err := httpServer.New(listener.name+"_httpServer",
-		&configs.HTTPServer{
-			TcpListenerConfig: listener.config.HttpTcpListenerConfig,
-		},
-		httpWrapperHandler,
-		map[string]http.HandlerFunc{
-			listener.config.Pattern: listener.getHTTPWebsocketUpgradeHandler(),
-		},
-	)
-	if err != nil {
-		return nil, err
+	if len(config.TrustedProxies) > 0 {
+		listener.trustedProxies = tools.NewAccessControlList(nil, nil)
+		for _, trustedProxy := range config.TrustedProxies {
+			if err := listener.trustedProxies.AddRange(trustedProxy); err != nil {
+				return nil, err
+			}
+		}
 	}
-	listener.httpServer = httpServer
-
 	return listener, nil
 }
 
 func (listener *WebsocketListener) GetConnector() systemge.Connector[[]byte] {
+	tcpListenerConfig := listener.httpServer.GetTcpListenerConfig()
 	config := &configs.TcpClient{
-		Port:   listener.config.HttpTcpListenerConfig.Port,
-		Ip:     listener.config.HttpTcpListenerConfig.Ip,
-		Domain: listener.config.HttpTcpListenerConfig.Domain,
+		Port:   tcpListenerConfig.Port,
+		Ip:     tcpListenerConfig.Ip,
+		Domain: tcpListenerConfig.Domain,
 	}
 
-	if listener.config.HttpTcpListenerConfig.TlsCertPath != "" && listener.config.HttpTcpListenerConfig.TlsKeyPath != "" {
-		config.TlsCert = helpers.GetFileContent(listener.config.HttpTcpListenerConfig.TlsCertPath)
+	if tcpListenerConfig.TlsCertPath != "" && tcpListenerConfig.TlsKeyPath != "" {
+		config.TlsCert = helpers.GetFileContent(tcpListenerConfig.TlsCertPath)
 	}
 	return &connector{
 		tcpClientConfig:       config,
+		pattern:               listener.config.Pattern,
 		incomingDataByteLimit: listener.incomingMessageByteLimit,
 	}
 }
 
+func (listener *WebsocketListener) GetHTTPServer() *httpServer.HTTPServer {
+	return listener.httpServer
+}
+
+// returns the address of the underlying http server (ip:port).
+func (listener *WebsocketListener) GetAddress() string {
+	return listener.httpServer.GetAddress()
+}
+
 func (listener *WebsocketListener) GetStopChannel() <-chan struct{} {
 	return listener.stopChannel
 }
@@ -118,6 +170,10 @@ func (listener *WebsocketListener) GetSessionId() string {
 }
 
 func (listener *WebsocketListener) GetStatus() int {
+	return listener.status.Get()
+}
+
+func (listener *WebsocketListener) GetStatusMachine() *status.Machine {
 	return listener.status
 }
 
@@ -12,20 +12,26 @@ func (listener *WebsocketListener) Start() error {
 	listener.statusMutex.Lock()
 	defer listener.statusMutex.Unlock()
 
-	if listener.status == status.Started {
-		return errors.New("tcpSystemgeListener is already started")
+	if err := listener.status.Transition(status.Starting, nil); err != nil {
+		return errors.New("websocketListener is already started")
 	}
 	listener.sessionId = tools.GenerateRandomString(constants.SessionIdLength, tools.ALPHA_NUMERIC)
-	listener.status = status.Pending
 	listener.stopChannel = make(chan struct{})
 
-	if err := listener.httpServer.Start(); err != nil {
+	if err := listener.httpServer.AddRouteIfAbsent(listener.config.Pattern, listener.getHTTPWebsocketUpgradeHandler()); err != nil {
 		close(listener.stopChannel)
-		listener.status = status.Stopped
-		return err
+		return listener.status.Fail(err)
 	}
 
-	listener.status = status.Started
+	if listener.ownsHttpServer {
+		if err := listener.httpServer.Start(); err != nil {
+			listener.httpServer.RemoveRoute(listener.config.Pattern)
+			close(listener.stopChannel)
+			return listener.status.Fail(err)
+		}
+	}
+
+	listener.status.Transition(status.Started, nil)
 
 	return nil
 }
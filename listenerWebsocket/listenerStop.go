@@ -10,17 +10,20 @@ func (listener *WebsocketListener) Stop() error {
 	listener.statusMutex.Lock()
 	defer listener.statusMutex.Unlock()
 
-	if listener.status == status.Stopped {
+	if !listener.status.Is(status.Started) {
 		return errors.New("websocketListener is already stopped")
 	}
 
-	listener.status = status.Pending
+	listener.status.Transition(status.Stopping, nil)
 	close(listener.stopChannel)
 
-	if err := listener.httpServer.Stop(); err != nil {
-		// something
+	listener.httpServer.RemoveRoute(listener.config.Pattern)
+	if listener.ownsHttpServer {
+		if err := listener.httpServer.Stop(); err != nil {
+			listener.logger.Warn("failed to stop http server", "listener", listener.name, "error", err)
+		}
 	}
 
-	listener.status = status.Stopped
+	listener.status.Transition(status.Stopped, nil)
 	return nil
 }
@@ -3,11 +3,24 @@ package listenerWebsocket
 import (
 	"net/http"
 
+	"github.com/neutralusername/systemge/httpServer"
 	"github.com/neutralusername/systemge/tools"
 )
 
 func (listener *WebsocketListener) getHTTPWebsocketUpgradeHandler() http.HandlerFunc {
 	return func(responseWriter http.ResponseWriter, httpRequest *http.Request) {
+		metadata := newRequestMetadata(httpRequest, listener.config)
+		if listener.upgradeAuthorizer != nil {
+			if statusCode, err := listener.upgradeAuthorizer(httpRequest, metadata); err != nil {
+				if statusCode == 0 {
+					statusCode = http.StatusForbidden
+				}
+				http.Error(responseWriter, http.StatusText(statusCode), statusCode)
+				listener.ClientsRejected.Add(1)
+				return
+			}
+		}
+
 		upgradeResponseChannel := make(chan *upgraderResponse)
 
 		timeout := tools.NewTimeout(listener.config.UpgradeRequestTimeoutNs, nil, false)
@@ -29,6 +42,8 @@ func (listener *WebsocketListener) getHTTPWebsocketUpgradeHandler() http.Handler
 			upgradeResponseChannel <- &upgraderResponse{
 				err:           err,
 				websocketConn: websocketConn,
+				address:       httpServer.ResolveClientAddress(httpRequest, listener.trustedProxies),
+				metadata:      metadata,
 			}
 		}
 	}
@@ -12,6 +12,10 @@ import (
 	"github.com/neutralusername/systemge/tools"
 )
 
+// emits the following events if an event handler is set (the first option is the default):
+//   - EVENT_READ_FAILED: Continue, Retry (read again. only offered while the connection is open), Cancel (stop the routine), Panic
+//   - EVENT_CONNECTION_CLOSED: Continue (the routine is stopped)
+//   - EVENT_ROUTINE_STOPPED: Continue
 type ReaderAsync[T any] struct {
 	connection systemge.Connection[T]
 
@@ -19,6 +23,10 @@ type ReaderAsync[T any] struct {
 
 	ReadHandler Handler[T]
 
+	logger       *tools.Logger
+	eventHandler *tools.Handler
+	closeEmitted atomic.Bool
+
 	// metrics
 
 	SucceededReads atomic.Uint64
@@ -46,36 +54,56 @@ func NewAsync[T any](
 	}
 
 	server := &ReaderAsync[T]{
+		connection:  connection,
 		ReadHandler: readHandler,
 	}
 
 	routine, err := tools.NewRoutine(
 		func(stopChannel <-chan struct{}) {
-			select {
-			case <-stopChannel:
-				connection.SetReadDeadline(1)
-				// routine was stopped
-				server.FailedReads.Add(1)
-				return
-
-			case <-connection.GetCloseChannel():
-				server.readRoutine.Stop()
-				// ending routine due to connection close
-				server.FailedReads.Add(1)
-				return
-
-			case data, ok := <-helpers.ChannelCall(func() (T, error) { return connection.Read(readerServerAsyncConfig.ReadTimeoutNs) }):
-				if !ok {
-					// do smthg with the error
+			for {
+				var readErr error // set before the channel of ChannelCall is closed
+				select {
+				case <-stopChannel:
+					connection.SetReadDeadline(1)
+					// routine was stopped
 					server.FailedReads.Add(1)
+					server.eventHandler.Emit(tools.EVENT_ROUTINE_STOPPED, systemge.NewEventContext(nil, connection, nil), tools.Continue)
+					return
+
+				case <-connection.GetCloseChannel():
+					// stopping blocks until this call returns
+					go server.readRoutine.Stop()
+					// ending routine due to connection close
+					server.FailedReads.Add(1)
+					if server.closeEmitted.CompareAndSwap(false, true) { // the routine may run again until it is stopped
+						server.eventHandler.Emit(tools.EVENT_CONNECTION_CLOSED, systemge.NewEventContext(nil, connection, nil), tools.Continue)
+					}
 					return
-				}
-				server.SucceededReads.Add(1)
 
-				if !readerServerAsyncConfig.HandleReadsConcurrently {
-					server.ReadHandler(data, connection)
-				} else {
-					go server.ReadHandler(data, connection)
+				case data, ok := <-helpers.ChannelCall(func() (T, error) {
+					data, err := connection.Read(readerServerAsyncConfig.ReadTimeoutNs)
+					readErr = err
+					return data, err
+				}):
+					if !ok {
+						server.logger.Warn("read failed", "address", connection.GetAddress(), "error", readErr)
+						server.FailedReads.Add(1)
+						switch emitReadFailed(server.eventHandler, connection, readErr) {
+						case tools.Retry:
+							continue
+						case tools.Cancel:
+							go server.readRoutine.Stop()
+						}
+						return
+					}
+					server.SucceededReads.Add(1)
+
+					if !readerServerAsyncConfig.HandleReadsConcurrently {
+						server.ReadHandler(data, connection)
+					} else {
+						go server.ReadHandler(data, connection)
+					}
+					return
 				}
 			}
 		},
@@ -89,6 +117,16 @@ func NewAsync[T any](
 	return server, nil
 }
 
+// the reader emits its events to eventHandler. should be called before the routine is started.
+func (server *ReaderAsync[T]) SetEventHandler(eventHandler *tools.Handler) {
+	server.eventHandler = eventHandler
+}
+
+// failed reads and writes are logged to logger. should be called before the routine is started.
+func (server *ReaderAsync[T]) SetLogger(logger *tools.Logger) {
+	server.logger = logger
+}
+
 func (server *ReaderAsync[T]) GetRoutine() *tools.Routine {
 	return server.readRoutine
 }
@@ -101,6 +139,7 @@ func (server *ReaderAsync[T]) CheckMetrics() tools.MetricsTypes {
 			"failedReads":    server.FailedReads.Load(),
 		},
 	))
+	metricsTypes.Merge(server.readRoutine.CheckMetrics())
 	metricsTypes.Merge(server.connection.CheckMetrics())
 	return metricsTypes
 }
@@ -112,6 +151,7 @@ func (server *ReaderAsync[T]) GetMetrics() tools.MetricsTypes {
 			"failedReads":    server.FailedReads.Swap(0),
 		},
 	))
+	metricsTypes.Merge(server.readRoutine.GetMetrics())
 	metricsTypes.Merge(server.connection.GetMetrics())
 	return metricsTypes
 }
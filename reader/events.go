@@ -0,0 +1,26 @@
+package reader
+
+import (
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+)
+
+// emits EVENT_CONNECTION_CLOSING and closes the connection unless Cancel is returned.
+func closeConnection[T any](eventHandler *tools.Handler, connection systemge.Connection[T], reason string) {
+	context := systemge.NewEventContext(nil, connection, nil)
+	context["reason"] = reason
+	if eventHandler.Emit(tools.EVENT_CONNECTION_CLOSING, context, tools.Continue, tools.Cancel) != tools.Cancel {
+		connection.Close()
+	}
+}
+
+// Retry is only offered while the connection is open, since reading from a closed connection fails immediately again.
+func emitReadFailed[T any](eventHandler *tools.Handler, connection systemge.Connection[T], err error) int8 {
+	options := []int8{tools.Cancel, tools.Panic}
+	select {
+	case <-connection.GetCloseChannel():
+	default:
+		options = append(options, tools.Retry)
+	}
+	return eventHandler.Emit(tools.EVENT_READ_FAILED, systemge.NewEventContext(nil, connection, err), tools.Continue, options...)
+}
@@ -2,6 +2,7 @@ package reader
 
 import (
 	"errors"
+	"net"
 
 	"github.com/neutralusername/systemge/configs"
 	"github.com/neutralusername/systemge/systemge"
@@ -91,7 +92,9 @@ func NewByteRateLimitHandler(
 
 	tokenBucketRateLimiter := tools.NewTokenBucketRateLimiter(tokenBucketRateLimiterConfig)
 	return func(data []byte, connection systemge.Connection[[]byte]) error {
-		tokenBucketRateLimiter.Consume(uint64(len(data)))
+		if !tokenBucketRateLimiter.Consume(uint64(len(data))) {
+			return errors.New("rate limited")
+		}
 		return nil
 	}
 }
@@ -104,7 +107,9 @@ func NewMessageRateLimitHandler[T any](
 
 	tokenBucketRateLimiter := tools.NewTokenBucketRateLimiter(tokenBucketRateLimiterConfig)
 	return func(data T, connection systemge.Connection[T]) error {
-		tokenBucketRateLimiter.Consume(1)
+		if !tokenBucketRateLimiter.Consume(1) {
+			return errors.New("rate limited")
+		}
 		return nil
 	}
 }
@@ -118,7 +123,35 @@ func NewCustomRateLimitHandler[T any](
 
 	tokenBucketRateLimiter := tools.NewTokenBucketRateLimiter(tokenBucketRateLimiterConfig)
 	return func(data T, connection systemge.Connection[T]) error {
-		tokenBucketRateLimiter.Consume(consumeFunc(data, connection))
+		if !tokenBucketRateLimiter.Consume(consumeFunc(data, connection)) {
+			return errors.New("rate limited")
+		}
+		return nil
+	}
+}
+
+// rejects data from connections whose ip is banned by banManager.
+// executes handler and registers a failure for the connection's ip if it returns an error.
+// wrap rate limit and validation handlers with this to ban repeat offenders.
+func NewBanHandler[T any](
+	banManager *tools.BanManager,
+	handler HandlerWithError[T],
+) HandlerWithError[T] {
+	return func(data T, connection systemge.Connection[T]) error {
+		ip, _, err := net.SplitHostPort(connection.GetAddress())
+		if err != nil {
+			return err
+		}
+		if banManager.IsBanned(ip) {
+			connection.Close()
+			return errors.New("banned")
+		}
+		if err := handler(data, connection); err != nil {
+			if banManager.RegisterFailure(ip) {
+				connection.Close()
+			}
+			return err
+		}
 		return nil
 	}
 }
@@ -210,12 +243,14 @@ type objectHandlerWrapper[T any, O any] struct {
 }
 
 // creates a new topic manager with the provided handlers.
+// failed sync handlers and failed response writes are logged to logger, which may be nil.
 func NewObjectTopicManager[T any, O any](
 	asyncObjectHandlers AsyncObjecthandlers[T, O],
 	syncObjectHandlers SyncObjectHandlers[T, O],
 	unknownAsyncObjectHandler AsyncObjectHandler[T, O],
 	unknownSyncObjectHandler SyncObjectHandler[T, O],
 	topicManagerConfig *configs.TopicManager,
+	logger *tools.Logger,
 ) (*tools.TopicManager[objectHandlerWrapper[T, O]], error) {
 
 	topicHandlers := tools.TopicHandlers[objectHandlerWrapper[T, O]]{}
@@ -228,12 +263,12 @@ func NewObjectTopicManager[T any, O any](
 		topicHandlers[topic] = func(mhw objectHandlerWrapper[T, O]) {
 			response, err := handler(mhw.connection, mhw.object)
 			if err != nil {
-				// do smthg w the err
+				logger.Warn("sync handler failed", "topic", topic, "address", mhw.connection.GetAddress(), "error", err)
 				return
 			}
 			err = mhw.connection.Write(response, 0)
 			if err != nil {
-				// do smthg w the err
+				logger.Warn("write failed", "topic", topic, "address", mhw.connection.GetAddress(), "error", err)
 			}
 		}
 	}
@@ -247,12 +282,12 @@ func NewObjectTopicManager[T any, O any](
 			if unknownSyncObjectHandler != nil {
 				response, err := unknownSyncObjectHandler(mhw.connection, mhw.object)
 				if err != nil {
-					// do smthg w the err
+					logger.Warn("unknown topic sync handler failed", "address", mhw.connection.GetAddress(), "error", err)
 					return
 				}
 				err = mhw.connection.Write(response, 0)
 				if err != nil {
-					// do smthg w the err
+					logger.Warn("write failed", "address", mhw.connection.GetAddress(), "error", err)
 				}
 			}
 		}
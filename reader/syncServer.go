@@ -12,6 +12,13 @@ import (
 	"github.com/neutralusername/systemge/tools"
 )
 
+// emits the following events if an event handler is set (the first option is the default):
+//   - EVENT_READ_FAILED: Continue, Retry (read again. only offered while the connection is open), Cancel (stop the routine), Panic
+//   - EVENT_READ_HANDLER_FAILED: Continue (keep the connection open. no result is written), Skip (close the connection), Panic
+//   - EVENT_WRITE_FAILED: Continue (keep the connection open), Skip (close the connection), Panic
+//   - EVENT_CONNECTION_CLOSING: Continue (close the connection), Cancel (keep the connection open). emitted after Skip with the reason "read handler failed" or "write failed"
+//   - EVENT_CONNECTION_CLOSED: Continue (the routine is stopped)
+//   - EVENT_ROUTINE_STOPPED: Continue
 type ReaderSync[T any] struct {
 	connection systemge.Connection[T]
 
@@ -19,6 +26,10 @@ type ReaderSync[T any] struct {
 
 	ReadHandler HandlerWithResult[T]
 
+	logger       *tools.Logger
+	eventHandler *tools.Handler
+	closeEmitted atomic.Bool
+
 	// metrics
 
 	SucceededReads atomic.Uint64
@@ -49,18 +60,27 @@ func NewSync[T any](
 	}
 
 	server := &ReaderSync[T]{
+		connection:  connection,
 		ReadHandler: readHandler,
 	}
 
 	handleRead := func(data T, connection systemge.Connection[T]) {
 		result, err := server.ReadHandler(data, connection)
 		if err != nil {
-			// do smthg with the error
+			server.logger.Warn("read handler failed", "address", connection.GetAddress(), "error", err)
 			server.FailedReads.Add(1)
+			if server.eventHandler.Emit(
+				tools.EVENT_READ_HANDLER_FAILED,
+				systemge.NewEventContext(nil, connection, err),
+				tools.Continue, tools.Skip, tools.Panic,
+			) == tools.Skip {
+				closeConnection(server.eventHandler, connection, "read handler failed")
+			}
 			return
 		}
 		server.SucceededReads.Add(1)
 
+		var writeErr error // set before the channel of ChannelCall is closed
 		select {
 		case <-server.readRoutine.GetStopChannel():
 			connection.SetWriteDeadline(1)
@@ -73,14 +93,22 @@ func NewSync[T any](
 			server.FailedWrites.Add(1)
 			return
 
-		case <-helpers.ChannelCall(func() (error, error) {
-			if err := connection.Write(result, readerServerSyncConfig.WriteTimeoutNs); err != nil {
-				// do smthg with the error
+		case _, ok := <-helpers.ChannelCall(func() (struct{}, error) {
+			writeErr = connection.Write(result, readerServerSyncConfig.WriteTimeoutNs)
+			return struct{}{}, writeErr
+		}):
+			if !ok {
+				server.logger.Warn("write failed", "address", connection.GetAddress(), "error", writeErr)
 				server.FailedWrites.Add(1)
-				return err, err
+				if server.eventHandler.Emit(
+					tools.EVENT_WRITE_FAILED,
+					systemge.NewEventContext(nil, connection, writeErr),
+					tools.Continue, tools.Skip, tools.Panic,
+				) == tools.Skip {
+					closeConnection(server.eventHandler, connection, "write failed")
+				}
+				return
 			}
-			return nil, nil
-		}):
 			server.SucceededWrites.Add(1)
 			return
 		}
@@ -88,29 +116,48 @@ func NewSync[T any](
 
 	routine, err := tools.NewRoutine(
 		func(stopChannel <-chan struct{}) {
-			select {
-			case <-stopChannel:
-				connection.SetReadDeadline(1)
-				// routine was stopped
-				server.FailedReads.Add(1)
-				return
-
-			case <-connection.GetCloseChannel():
-				server.readRoutine.Stop()
-				// ending routine due to connection close
-				server.FailedReads.Add(1)
-				return
+			for {
+				var readErr error // set before the channel of ChannelCall is closed
+				select {
+				case <-stopChannel:
+					connection.SetReadDeadline(1)
+					// routine was stopped
+					server.FailedReads.Add(1)
+					server.eventHandler.Emit(tools.EVENT_ROUTINE_STOPPED, systemge.NewEventContext(nil, connection, nil), tools.Continue)
+					return
 
-			case data, ok := <-helpers.ChannelCall(func() (T, error) { return connection.Read(readerServerSyncConfig.ReadTimeoutNs) }):
-				if !ok {
-					// do smthg with the error
+				case <-connection.GetCloseChannel():
+					// stopping blocks until this call returns
+					go server.readRoutine.Stop()
+					// ending routine due to connection close
 					server.FailedReads.Add(1)
+					if server.closeEmitted.CompareAndSwap(false, true) { // the routine may run again until it is stopped
+						server.eventHandler.Emit(tools.EVENT_CONNECTION_CLOSED, systemge.NewEventContext(nil, connection, nil), tools.Continue)
+					}
+					return
+
+				case data, ok := <-helpers.ChannelCall(func() (T, error) {
+					data, err := connection.Read(readerServerSyncConfig.ReadTimeoutNs)
+					readErr = err
+					return data, err
+				}):
+					if !ok {
+						server.logger.Warn("read failed", "address", connection.GetAddress(), "error", readErr)
+						server.FailedReads.Add(1)
+						switch emitReadFailed(server.eventHandler, connection, readErr) {
+						case tools.Retry:
+							continue
+						case tools.Cancel:
+							go server.readRoutine.Stop()
+						}
+						return
+					}
+					if !readerServerSyncConfig.HandleReadsConcurrently {
+						handleRead(data, connection)
+					} else {
+						go handleRead(data, connection)
+					}
 					return
-				}
-				if !readerServerSyncConfig.HandleReadsConcurrently {
-					handleRead(data, connection)
-				} else {
-					go handleRead(data, connection)
 				}
 			}
 		},
@@ -124,6 +171,16 @@ func NewSync[T any](
 	return server, nil
 }
 
+// the reader emits its events to eventHandler. should be called before the routine is started.
+func (server *ReaderSync[T]) SetEventHandler(eventHandler *tools.Handler) {
+	server.eventHandler = eventHandler
+}
+
+// failed reads and writes are logged to logger. should be called before the routine is started.
+func (server *ReaderSync[T]) SetLogger(logger *tools.Logger) {
+	server.logger = logger
+}
+
 func (server *ReaderSync[T]) GetRoutine() *tools.Routine {
 	return server.readRoutine
 }
@@ -138,6 +195,7 @@ func (server *ReaderSync[T]) CheckMetrics() tools.MetricsTypes {
 			"failedWrites":    server.FailedWrites.Load(),
 		},
 	))
+	metricsTypes.Merge(server.readRoutine.CheckMetrics())
 	metricsTypes.Merge(server.connection.CheckMetrics())
 	return metricsTypes
 }
@@ -151,6 +209,7 @@ func (server *ReaderSync[T]) GetMetrics() tools.MetricsTypes {
 			"failedWrites":    server.FailedWrites.Swap(0),
 		},
 	))
+	metricsTypes.Merge(server.readRoutine.GetMetrics())
 	metricsTypes.Merge(server.connection.GetMetrics())
 	return metricsTypes
 }
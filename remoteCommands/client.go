@@ -0,0 +1,89 @@
+package remoteCommands
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+)
+
+var ErrNoResponse = errors.New("no response received")
+
+// executes commands on a remote Server.
+// the client does not read from the connection. responses must be passed to HandleResponse by whoever does.
+type Client struct {
+	config                 *configs.CommandClient
+	connection             systemge.Connection[*tools.Message]
+	requestResponseManager *tools.RequestResponseManager[*tools.Message]
+}
+
+// if requestResponseManager is nil, a new one is created.
+// sharing one allows the connection to be used for other sync requests as well.
+func NewClient(
+	config *configs.CommandClient,
+	connection systemge.Connection[*tools.Message],
+	requestResponseManager *tools.RequestResponseManager[*tools.Message],
+) (*Client, error) {
+
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if connection == nil {
+		return nil, errors.New("connection is nil")
+	}
+	if config.SyncTokenLength == 0 {
+		config.SyncTokenLength = 32
+	}
+	if requestResponseManager == nil {
+		requestResponseManager = tools.NewRequestResponseManager[*tools.Message](nil)
+	}
+	return &Client{
+		config:                 config,
+		connection:             connection,
+		requestResponseManager: requestResponseManager,
+	}, nil
+}
+
+// executes command of the remote command handlers name and blocks until the response is received.
+func (client *Client) Execute(name string, command string, args []string) (string, error) {
+	payload, err := json.Marshal(&Request{
+		Name: name,
+		Command: tools.Command{
+			Command: command,
+			Args:    args,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	syncToken := tools.GenerateRandomString(client.config.SyncTokenLength, tools.ALPHA_NUMERIC)
+	request, err := client.requestResponseManager.NewRequest(syncToken, 1, client.config.RequestTimeoutNs, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := client.connection.Write(tools.NewSync(TOPIC_COMMAND, string(payload), syncToken), client.config.WriteTimeoutNs); err != nil {
+		client.requestResponseManager.AbortRequest(syncToken)
+		return "", err
+	}
+
+	response, err := request.GetNextResponse()
+	if err != nil {
+		return "", ErrNoResponse
+	}
+	if response.GetTopic() != tools.TOPIC_SUCCESS {
+		return "", errors.New(response.GetPayload())
+	}
+	return response.GetPayload(), nil
+}
+
+// passes a response message to the request it belongs to.
+// may be used as reader.HandlerWithError for async readers.
+func (client *Client) HandleResponse(message *tools.Message, connection systemge.Connection[*tools.Message]) error {
+	if !message.IsResponse() {
+		return errors.New("message is not a response")
+	}
+	return client.requestResponseManager.AddResponse(message.GetSyncToken(), message)
+}
@@ -0,0 +1,179 @@
+package remoteCommands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/systemge"
+	"github.com/neutralusername/systemge/tools"
+)
+
+const TOPIC_COMMAND = "command"
+
+// payload of a command message.
+type Request struct {
+	Name string `json:"name"`
+	tools.Command
+}
+
+// returns the identity of the caller of a connection, which is used for permission checks.
+type GetIdentity func(connection systemge.Connection[*tools.Message]) (string, error)
+
+// executes commands of named command handlers on behalf of remote callers.
+// []byte connections can be used through typedConnection with tools.JsonMarshalMessage/tools.JsonUnmarshalMessage.
+type Server struct {
+	config      *configs.CommandServer
+	getIdentity GetIdentity
+
+	mutex           sync.RWMutex
+	commandHandlers map[string]tools.CommandHandlers
+
+	// metrics
+
+	SucceededCommands atomic.Uint64
+	FailedCommands    atomic.Uint64
+	RejectedCommands  atomic.Uint64
+}
+
+// if getIdentity is nil, the ip of the connection's address is used as identity.
+func NewServer(config *configs.CommandServer, getIdentity GetIdentity) (*Server, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if getIdentity == nil {
+		getIdentity = func(connection systemge.Connection[*tools.Message]) (string, error) {
+			ip, _, err := net.SplitHostPort(connection.GetAddress())
+			if err != nil {
+				return "", err
+			}
+			return ip, nil
+		}
+	}
+	return &Server{
+		config:          config,
+		getIdentity:     getIdentity,
+		commandHandlers: make(map[string]tools.CommandHandlers),
+	}, nil
+}
+
+func (server *Server) AddCommandHandlers(name string, commandHandlers tools.CommandHandlers) error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if _, ok := server.commandHandlers[name]; ok {
+		return errors.New("command handlers already exist")
+	}
+	server.commandHandlers[name] = commandHandlers
+	return nil
+}
+
+func (server *Server) RemoveCommandHandlers(name string) error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if _, ok := server.commandHandlers[name]; !ok {
+		return errors.New("command handlers do not exist")
+	}
+	delete(server.commandHandlers, name)
+	return nil
+}
+
+// executes the command of a command message and returns the response message.
+// failures are returned as failure responses, so the caller is informed about them.
+// an error is only returned if the message is not a command request.
+// may be used as reader.HandlerWithResult for sync readers.
+func (server *Server) GetResponse(message *tools.Message, connection systemge.Connection[*tools.Message]) (*tools.Message, error) {
+	if message.IsResponse() || message.GetTopic() != TOPIC_COMMAND {
+		return nil, errors.New("message is not a command request")
+	}
+	if message.GetSyncToken() == "" {
+		return nil, errors.New("message has no sync token")
+	}
+
+	request := &Request{}
+	if err := json.Unmarshal([]byte(message.GetPayload()), request); err != nil {
+		server.FailedCommands.Add(1)
+		return message.NewFailureResponse("invalid request: " + err.Error()), nil
+	}
+
+	identity, err := server.getIdentity(connection)
+	if err != nil {
+		server.RejectedCommands.Add(1)
+		return message.NewFailureResponse("failed to get identity: " + err.Error()), nil
+	}
+	if !server.isPermitted(identity, request.Name, request.Command.Command) {
+		server.RejectedCommands.Add(1)
+		return message.NewFailureResponse("permission denied"), nil
+	}
+
+	server.mutex.RLock()
+	commandHandlers, ok := server.commandHandlers[request.Name]
+	server.mutex.RUnlock()
+	if !ok {
+		server.FailedCommands.Add(1)
+		return message.NewFailureResponse("command handlers do not exist"), nil
+	}
+
+	result, err := executeSafely(commandHandlers, request.Command.Command, request.Args)
+	if err != nil {
+		server.FailedCommands.Add(1)
+		return message.NewFailureResponse(err.Error()), nil
+	}
+	server.SucceededCommands.Add(1)
+	return message.NewSuccessResponse(result), nil
+}
+
+// executes the command and returns a panic of its handler as error, so a faulty handler does not crash the process.
+func executeSafely(commandHandlers tools.CommandHandlers, command string, args []string) (result string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("command panicked: %v", recovered)
+		}
+	}()
+	return commandHandlers.Execute(command, args)
+}
+
+// writes the response of a command message to the connection.
+// may be used as reader.HandlerWithError for async readers.
+func (server *Server) HandleMessage(message *tools.Message, connection systemge.Connection[*tools.Message]) error {
+	response, err := server.GetResponse(message, connection)
+	if err != nil {
+		return err
+	}
+	return connection.Write(response, server.config.WriteTimeoutNs)
+}
+
+func (server *Server) isPermitted(identity string, name string, command string) bool {
+	for _, permission := range server.config.Permissions[identity] {
+		if permission == "*" || permission == name || permission == name+":"+command {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *Server) CheckMetrics() tools.MetricsTypes {
+	metricsTypes := tools.NewMetricsTypes()
+	metricsTypes.AddMetrics("command_server", tools.NewMetrics(
+		map[string]uint64{
+			"succeededCommands": server.SucceededCommands.Load(),
+			"failedCommands":    server.FailedCommands.Load(),
+			"rejectedCommands":  server.RejectedCommands.Load(),
+		},
+	))
+	return metricsTypes
+}
+func (server *Server) GetMetrics() tools.MetricsTypes {
+	metricsTypes := tools.NewMetricsTypes()
+	metricsTypes.AddMetrics("command_server", tools.NewMetrics(
+		map[string]uint64{
+			"succeededCommands": server.SucceededCommands.Swap(0),
+			"failedCommands":    server.FailedCommands.Swap(0),
+			"rejectedCommands":  server.RejectedCommands.Swap(0),
+		},
+	))
+	return metricsTypes
+}
@@ -11,6 +11,7 @@ type PublishSubscribeServer[T any] struct {
 	accepter               *accepter.Accepter[T]
 	requestResponseManager *tools.RequestResponseManager[T]
 	handleMessage          HandleMessage[T]
+	logger                 *tools.Logger
 }
 
 type subscriber[T any] struct {
@@ -89,7 +90,7 @@ func NewPublishSubscribeServer[T any](
 		accepterRoutineConfig,
 		func(connection systemge.Connection[T]) error {
 			if err := acceptHandler(connection); err != nil {
-				// do smthg with the error
+				publishSubscribeServer.logger.Warn("accept handler failed", "address", connection.GetAddress(), "error", err)
 				return nil
 			}
 
@@ -100,7 +101,7 @@ func NewPublishSubscribeServer[T any](
 				publishSubscribeServer.readHandler,
 			)
 			if err != nil {
-				// do smthg with the error
+				publishSubscribeServer.logger.Warn("failed to create reader", "address", connection.GetAddress(), "error", err)
 				return err
 			}
 
@@ -140,7 +141,6 @@ func NewPublishSubscribeServer[T any](
 		},
 	)
 	if err != nil {
-		// do smthg with the error (? accepter failed to start)
 		return nil, err
 	}
 
@@ -154,7 +154,7 @@ func (publishSubscribeServer *PublishSubscribeServer[T]) readHandler(
 ) {
 	messageType, topic, payload, syncToken, err := publishSubscribeServer.handleMessage(data, connection)
 	if err != nil {
-		// do smthg with the error
+		publishSubscribeServer.logger.Warn("message handler failed", "address", connection.GetAddress(), "error", err)
 		return
 	}
 
@@ -165,7 +165,7 @@ func (publishSubscribeServer *PublishSubscribeServer[T]) readHandler(
 
 		subscriber, ok := publishSubscribeServer.subscribers[connection]
 		if !ok {
-			// do smthg with the error
+			publishSubscribeServer.logger.Warn("subscribe from unknown connection", "topic", topic, "address", connection.GetAddress())
 			return
 		}
 		subscriber.subscriptions[topic] = struct{}{}
@@ -176,7 +176,7 @@ func (publishSubscribeServer *PublishSubscribeServer[T]) readHandler(
 
 		subscriber, ok := publishSubscribeServer.subscribers[connection]
 		if !ok {
-			// do smthg with the error
+			publishSubscribeServer.logger.Warn("unsubscribe from unknown connection", "topic", topic, "address", connection.GetAddress())
 			return
 		}
 		delete(subscriber.subscriptions, topic)
@@ -199,7 +199,7 @@ func (publishSubscribeServer *PublishSubscribeServer[T]) readHandler(
 		publishSubscribeServer.Propagate(connection, topic, payload)
 
 	default:
-		// unknown message type
+		publishSubscribeServer.logger.Warn("unknown message type", "messageType", messageType, "address", connection.GetAddress())
 	}
 }
 
@@ -213,7 +213,7 @@ func (publishSubscribeServer *PublishSubscribeServer[T]) Propagate(
 
 	subscribers, ok := publishSubscribeServer.topics[topic]
 	if !ok {
-		// do smthg with the error
+		publishSubscribeServer.logger.Warn("propagate to unknown topic", "topic", topic, "address", publisher.GetAddress())
 		return
 	}
 
@@ -237,7 +237,7 @@ func (publishSubscribeServer *PublishSubscribeServer[T]) Request(
 			go requester.Write(response, publishSubscribeServer.config.PropagateTimeoutNs)
 		},
 	); err != nil {
-		// do smthg with the error
+		publishSubscribeServer.logger.Warn("failed to create request", "syncToken", syncToken, "address", requester.GetAddress(), "error", err)
 		return
 	}
 }
@@ -247,8 +247,13 @@ func (publishSubscribeServer *PublishSubscribeServer[T]) Respond(
 	payload T,
 ) {
 	if err := publishSubscribeServer.requestResponseManager.AddResponse(syncToken, payload); err != nil { // currently has the side effect, that responses to requests may have any topic. might as well be a feature
-		// do smthg with the error
+		publishSubscribeServer.logger.Warn("failed to add response", "syncToken", syncToken, "error", err)
 		return
 	}
 }
+
+// failed accept handlers, message handlers, requests and responses are logged to logger. should be called before the accepter is started.
+func (publishSubscribeServer *PublishSubscribeServer[T]) SetLogger(logger *tools.Logger) {
+	publishSubscribeServer.logger = logger
+}
 */
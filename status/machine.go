@@ -0,0 +1,187 @@
+package status
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// valid transitions of a Machine (from -> to).
+var transitions = map[int][]int{
+	Stopped:  {Starting},
+	Starting: {Started, Failed},
+	Started:  {Stopping, Failed},
+	Stopping: {Stopped, Failed},
+	Failed:   {Starting, Stopping},
+}
+
+type Transition struct {
+	From  int
+	To    int
+	Time  time.Time
+	Error error // set for transitions to Failed
+}
+
+// tracks the status of a component and enforces valid transitions:
+// Stopped -> Starting -> Started -> Stopping -> Stopped.
+// Starting, Started and Stopping may fail, after which the component may be started or stopped again.
+// safe for concurrent use. components serialize their own start/stop operations.
+type Machine struct {
+	mutex       sync.RWMutex
+	status      int
+	since       time.Time
+	lastError   error
+	lastErrorAt time.Time
+
+	subscribers map[<-chan *Transition]chan *Transition
+}
+
+// the machine starts in Stopped.
+func NewMachine() *Machine {
+	return &Machine{
+		status:      Stopped,
+		since:       time.Now(),
+		subscribers: make(map[<-chan *Transition]chan *Transition),
+	}
+}
+
+func (machine *Machine) Get() int {
+	machine.mutex.RLock()
+	defer machine.mutex.RUnlock()
+	return machine.status
+}
+
+// returns true if the current status is one of statuses.
+func (machine *Machine) Is(statuses ...int) bool {
+	current := machine.Get()
+	for _, status := range statuses {
+		if current == status {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the time of the last transition (or of the creation of the machine).
+func (machine *Machine) GetSince() time.Time {
+	machine.mutex.RLock()
+	defer machine.mutex.RUnlock()
+	return machine.since
+}
+
+// returns the error of the last transition to Failed. nil if the machine never failed.
+func (machine *Machine) GetLastError() error {
+	machine.mutex.RLock()
+	defer machine.mutex.RUnlock()
+	return machine.lastError
+}
+
+// returns the time of the last transition to Failed. zero if the machine never failed.
+func (machine *Machine) GetLastErrorTime() time.Time {
+	machine.mutex.RLock()
+	defer machine.mutex.RUnlock()
+	return machine.lastErrorAt
+}
+
+func (machine *Machine) CanTransition(to int) bool {
+	machine.mutex.RLock()
+	defer machine.mutex.RUnlock()
+	return isValidTransition(machine.status, to)
+}
+
+// err is required for transitions to Failed and ignored otherwise.
+func (machine *Machine) Transition(to int, err error) error {
+	if to == Failed && err == nil {
+		return errors.New("err is nil")
+	}
+	machine.mutex.Lock()
+	defer machine.mutex.Unlock()
+	if !isValidTransition(machine.status, to) {
+		return errors.New("invalid status transition from " + ToString(machine.status) + " to " + ToString(to))
+	}
+	transition := &Transition{
+		From: machine.status,
+		To:   to,
+		Time: time.Now(),
+	}
+	if to == Failed {
+		transition.Error = err
+		machine.lastError = err
+		machine.lastErrorAt = transition.Time
+	}
+	machine.status = to
+	machine.since = transition.Time
+	for _, subscriber := range machine.subscribers {
+		select {
+		case subscriber <- transition:
+		default:
+		}
+	}
+	return nil
+}
+
+// transitions to Failed and returns err, so that failing operations can "return machine.Fail(err)".
+func (machine *Machine) Fail(err error) error {
+	machine.Transition(Failed, err)
+	return err
+}
+
+// returns a channel that receives all following transitions.
+// transitions are dropped if the buffer of the channel is full, so the machine never blocks on slow subscribers.
+func (machine *Machine) Subscribe(bufferSize int) <-chan *Transition {
+	machine.mutex.Lock()
+	defer machine.mutex.Unlock()
+	subscriber := make(chan *Transition, bufferSize)
+	machine.subscribers[subscriber] = subscriber
+	return subscriber
+}
+
+// closes the channel.
+func (machine *Machine) Unsubscribe(subscriber <-chan *Transition) error {
+	machine.mutex.Lock()
+	defer machine.mutex.Unlock()
+	channel, ok := machine.subscribers[subscriber]
+	if !ok {
+		return errors.New("subscriber not found")
+	}
+	delete(machine.subscribers, subscriber)
+	close(channel)
+	return nil
+}
+
+// blocks until the machine reaches one of statuses. returns false if the timeout expires first.
+// timeoutNs <= 0 waits indefinitely.
+func (machine *Machine) WaitFor(timeoutNs int64, statuses ...int) bool {
+	subscriber := machine.Subscribe(1)
+	defer machine.Unsubscribe(subscriber)
+	if machine.Is(statuses...) {
+		return true
+	}
+
+	var deadline <-chan time.Time
+	if timeoutNs > 0 {
+		timer := time.NewTimer(time.Duration(timeoutNs))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	for {
+		select {
+		case <-subscriber:
+			// the status is read again since transitions may have been dropped
+			if machine.Is(statuses...) {
+				return true
+			}
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func isValidTransition(from int, to int) bool {
+	for _, valid := range transitions[from] {
+		if valid == to {
+			return true
+		}
+	}
+	return false
+}
@@ -3,8 +3,11 @@ package status
 const (
 	Non_Existant = -1
 	Stopped      = 0
-	Pending      = 1
+	Pending      = 1 // transitional status of components that do not distinguish between starting and stopping
 	Started      = 2
+	Starting     = 3
+	Stopping     = 4
+	Failed       = 5
 )
 
 func ToString(status int) string {
@@ -12,11 +15,17 @@ func ToString(status int) string {
 	case Non_Existant:
 		return "Non_Existant"
 	case Stopped:
-		return "Stoped"
+		return "Stopped"
 	case Pending:
 		return "Pending"
 	case Started:
 		return "Started"
+	case Starting:
+		return "Starting"
+	case Stopping:
+		return "Stopping"
+	case Failed:
+		return "Failed"
 	default:
 		return "Unknown"
 	}
@@ -24,7 +33,17 @@ func ToString(status int) string {
 
 func IsValidStatus(status int) bool {
 	switch status {
-	case Non_Existant, Stopped, Pending, Started:
+	case Non_Existant, Stopped, Pending, Started, Starting, Stopping, Failed:
+		return true
+	default:
+		return false
+	}
+}
+
+// returns true for statuses a component passes through while starting or stopping.
+func IsTransitional(status int) bool {
+	switch status {
+	case Pending, Starting, Stopping:
 		return true
 	default:
 		return false
@@ -0,0 +1,22 @@
+package systemge
+
+import (
+	"github.com/neutralusername/systemge/tools"
+)
+
+// returns the context of an event. listener, connection and err may be nil.
+func NewEventContext[T any](listener Listener[T], connection Connection[T], err error) tools.Context {
+	context := tools.Context{}
+	if listener != nil {
+		context["listenerName"] = listener.GetName()
+		context["listenerInstanceId"] = listener.GetInstanceId()
+	}
+	if connection != nil {
+		context["connectionInstanceId"] = connection.GetInstanceId()
+		context["address"] = connection.GetAddress()
+	}
+	if err != nil {
+		context["error"] = err.Error()
+	}
+	return context
+}
@@ -1,6 +1,7 @@
 package systemge
 
 import (
+	"github.com/neutralusername/systemge/status"
 	"github.com/neutralusername/systemge/tools"
 )
 
@@ -18,10 +19,12 @@ type Listener[T any] interface {
 	GetSessionId() string
 	GetName() string
 	GetStatus() int
+	GetStatusMachine() *status.Machine // for reading and subscribing to the status. transitions are made by the listener
 	GetStopChannel() <-chan struct{}
 
 	Accept(int64) (Connection[T], error)
 	SetAcceptDeadline(int64)
+	SetEventHandler(*tools.Handler) // should be called before the listener is started
 
 	GetDefaultCommands() tools.CommandHandlers
 
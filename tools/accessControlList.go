@@ -1,55 +1,155 @@
 package tools
 
 import (
+	"errors"
+	"net/netip"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/neutralusername/systemge/configs"
 	"github.com/neutralusername/systemge/helpers"
 )
 
 type AccessControlList struct {
-	list  map[string]bool
-	mutex sync.Mutex
+	config *configs.AccessControlList
+	list   map[string]time.Time // zero time == never expires
+	ranges *IpPrefixTrie
+	mutex  sync.Mutex
 }
 
-func NewAccessControlList(entries []string) *AccessControlList {
-	list := map[string]bool{}
+// config may be nil, in which case the list is not persisted.
+func NewAccessControlList(config *configs.AccessControlList, entries []string) *AccessControlList {
+	if config == nil {
+		config = &configs.AccessControlList{}
+	}
+	list := map[string]time.Time{}
 	for _, item := range entries {
-		list[item] = true
+		list[item] = time.Time{}
 	}
 	return &AccessControlList{
-		list: list,
+		config: config,
+		list:   list,
+		ranges: NewIpPrefixTrie(),
 	}
 }
 
+// adds a cidr range (e.g. "10.0.0.0/8" or "2001:db8::/64").
+// ips within the range are considered to be contained in the list.
+func (acl *AccessControlList) AddRange(cidr string) error {
+	prefix, err := ParseIpPrefix(cidr)
+	if err != nil {
+		return err
+	}
+	acl.mutex.Lock()
+	defer acl.mutex.Unlock()
+	if !acl.ranges.Insert(prefix) {
+		return errors.New("range already exists")
+	}
+	return nil
+}
+
+func (acl *AccessControlList) RemoveRange(cidr string) error {
+	prefix, err := ParseIpPrefix(cidr)
+	if err != nil {
+		return err
+	}
+	acl.mutex.Lock()
+	defer acl.mutex.Unlock()
+	if !acl.ranges.Remove(prefix) {
+		return errors.New("range not found")
+	}
+	return nil
+}
+
+func (acl *AccessControlList) GetRanges() []string {
+	acl.mutex.Lock()
+	defer acl.mutex.Unlock()
+	prefixes := acl.ranges.GetPrefixes()
+	ranges := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		ranges = append(ranges, prefix.String())
+	}
+	return ranges
+}
+
 func (acl *AccessControlList) Add(item string) {
 	acl.mutex.Lock()
 	defer acl.mutex.Unlock()
-	acl.list[item] = true
+	acl.list[item] = time.Time{}
+}
+
+// adds item to the list, which will be removed once lifetimeNs has passed.
+// lifetimeNs <= 0 == never expires.
+func (acl *AccessControlList) AddTemporary(item string, lifetimeNs int64) {
+	acl.mutex.Lock()
+	defer acl.mutex.Unlock()
+	if lifetimeNs <= 0 {
+		acl.list[item] = time.Time{}
+		return
+	}
+	acl.list[item] = time.Now().Add(time.Duration(lifetimeNs) * time.Nanosecond)
 }
 
 func (acl *AccessControlList) Remove(item string) {
+	acl.removeItem(item)
+}
+
+// returns false if item was not in the list (or had already expired).
+func (acl *AccessControlList) removeItem(item string) bool {
 	acl.mutex.Lock()
 	defer acl.mutex.Unlock()
+	expiration, ok := acl.list[item]
 	delete(acl.list, item)
+	return ok && (expiration.IsZero() || time.Now().Before(expiration))
 }
 
+// returns true if item is in the list or if item is an ip within one of the ranges.
 func (acl *AccessControlList) Contains(item string) bool {
 	acl.mutex.Lock()
 	defer acl.mutex.Unlock()
-	_, ok := acl.list[item]
-	return ok
+	if expiration, ok := acl.list[item]; ok {
+		if expiration.IsZero() || !time.Now().After(expiration) {
+			return true
+		}
+		// an expired entry may still be covered by a range
+		delete(acl.list, item)
+	}
+	if acl.ranges.Count() == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(item)
+	if err != nil {
+		return false
+	}
+	return acl.ranges.Contains(addr)
+}
+
+// returns the time at which item expires.
+// returns the zero time if item never expires or is not in the list (or has already expired).
+func (acl *AccessControlList) GetExpiration(item string) time.Time {
+	acl.mutex.Lock()
+	defer acl.mutex.Unlock()
+	expiration := acl.list[item]
+	if !expiration.IsZero() && time.Now().After(expiration) {
+		delete(acl.list, item)
+		return time.Time{}
+	}
+	return expiration
 }
 
 func (acl *AccessControlList) ElementCount() int {
 	acl.mutex.Lock()
 	defer acl.mutex.Unlock()
-	return len(acl.list)
+	acl.removeExpired()
+	return len(acl.list) + acl.ranges.Count()
 }
 
 func (acl *AccessControlList) GetElements() []string {
 	acl.mutex.Lock()
 	defer acl.mutex.Unlock()
+	acl.removeExpired()
 	items := make([]string, 0, len(acl.list))
 	for item := range acl.list {
 		items = append(items, item)
@@ -57,17 +157,109 @@ func (acl *AccessControlList) GetElements() []string {
 	return items
 }
 
+// loads entries from a file containing one ip, cidr range or other item per line.
+// empty lines and lines starting with "#" are ignored.
+func (acl *AccessControlList) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, line := range helpers.SplitLines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, "/") {
+			acl.Add(line)
+			continue
+		}
+		prefix, err := ParseIpPrefix(line)
+		if err != nil {
+			return err
+		}
+		acl.mutex.Lock()
+		acl.ranges.Insert(prefix)
+		acl.mutex.Unlock()
+	}
+	return nil
+}
+
+// saves permanent entries and ranges to a file, one per line.
+// temporary entries are not saved.
+func (acl *AccessControlList) SaveFile(path string) error {
+	acl.mutex.Lock()
+	lines := []string{}
+	for item, expiration := range acl.list {
+		if expiration.IsZero() {
+			lines = append(lines, item)
+		}
+	}
+	for _, prefix := range acl.ranges.GetPrefixes() {
+		lines = append(lines, prefix.String())
+	}
+	acl.mutex.Unlock()
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0666)
+}
+
+func (acl *AccessControlList) removeExpired() {
+	now := time.Now()
+	for item, expiration := range acl.list {
+		if !expiration.IsZero() && now.After(expiration) {
+			delete(acl.list, item)
+		}
+	}
+}
+
+// the loadFile and saveFile commands use the configured persistence path,
+// so callers of the commands can not access arbitrary files.
 func (acl *AccessControlList) GetDefaultCommands() CommandHandlers {
-	return CommandHandlers{
+	commands := CommandHandlers{
 		"add": func(args []string) (string, error) {
+			if len(args) != 1 {
+				return "", errors.New("add expects 1 argument")
+			}
 			acl.Add(args[0])
 			return "success", nil
 		},
+		"addTemporary": func(args []string) (string, error) {
+			if len(args) != 2 {
+				return "", errors.New("addTemporary expects 2 arguments")
+			}
+			acl.AddTemporary(args[0], helpers.StringToInt64(args[1]))
+			return "success", nil
+		},
 		"remove": func(args []string) (string, error) {
+			if len(args) != 1 {
+				return "", errors.New("remove expects 1 argument")
+			}
 			acl.Remove(args[0])
 			return "success", nil
 		},
+		"addRange": func(args []string) (string, error) {
+			if len(args) != 1 {
+				return "", errors.New("addRange expects 1 argument")
+			}
+			if err := acl.AddRange(args[0]); err != nil {
+				return "", err
+			}
+			return "success", nil
+		},
+		"removeRange": func(args []string) (string, error) {
+			if len(args) != 1 {
+				return "", errors.New("removeRange expects 1 argument")
+			}
+			if err := acl.RemoveRange(args[0]); err != nil {
+				return "", err
+			}
+			return "success", nil
+		},
+		"getRanges": func(args []string) (string, error) {
+			return strings.Join(acl.GetRanges(), "\n"), nil
+		},
 		"contains": func(args []string) (string, error) {
+			if len(args) != 1 {
+				return "", errors.New("contains expects 1 argument")
+			}
 			if acl.Contains(args[0]) {
 				return "true", nil
 			}
@@ -80,4 +272,19 @@ func (acl *AccessControlList) GetDefaultCommands() CommandHandlers {
 			return strings.Join(acl.GetElements(), "\n"), nil
 		},
 	}
+	if acl.config.PersistencePath != "" {
+		commands["loadFile"] = func(args []string) (string, error) {
+			if err := acl.LoadFile(acl.config.PersistencePath); err != nil {
+				return "", err
+			}
+			return "success", nil
+		}
+		commands["saveFile"] = func(args []string) (string, error) {
+			if err := acl.SaveFile(acl.config.PersistencePath); err != nil {
+				return "", err
+			}
+			return "success", nil
+		}
+	}
+	return commands
 }
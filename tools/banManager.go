@@ -0,0 +1,379 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/helpers"
+)
+
+// counts failures per ip and bans repeat offenders by adding them to a block list.
+// each repeated offence multiplies the ban duration by the configured multiplier.
+type BanManager struct {
+	config    *configs.BanManager
+	blockList *AccessControlList
+
+	offenders map[string]*offender
+	mutex     sync.Mutex
+
+	stopChannel chan struct{}
+
+	logger *Logger
+
+	// metrics
+
+	FailuresRegistered atomic.Uint64
+	BansIssued         atomic.Uint64
+}
+
+type offender struct {
+	failures    []time.Time
+	offences    uint32
+	banned      bool
+	bannedUntil time.Time // zero time == permanent
+}
+
+type Ban struct {
+	Ip         string    `json:"ip"`
+	Offences   uint32    `json:"offences"`
+	Expiration time.Time `json:"expiration"` // zero time == permanent
+}
+
+// blockList may be nil, in which case a new one is created.
+// the block list can be passed to accepter.NewAccessControlHandler to reject banned ips.
+func NewBanManager(config *configs.BanManager, blockList *AccessControlList) (*BanManager, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+	if config.BanDurationMultiplier <= 0 {
+		config.BanDurationMultiplier = 2
+	}
+	if blockList == nil {
+		blockList = NewAccessControlList(nil, nil)
+	}
+	banManager := &BanManager{
+		config:    config,
+		blockList: blockList,
+		offenders: make(map[string]*offender),
+
+		stopChannel: make(chan struct{}),
+	}
+	if config.PersistencePath != "" && helpers.FileExists(config.PersistencePath) {
+		if err := banManager.load(); err != nil {
+			return nil, err
+		}
+	}
+	go banManager.cleanupRoutine()
+	return banManager, nil
+}
+
+// stops the cleanup routine. subsequent calls have no effect.
+func (banManager *BanManager) Close() {
+	banManager.mutex.Lock()
+	defer banManager.mutex.Unlock()
+	select {
+	case <-banManager.stopChannel:
+	default:
+		close(banManager.stopChannel)
+	}
+}
+
+// failures to persist bans are logged to logger.
+func (banManager *BanManager) SetLogger(logger *Logger) {
+	banManager.mutex.Lock()
+	defer banManager.mutex.Unlock()
+	banManager.logger = logger
+}
+
+func (banManager *BanManager) GetBlockList() *AccessControlList {
+	return banManager.blockList
+}
+
+// registers a failure for ip.
+// returns true if ip is banned after this call.
+func (banManager *BanManager) RegisterFailure(ip string) bool {
+	banManager.FailuresRegistered.Add(1)
+
+	banManager.mutex.Lock()
+	defer banManager.mutex.Unlock()
+
+	now := time.Now()
+	offender := banManager.getOffender(ip, now)
+	if offender.banned {
+		return true
+	}
+
+	recentFailures := []time.Time{now} // most recent failure first
+	for _, t := range offender.failures {
+		if banManager.config.FailureTimeWindowNs <= 0 || now.Sub(t) <= time.Duration(banManager.config.FailureTimeWindowNs) {
+			recentFailures = append(recentFailures, t)
+		}
+	}
+	offender.failures = recentFailures
+	if len(offender.failures) < banManager.config.FailureThreshold {
+		return false
+	}
+
+	banManager.ban(ip, offender, banManager.getBanDurationNs(offender.offences), now)
+	if err := banManager.save(); err != nil {
+		banManager.logger.Error("failed to save bans", "path", banManager.config.PersistencePath, "error", err)
+	}
+	return true
+}
+
+// bans ip for durationNs.
+// durationNs <= 0 == permanent.
+func (banManager *BanManager) Ban(ip string, durationNs int64) error {
+	banManager.mutex.Lock()
+	defer banManager.mutex.Unlock()
+
+	now := time.Now()
+	banManager.ban(ip, banManager.getOffender(ip, now), durationNs, now)
+	return banManager.save()
+}
+
+// lifts the ban of ip and resets its offences.
+func (banManager *BanManager) Unban(ip string) error {
+	banManager.mutex.Lock()
+	defer banManager.mutex.Unlock()
+
+	_, isOffender := banManager.offenders[ip]
+	isBlocked := banManager.blockList.removeItem(ip)
+	if !isOffender && !isBlocked {
+		return errors.New("ip not found")
+	}
+	delete(banManager.offenders, ip)
+	return banManager.save()
+}
+
+func (banManager *BanManager) IsBanned(ip string) bool {
+	return banManager.blockList.Contains(ip)
+}
+
+// returns all currently active bans.
+func (banManager *BanManager) GetBans() []*Ban {
+	banManager.mutex.Lock()
+	defer banManager.mutex.Unlock()
+
+	return banManager.getBans(time.Now())
+}
+
+func (banManager *BanManager) getBans(now time.Time) []*Ban {
+	bans := []*Ban{}
+	for ip, offender := range banManager.offenders {
+		if !offender.isBanned(now) {
+			continue
+		}
+		bans = append(bans, &Ban{
+			Ip:         ip,
+			Offences:   offender.offences,
+			Expiration: offender.bannedUntil,
+		})
+	}
+	return bans
+}
+
+// must be called with mutex locked.
+func (banManager *BanManager) getOffender(ip string, now time.Time) *offender {
+	entry, ok := banManager.offenders[ip]
+	if !ok {
+		entry = &offender{}
+		banManager.offenders[ip] = entry
+	}
+	if entry.banned && !entry.isBanned(now) {
+		entry.banned = false
+	}
+	if !entry.banned && entry.offences > 0 && banManager.config.OffenceLifetimeNs > 0 &&
+		now.Sub(entry.bannedUntil) > time.Duration(banManager.config.OffenceLifetimeNs) {
+		entry.offences = 0
+	}
+	return entry
+}
+
+// must be called with mutex locked.
+func (banManager *BanManager) ban(ip string, offender *offender, durationNs int64, now time.Time) {
+	offender.offences++
+	offender.failures = nil
+	offender.banned = true
+	if durationNs > 0 {
+		offender.bannedUntil = now.Add(time.Duration(durationNs))
+	} else {
+		offender.bannedUntil = time.Time{}
+	}
+	banManager.blockList.AddTemporary(ip, durationNs)
+	banManager.BansIssued.Add(1)
+}
+
+func (banManager *BanManager) getBanDurationNs(previousOffences uint32) int64 {
+	if banManager.config.InitialBanDurationNs <= 0 {
+		return 0
+	}
+	durationNs := float64(banManager.config.InitialBanDurationNs) * math.Pow(banManager.config.BanDurationMultiplier, float64(previousOffences))
+	if banManager.config.MaxBanDurationNs > 0 && durationNs > float64(banManager.config.MaxBanDurationNs) {
+		return banManager.config.MaxBanDurationNs
+	}
+	if durationNs > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(durationNs)
+}
+
+func (offender *offender) isBanned(now time.Time) bool {
+	return offender.banned && (offender.bannedUntil.IsZero() || now.Before(offender.bannedUntil))
+}
+
+// must be called with mutex locked.
+func (banManager *BanManager) save() error {
+	if banManager.config.PersistencePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(banManager.getBans(time.Now()))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(banManager.config.PersistencePath, data, 0666)
+}
+
+func (banManager *BanManager) load() error {
+	data, err := os.ReadFile(banManager.config.PersistencePath)
+	if err != nil {
+		return err
+	}
+	bans := []*Ban{}
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, ban := range bans {
+		if !ban.Expiration.IsZero() && !now.Before(ban.Expiration) {
+			continue
+		}
+		banManager.offenders[ban.Ip] = &offender{
+			offences:    ban.Offences,
+			banned:      true,
+			bannedUntil: ban.Expiration,
+		}
+		if ban.Expiration.IsZero() {
+			banManager.blockList.Add(ban.Ip)
+		} else {
+			banManager.blockList.AddTemporary(ban.Ip, int64(ban.Expiration.Sub(now)))
+		}
+	}
+	return nil
+}
+
+func (banManager *BanManager) cleanupRoutine() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-banManager.stopChannel:
+			return
+		case <-ticker.C:
+		}
+		banManager.mutex.Lock()
+		now := time.Now()
+		for ip := range banManager.offenders {
+			offender := banManager.getOffender(ip, now)
+			if offender.banned || offender.offences > 0 {
+				continue
+			}
+			if len(offender.failures) == 0 || (banManager.config.FailureTimeWindowNs > 0 && now.Sub(offender.failures[0]) > time.Duration(banManager.config.FailureTimeWindowNs)) {
+				delete(banManager.offenders, ip)
+			}
+		}
+		banManager.mutex.Unlock()
+	}
+}
+
+func (banManager *BanManager) CheckMetrics() MetricsTypes {
+	metricsTypes := NewMetricsTypes()
+	metricsTypes.AddMetrics("ban_manager", NewMetrics(
+		map[string]uint64{
+			"failuresRegistered": banManager.FailuresRegistered.Load(),
+			"bansIssued":         banManager.BansIssued.Load(),
+		},
+	))
+	return metricsTypes
+}
+
+func (banManager *BanManager) GetMetrics() MetricsTypes {
+	metricsTypes := NewMetricsTypes()
+	metricsTypes.AddMetrics("ban_manager", NewMetrics(
+		map[string]uint64{
+			"failuresRegistered": banManager.FailuresRegistered.Swap(0),
+			"bansIssued":         banManager.BansIssued.Swap(0),
+		},
+	))
+	return metricsTypes
+}
+
+func (banManager *BanManager) GetDefaultCommands() CommandHandlers {
+	commands := CommandHandlers{}
+	commands["list"] = func(args []string) (string, error) {
+		json, err := json.Marshal(banManager.GetBans())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["ban"] = func(args []string) (string, error) {
+		if len(args) < 1 || len(args) > 2 {
+			return "", errors.New("ban expects 1 or 2 arguments")
+		}
+		durationNs := int64(0)
+		if len(args) == 2 {
+			durationNs = helpers.StringToInt64(args[1])
+		}
+		if err := banManager.Ban(args[0], durationNs); err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["unban"] = func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", errors.New("unban expects 1 argument")
+		}
+		if err := banManager.Unban(args[0]); err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["isBanned"] = func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", errors.New("isBanned expects 1 argument")
+		}
+		if banManager.IsBanned(args[0]) {
+			return "true", nil
+		}
+		return "false", nil
+	}
+	commands["checkMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(banManager.CheckMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(banManager.GetMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	blockListCommands := banManager.blockList.GetDefaultCommands()
+	for key, value := range blockListCommands {
+		commands["blockList_"+key] = value
+	}
+	return commands
+}
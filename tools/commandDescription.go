@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	COMMAND_ARG_STRING   = "string"
+	COMMAND_ARG_INT      = "int"
+	COMMAND_ARG_UINT     = "uint"
+	COMMAND_ARG_FLOAT    = "float"
+	COMMAND_ARG_BOOL     = "bool"
+	COMMAND_ARG_DURATION = "duration" // time.ParseDuration syntax, e.g. "1m30s"
+)
+
+// optional metadata of a command.
+// used to generate help output, to complete input and to validate and convert args before the handler is executed.
+type CommandDescription struct {
+	Description string        `json:"description"`
+	Args        []*CommandArg `json:"args"`
+	Examples    []string      `json:"examples"`
+}
+
+type CommandArg struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"` // default: "string"
+	Description string   `json:"description"`
+	Default     string   `json:"default"`  // used if the arg is optional and not provided
+	Optional    bool     `json:"optional"` // optional args must not be followed by required args
+	Variadic    bool     `json:"variadic"` // only the last arg may be variadic. collects all remaining args into a slice
+	Choices     []string `json:"choices"`  // *optional* allowed values
+}
+
+// command name -> description
+type CommandDescriptions map[string]*CommandDescription
+
+// args converted according to a CommandDescription. arg name -> value.
+// values are string, int64, uint64, float64, bool, time.Duration or slices of these for variadic args.
+type CommandArgs map[string]any
+
+// executed with args that have been validated and converted according to a CommandDescription.
+type DescribedCommandHandler func(CommandArgs) (string, error)
+
+// returns a CommandHandler that validates and converts args according to description before executing handler.
+// the returned handler can be registered like any other CommandHandler.
+func NewDescribedCommandHandler(description *CommandDescription, handler DescribedCommandHandler) CommandHandler {
+	return func(args []string) (string, error) {
+		commandArgs, err := description.ParseArgs(args)
+		if err != nil {
+			return "", err
+		}
+		return handler(commandArgs)
+	}
+}
+
+// converts positional args and "name=value" args into CommandArgs.
+// returns an error if required args are missing, unknown args are provided or values can not be converted.
+func (description *CommandDescription) ParseArgs(args []string) (CommandArgs, error) {
+	commandArgs := CommandArgs{}
+	rawValues := map[string][]string{}
+	positionalArgs := []string{}
+	for _, arg := range args {
+		if name, value, ok := strings.Cut(arg, "="); ok {
+			if commandArg := description.getArg(name); commandArg != nil {
+				rawValues[name] = append(rawValues[name], value)
+				continue
+			}
+		}
+		positionalArgs = append(positionalArgs, arg)
+	}
+	// positional args fill the args that were not provided by name
+	position := 0
+	for _, arg := range positionalArgs {
+		for position < len(description.Args) && rawValues[description.Args[position].Name] != nil && !description.Args[position].Variadic {
+			position++
+		}
+		if position >= len(description.Args) {
+			return nil, errors.New("too many args")
+		}
+		commandArg := description.Args[position]
+		rawValues[commandArg.Name] = append(rawValues[commandArg.Name], arg)
+		if !commandArg.Variadic {
+			position++
+		}
+	}
+
+	for _, commandArg := range description.Args {
+		values, ok := rawValues[commandArg.Name]
+		if !ok {
+			if !commandArg.Optional {
+				return nil, errors.New("missing arg \"" + commandArg.Name + "\"")
+			}
+			if commandArg.Default == "" {
+				continue
+			}
+			values = []string{commandArg.Default}
+		}
+		if len(values) > 1 && !commandArg.Variadic {
+			return nil, errors.New("arg \"" + commandArg.Name + "\" provided more than once")
+		}
+		converted := make([]any, len(values))
+		for i, value := range values {
+			convertedValue, err := commandArg.convert(value)
+			if err != nil {
+				return nil, errors.New("invalid value for arg \"" + commandArg.Name + "\": " + err.Error())
+			}
+			converted[i] = convertedValue
+		}
+		if commandArg.Variadic {
+			commandArgs[commandArg.Name] = toTypedSlice(commandArg.Type, converted)
+		} else {
+			commandArgs[commandArg.Name] = converted[0]
+		}
+	}
+	return commandArgs, nil
+}
+
+func (description *CommandDescription) getArg(name string) *CommandArg {
+	for _, commandArg := range description.Args {
+		if commandArg.Name == name {
+			return commandArg
+		}
+	}
+	return nil
+}
+
+func (commandArg *CommandArg) convert(value string) (any, error) {
+	if len(commandArg.Choices) > 0 {
+		found := false
+		for _, choice := range commandArg.Choices {
+			if choice == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("must be one of " + strings.Join(commandArg.Choices, ", "))
+		}
+	}
+	switch commandArg.Type {
+	case "", COMMAND_ARG_STRING:
+		return value, nil
+	case COMMAND_ARG_INT:
+		return strconv.ParseInt(value, 10, 64)
+	case COMMAND_ARG_UINT:
+		return strconv.ParseUint(value, 10, 64)
+	case COMMAND_ARG_FLOAT:
+		return strconv.ParseFloat(value, 64)
+	case COMMAND_ARG_BOOL:
+		return strconv.ParseBool(value)
+	case COMMAND_ARG_DURATION:
+		return time.ParseDuration(value)
+	default:
+		return nil, errors.New("unknown arg type \"" + commandArg.Type + "\"")
+	}
+}
+
+func toTypedSlice(argType string, values []any) any {
+	switch argType {
+	case COMMAND_ARG_INT:
+		return convertSlice[int64](values)
+	case COMMAND_ARG_UINT:
+		return convertSlice[uint64](values)
+	case COMMAND_ARG_FLOAT:
+		return convertSlice[float64](values)
+	case COMMAND_ARG_BOOL:
+		return convertSlice[bool](values)
+	case COMMAND_ARG_DURATION:
+		return convertSlice[time.Duration](values)
+	default:
+		return convertSlice[string](values)
+	}
+}
+
+func convertSlice[T any](values []any) []T {
+	slice := make([]T, len(values))
+	for i, value := range values {
+		slice[i] = value.(T)
+	}
+	return slice
+}
+
+func (args CommandArgs) GetString(name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+func (args CommandArgs) GetInt(name string) int64 {
+	value, _ := args[name].(int64)
+	return value
+}
+
+func (args CommandArgs) GetUint(name string) uint64 {
+	value, _ := args[name].(uint64)
+	return value
+}
+
+func (args CommandArgs) GetFloat(name string) float64 {
+	value, _ := args[name].(float64)
+	return value
+}
+
+func (args CommandArgs) GetBool(name string) bool {
+	value, _ := args[name].(bool)
+	return value
+}
+
+func (args CommandArgs) GetDuration(name string) time.Duration {
+	value, _ := args[name].(time.Duration)
+	return value
+}
+
+func (args CommandArgs) GetStrings(name string) []string {
+	value, _ := args[name].([]string)
+	return value
+}
+
+func (args CommandArgs) Has(name string) bool {
+	_, ok := args[name]
+	return ok
+}
+
+// returns the usage line of a command, e.g. "ban <ip> [durationNs:int=0]".
+func (description *CommandDescription) Usage(name string) string {
+	builder := strings.Builder{}
+	builder.WriteString(name)
+	for _, commandArg := range description.Args {
+		arg := commandArg.Name
+		if commandArg.Type != "" && commandArg.Type != COMMAND_ARG_STRING {
+			arg += ":" + commandArg.Type
+		}
+		if commandArg.Default != "" {
+			arg += "=" + commandArg.Default
+		}
+		if commandArg.Variadic {
+			arg += "..."
+		}
+		if commandArg.Optional {
+			builder.WriteString(" [" + arg + "]")
+		} else {
+			builder.WriteString(" <" + arg + ">")
+		}
+	}
+	return builder.String()
+}
+
+// returns the usage line, description, args and examples of a command.
+func (description *CommandDescription) Help(name string) string {
+	builder := strings.Builder{}
+	builder.WriteString(description.Usage(name))
+	if description.Description != "" {
+		builder.WriteString("\n  " + description.Description)
+	}
+	if len(description.Args) > 0 {
+		builder.WriteString("\n  args:")
+		for _, commandArg := range description.Args {
+			builder.WriteString("\n    " + commandArg.Name)
+			if commandArg.Description != "" {
+				builder.WriteString(": " + commandArg.Description)
+			}
+			if len(commandArg.Choices) > 0 {
+				builder.WriteString(" (" + strings.Join(commandArg.Choices, "|") + ")")
+			}
+		}
+	}
+	if len(description.Examples) > 0 {
+		builder.WriteString("\n  examples:")
+		for _, example := range description.Examples {
+			builder.WriteString("\n    " + example)
+		}
+	}
+	return builder.String()
+}
+
+// returns the help output of a single command, or an overview of all commands if name is empty.
+// commands without description are listed by name only.
+func (descriptions CommandDescriptions) Help(commandHandlers CommandHandlers, name string) (string, error) {
+	if name != "" {
+		if _, ok := commandHandlers[name]; !ok {
+			return "", errors.New("command not found")
+		}
+		description, ok := descriptions[name]
+		if !ok {
+			return name + "\n  no description available", nil
+		}
+		return description.Help(name), nil
+	}
+
+	keys := commandHandlers.GetKeys()
+	sort.Strings(keys)
+	builder := strings.Builder{}
+	for i, key := range keys {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		description, ok := descriptions[key]
+		if !ok {
+			builder.WriteString(key)
+			continue
+		}
+		builder.WriteString(description.Usage(key))
+		if description.Description != "" {
+			builder.WriteString("\n  " + description.Description)
+		}
+	}
+	return builder.String(), nil
+}
+
+// returns the candidates for the last word of line.
+// the first word is completed with command names, later words with the choices of the arg at that position.
+func (descriptions CommandDescriptions) Complete(commandHandlers CommandHandlers, line string) []string {
+	words := strings.Fields(line)
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	prefix := words[len(words)-1]
+
+	candidates := []string{}
+	if len(words) == 1 {
+		for key := range commandHandlers {
+			if strings.HasPrefix(key, prefix) {
+				candidates = append(candidates, key)
+			}
+		}
+		sort.Strings(candidates)
+		return candidates
+	}
+
+	description, ok := descriptions[words[0]]
+	if !ok || len(description.Args) == 0 {
+		return candidates
+	}
+	position := len(words) - 2
+	if position >= len(description.Args) {
+		if !description.Args[len(description.Args)-1].Variadic {
+			return candidates
+		}
+		position = len(description.Args) - 1
+	}
+	for _, choice := range description.Args[position].Choices {
+		if strings.HasPrefix(choice, prefix) {
+			candidates = append(candidates, choice)
+		}
+	}
+	return candidates
+}
@@ -1,25 +1,49 @@
 package tools
 
 import (
-	"fmt"
+	"bufio"
+	"os"
 	"strings"
 )
 
 func StartCLI(commandHandlers CommandHandlers) {
+	StartDescribedCLI(commandHandlers, nil)
+}
+
+// like StartCLI, but "help [command]" prints the usage, description and examples of commands.
+func StartDescribedCLI(commandHandlers CommandHandlers, commandDescriptions CommandDescriptions) {
 	println("Welcome to the Systemge command line interface")
 	println("Type 'exit' to exit")
+	println("Type 'help' to list all commands or 'help <command>' for details")
 	println("Type your command followed by arguments separated by spaces")
+	scanner := bufio.NewScanner(os.Stdin)
 	for {
 		print("> ")
-		var command string
-		_, _ = fmt.Scanln(&command)
-		if command == "exit" {
+		if !scanner.Scan() {
 			break
 		}
-		segments := strings.Split(command, " ")
+		segments := strings.Fields(scanner.Text())
 		if len(segments) == 0 {
 			continue
 		}
+		if segments[0] == "exit" {
+			break
+		}
+		if segments[0] == "help" {
+			if _, ok := commandHandlers["help"]; !ok {
+				name := ""
+				if len(segments) > 1 {
+					name = segments[1]
+				}
+				help, err := commandDescriptions.Help(commandHandlers, name)
+				if err != nil {
+					println("Error: " + err.Error())
+					continue
+				}
+				println(help)
+				continue
+			}
+		}
 		commandHandler, ok := commandHandlers[segments[0]]
 		if !ok {
 			println("Command not found")
@@ -14,13 +14,31 @@ type Handler struct {
 }
 
 func (handler *Handler) Handle(event *Event) *Event {
-	if handler.eventHandler != nil {
-		event.GetContext().Merge(handler.getDefaultContext())
+	if handler != nil && handler.eventHandler != nil {
+		if handler.getDefaultContext != nil {
+			event.GetContext().Merge(handler.getDefaultContext())
+		}
 		handler.eventHandler(event)
 	}
 	return event
 }
 
+// handles a new event and returns the action chosen by the event handler (defaultAction if handler is nil).
+// panics if the chosen action is Panic.
+func (handler *Handler) Emit(event string, context Context, defaultAction int8, options ...int8) int8 {
+	if handler == nil || handler.eventHandler == nil {
+		return defaultAction
+	}
+	action := handler.Handle(New(event, context, defaultAction, options...)).GetAction()
+	if action == Panic {
+		if err, ok := context["error"]; ok {
+			panic(event + ": " + err)
+		}
+		panic(event)
+	}
+	return action
+}
+
 func (handler *Handler) SetDefaultContext(getDefaultContext func() Context) {
 	handler.getDefaultContext = getDefaultContext
 }
@@ -39,6 +57,9 @@ type Handlers struct {
 	DefaultHandler Handler
 }
 
+// actions of an event. the emitting components use them consistently:
+// Skip drops the connection of the event (it is closed), Cancel aborts the pending action,
+// i.e. it stops the routine for accept and read failures and keeps the connection open for events that would close it.
 const (
 	Continue = int8(0)
 	Skip     = int8(1)
@@ -47,6 +68,26 @@ const (
 	Panic    = int8(4)
 )
 
+// events emitted by listeners, accepters and readers. the options of each event are documented at the emitting component.
+// contexts may contain "listenerName", "listenerInstanceId", "connectionInstanceId", "address", "reason" and "error".
+const (
+	EVENT_LISTENER_ACCEPT_SUCCEEDED = "listenerAcceptSucceeded"
+	EVENT_LISTENER_ACCEPT_FAILED    = "listenerAcceptFailed"
+
+	EVENT_ACCEPT_SUCCEEDED            = "acceptSucceeded"
+	EVENT_ACCEPT_FAILED               = "acceptFailed"
+	EVENT_ACCEPT_HANDLER_FAILED       = "acceptHandlerFailed"
+	EVENT_CONNECTION_LIFETIME_EXPIRED = "connectionLifetimeExpired"
+
+	EVENT_READ_FAILED         = "readFailed"
+	EVENT_READ_HANDLER_FAILED = "readHandlerFailed"
+	EVENT_WRITE_FAILED        = "writeFailed"
+	EVENT_CONNECTION_CLOSING  = "connectionClosing" // emitted before a reader closes a connection
+	EVENT_CONNECTION_CLOSED   = "connectionClosed"  // emitted after the connection of a reader was closed
+
+	EVENT_ROUTINE_STOPPED = "routineStopped"
+)
+
 type Event struct {
 	event   string
 	context Context
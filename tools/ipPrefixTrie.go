@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"errors"
+	"net/netip"
+)
+
+// binary trie of ipv4 and ipv6 prefixes.
+// lookups are O(address length) regardless of the number of stored prefixes.
+// not safe for concurrent use.
+type IpPrefixTrie struct {
+	v4    *ipPrefixTrieNode
+	v6    *ipPrefixTrieNode
+	count int
+}
+
+type ipPrefixTrieNode struct {
+	children [2]*ipPrefixTrieNode
+	terminal bool
+}
+
+func NewIpPrefixTrie() *IpPrefixTrie {
+	return &IpPrefixTrie{
+		v4: &ipPrefixTrieNode{},
+		v6: &ipPrefixTrieNode{},
+	}
+}
+
+// parses cidr notation (e.g. "10.0.0.0/8", "2001:db8::/64").
+// a bare ip is treated as a single address prefix (/32 or /128).
+// ipv4-mapped ipv6 prefixes (e.g. "::ffff:10.0.0.0/104") are converted to ipv4 prefixes and must be at least /96.
+func ParseIpPrefix(cidr string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		addr, addrErr := netip.ParseAddr(cidr)
+		if addrErr != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	if prefix.Addr().Is4In6() {
+		if prefix.Bits() < 96 {
+			return netip.Prefix{}, errors.New("ipv4-mapped prefix \"" + cidr + "\" is shorter than /96")
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// returns the string representation of ip masked to the provided prefix length.
+// bits <= 0 or bits >= the address length returns ip unchanged.
+func GetIpPrefix(ip string, ipv4Bits int, ipv6Bits int) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	bits := ipv6Bits
+	if addr.Is4() {
+		bits = ipv4Bits
+	}
+	if bits <= 0 || bits >= addr.BitLen() {
+		return addr.String()
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
+
+func (trie *IpPrefixTrie) root(addr netip.Addr) *ipPrefixTrieNode {
+	if addr.Is4() {
+		return trie.v4
+	}
+	return trie.v6
+}
+
+// returns false if prefix was already present.
+func (trie *IpPrefixTrie) Insert(prefix netip.Prefix) bool {
+	addr := prefix.Addr()
+	bytes := addr.AsSlice()
+	node := trie.root(addr)
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := getBit(bytes, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &ipPrefixTrieNode{}
+		}
+		node = node.children[bit]
+	}
+	if node.terminal {
+		return false
+	}
+	node.terminal = true
+	trie.count++
+	return true
+}
+
+// returns false if prefix was not present.
+// prefixes nested inside of prefix are not removed.
+func (trie *IpPrefixTrie) Remove(prefix netip.Prefix) bool {
+	addr := prefix.Addr()
+	bytes := addr.AsSlice()
+	path := []*ipPrefixTrieNode{trie.root(addr)}
+	for i := 0; i < prefix.Bits(); i++ {
+		next := path[len(path)-1].children[getBit(bytes, i)]
+		if next == nil {
+			return false
+		}
+		path = append(path, next)
+	}
+	node := path[len(path)-1]
+	if !node.terminal {
+		return false
+	}
+	node.terminal = false
+	trie.count--
+
+	// prune nodes that no longer lead to any prefix
+	for i := len(path) - 1; i > 0; i-- {
+		node := path[i]
+		if node.terminal || node.children[0] != nil || node.children[1] != nil {
+			break
+		}
+		path[i-1].children[getBit(bytes, i-1)] = nil
+	}
+	return true
+}
+
+// returns true if addr is covered by any stored prefix.
+func (trie *IpPrefixTrie) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	bytes := addr.AsSlice()
+	node := trie.root(addr)
+	for i := 0; i < addr.BitLen(); i++ {
+		if node.terminal {
+			return true
+		}
+		node = node.children[getBit(bytes, i)]
+		if node == nil {
+			return false
+		}
+	}
+	return node.terminal
+}
+
+func (trie *IpPrefixTrie) Count() int {
+	return trie.count
+}
+
+func (trie *IpPrefixTrie) GetPrefixes() []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, trie.count)
+	prefixes = collectPrefixes(trie.v4, make([]byte, 4), 0, prefixes)
+	prefixes = collectPrefixes(trie.v6, make([]byte, 16), 0, prefixes)
+	return prefixes
+}
+
+func collectPrefixes(node *ipPrefixTrieNode, bytes []byte, depth int, prefixes []netip.Prefix) []netip.Prefix {
+	if node.terminal {
+		addr, _ := netip.AddrFromSlice(bytes)
+		prefixes = append(prefixes, netip.PrefixFrom(addr, depth))
+	}
+	for bit, child := range node.children {
+		if child == nil {
+			continue
+		}
+		next := make([]byte, len(bytes))
+		copy(next, bytes)
+		if bit == 1 {
+			next[depth/8] |= 0x80 >> (depth % 8)
+		}
+		prefixes = collectPrefixes(child, next, depth+1, prefixes)
+	}
+	return prefixes
+}
+
+func getBit(bytes []byte, index int) int {
+	return int(bytes[index/8]>>(7-index%8)) & 1
+}
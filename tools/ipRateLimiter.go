@@ -8,12 +8,14 @@ import (
 )
 
 type IpRateLimiter struct {
-	connections     map[string][]time.Time
-	mutex           sync.Mutex
-	active          bool
-	timeWindow      time.Duration
-	maxAttempts     int
-	cleanupInterval time.Duration
+	connections      map[string][]time.Time
+	mutex            sync.Mutex
+	active           bool
+	timeWindow       time.Duration
+	maxAttempts      int
+	cleanupInterval  time.Duration
+	ipv4PrefixLength int
+	ipv6PrefixLength int
 }
 
 func (rl *IpRateLimiter) Close() {
@@ -31,18 +33,24 @@ func NewIpRateLimiter(config *configs.IpRateLimiter) *IpRateLimiter {
 		config.CleanupIntervalNs = 1000
 	}
 	rl := &IpRateLimiter{
-		connections:     make(map[string][]time.Time),
-		active:          true,
-		timeWindow:      time.Duration(config.AttemptTimeWindowNs) * time.Nanosecond,
-		maxAttempts:     config.MaxAttempts,
-		cleanupInterval: time.Duration(config.CleanupIntervalNs) * time.Nanosecond,
+		connections:      make(map[string][]time.Time),
+		active:           true,
+		timeWindow:       time.Duration(config.AttemptTimeWindowNs) * time.Nanosecond,
+		maxAttempts:      config.MaxAttempts,
+		cleanupInterval:  time.Duration(config.CleanupIntervalNs) * time.Nanosecond,
+		ipv4PrefixLength: config.Ipv4PrefixLength,
+		ipv6PrefixLength: config.Ipv6PrefixLength,
 	}
 	go rl.cleanupOldEntries()
 	return rl
 }
 
-// Returns true if the connection attempt is allowed, false otherwise
+// Returns true if the connection attempt is allowed, false otherwise.
+// If prefix lengths are configured, attempts are aggregated by the ip's prefix.
 func (rl *IpRateLimiter) RegisterConnectionAttempt(ip string) bool {
+	if rl.ipv4PrefixLength > 0 || rl.ipv6PrefixLength > 0 {
+		ip = GetIpPrefix(ip, rl.ipv4PrefixLength, rl.ipv6PrefixLength)
+	}
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 	now := time.Now()
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+)
+
+const logBackupTimeFormat = "20060102-150405.000000000"
+
+// writes log entries asynchronously to a file (or standard error) and rotates the file by size and/or time.
+// Write never blocks: entries are dropped and counted once the queue is full.
+type LogWriter struct {
+	config *configs.Logger
+
+	closedMutex sync.RWMutex
+	closed      bool
+	queue       chan []byte
+	stopChannel chan struct{}
+	waitGroup   sync.WaitGroup
+
+	// only accessed by the write routine
+	output   io.Writer
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// metrics
+
+	EntriesWritten atomic.Uint64
+	EntriesDropped atomic.Uint64
+	WriteErrors    atomic.Uint64
+	Rotations      atomic.Uint64
+}
+
+var logWriters = make(map[string]*LogWriter)
+var logWritersMutex = sync.Mutex{}
+
+// returns the writer of config.Path and creates it if it does not exist yet.
+// the config of the first call for a path is used.
+func GetLogWriter(config *configs.Logger) (*LogWriter, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	logWritersMutex.Lock()
+	defer logWritersMutex.Unlock()
+	if logWriter, ok := logWriters[config.Path]; ok {
+		return logWriter, nil
+	}
+	logWriter, err := newLogWriter(config)
+	if err != nil {
+		return nil, err
+	}
+	logWriters[config.Path] = logWriter
+	return logWriter, nil
+}
+
+// writes the queued entries and closes the writer of path.
+func CloseLogWriter(path string) error {
+	logWritersMutex.Lock()
+	logWriter, ok := logWriters[path]
+	delete(logWriters, path)
+	logWritersMutex.Unlock()
+	if !ok {
+		return errors.New("log writer not found")
+	}
+	logWriter.close()
+	return nil
+}
+
+func newLogWriter(config *configs.Logger) (*LogWriter, error) {
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+	logWriter := &LogWriter{
+		config:      config,
+		queue:       make(chan []byte, queueSize),
+		stopChannel: make(chan struct{}),
+		output:      os.Stderr,
+	}
+	if config.Path != "" {
+		if err := logWriter.openFile(); err != nil {
+			return nil, err
+		}
+	}
+	logWriter.waitGroup.Add(1)
+	go logWriter.writeRoutine()
+	return logWriter, nil
+}
+
+func (logWriter *LogWriter) GetPath() string {
+	return logWriter.config.Path
+}
+
+// queues a copy of entry. returns len(entry) even if the entry was dropped.
+func (logWriter *LogWriter) Write(entry []byte) (int, error) {
+	logWriter.closedMutex.RLock()
+	defer logWriter.closedMutex.RUnlock()
+	if logWriter.closed {
+		logWriter.EntriesDropped.Add(1)
+		return len(entry), nil
+	}
+	select {
+	case logWriter.queue <- append([]byte(nil), entry...):
+	default:
+		logWriter.EntriesDropped.Add(1)
+	}
+	return len(entry), nil
+}
+
+func (logWriter *LogWriter) close() {
+	logWriter.closedMutex.Lock()
+	if logWriter.closed {
+		logWriter.closedMutex.Unlock()
+		return
+	}
+	logWriter.closed = true
+	close(logWriter.stopChannel)
+	logWriter.closedMutex.Unlock()
+	logWriter.waitGroup.Wait()
+}
+
+func (logWriter *LogWriter) writeRoutine() {
+	defer logWriter.waitGroup.Done()
+	for {
+		select {
+		case entry := <-logWriter.queue:
+			logWriter.write(entry)
+		case <-logWriter.stopChannel:
+			for {
+				select {
+				case entry := <-logWriter.queue:
+					logWriter.write(entry)
+				default:
+					if logWriter.file != nil {
+						logWriter.file.Close()
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (logWriter *LogWriter) write(entry []byte) {
+	if logWriter.file != nil && logWriter.shouldRotate(len(entry)) {
+		if err := logWriter.rotate(); err != nil {
+			logWriter.WriteErrors.Add(1)
+		}
+	}
+	if logWriter.output == nil {
+		// the file could not be reopened after a rotation
+		if err := logWriter.openFile(); err != nil {
+			logWriter.WriteErrors.Add(1)
+			return
+		}
+	}
+	bytesWritten, err := logWriter.output.Write(entry)
+	logWriter.size += int64(bytesWritten)
+	if err != nil {
+		logWriter.WriteErrors.Add(1)
+		return
+	}
+	logWriter.EntriesWritten.Add(1)
+}
+
+// time-based rotation is checked before every write.
+func (logWriter *LogWriter) shouldRotate(entryLength int) bool {
+	if logWriter.config.MaxSizeBytes > 0 && logWriter.size > 0 && logWriter.size+int64(entryLength) > logWriter.config.MaxSizeBytes {
+		return true
+	}
+	if logWriter.config.RotationIntervalNs > 0 && time.Since(logWriter.openedAt) >= time.Duration(logWriter.config.RotationIntervalNs) {
+		return true
+	}
+	return false
+}
+
+func (logWriter *LogWriter) openFile() error {
+	file, err := os.OpenFile(logWriter.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	logWriter.file = file
+	logWriter.output = file
+	logWriter.size = info.Size()
+	logWriter.openedAt = time.Now()
+	return nil
+}
+
+// renames the file to "<path>.<time>" and opens a new one.
+func (logWriter *LogWriter) rotate() error {
+	logWriter.file.Close()
+	logWriter.file = nil
+	logWriter.output = nil
+	now := time.Now()
+	if err := os.Rename(logWriter.config.Path, logWriter.config.Path+"."+now.Format(logBackupTimeFormat)); err != nil {
+		logWriter.openFile()
+		return err
+	}
+	logWriter.Rotations.Add(1)
+	if err := logWriter.openFile(); err != nil {
+		return err
+	}
+	return logWriter.removeBackups(now)
+}
+
+// removes the rotated files beyond MaxBackups and those older than MaxBackupAgeNs.
+func (logWriter *LogWriter) removeBackups(now time.Time) error {
+	if logWriter.config.MaxBackups <= 0 && logWriter.config.MaxBackupAgeNs <= 0 {
+		return nil
+	}
+	directory := filepath.Dir(logWriter.config.Path)
+	prefix := filepath.Base(logWriter.config.Path) + "."
+	dirEntries, err := os.ReadDir(directory)
+	if err != nil {
+		return err
+	}
+	type backup struct {
+		path string
+		time time.Time
+	}
+	backups := []backup{}
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasPrefix(dirEntry.Name(), prefix) {
+			continue
+		}
+		backupTime, err := time.ParseInLocation(logBackupTimeFormat, strings.TrimPrefix(dirEntry.Name(), prefix), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{filepath.Join(directory, dirEntry.Name()), backupTime})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	var lastErr error
+	for i, backup := range backups {
+		tooMany := logWriter.config.MaxBackups > 0 && i >= logWriter.config.MaxBackups
+		tooOld := logWriter.config.MaxBackupAgeNs > 0 && now.Sub(backup.time) > time.Duration(logWriter.config.MaxBackupAgeNs)
+		if tooMany || tooOld {
+			if err := os.Remove(backup.path); err != nil {
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+func (logWriter *LogWriter) CheckMetrics() MetricsTypes {
+	return logWriter.getMetrics(func(value *atomic.Uint64) uint64 { return value.Load() })
+}
+
+func (logWriter *LogWriter) GetMetrics() MetricsTypes {
+	return logWriter.getMetrics(func(value *atomic.Uint64) uint64 { return value.Swap(0) })
+}
+
+func (logWriter *LogWriter) getMetrics(load func(*atomic.Uint64) uint64) MetricsTypes {
+	metricsTypes := NewMetricsTypes()
+	metricsTypes.AddMetrics("log_writer", NewMetrics(
+		map[string]uint64{
+			"queuedEntries":  uint64(len(logWriter.queue)),
+			"entriesWritten": load(&logWriter.EntriesWritten),
+			"entriesDropped": load(&logWriter.EntriesDropped),
+			"writeErrors":    load(&logWriter.WriteErrors),
+			"rotations":      load(&logWriter.Rotations),
+		},
+	))
+	return metricsTypes
+}
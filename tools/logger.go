@@ -1,98 +1,200 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"log"
-	"sync"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
 
-	"github.com/neutralusername/systemge/helpers"
+	"github.com/neutralusername/systemge/configs"
 )
 
+const (
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+)
+
+// leveled, structured logger backed by log/slog. entries are written by a shared LogWriter per path.
+// args are alternating keys and values (or slog.Attr) as in log/slog.
+// all methods may be called on a nil logger, which discards everything.
 type Logger struct {
-	prefix string
-	queue  *LoggerQueue
+	handler slog.Handler
+	level   *slog.LevelVar
+	writer  *LogWriter
 }
 
-const defaultQueueBufferSize = 100
+// args are added to every entry of the logger (e.g. "component", name).
+func NewLogger(config *configs.Logger, args ...any) (*Logger, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	level := &slog.LevelVar{}
+	if config.Level != "" {
+		if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+			return nil, err
+		}
+	}
+	writer, err := GetLogWriter(config)
+	if err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{
+		Level:     level,
+		AddSource: config.AddSource,
+	}
+	var handler slog.Handler
+	switch config.Format {
+	case "", LOG_FORMAT_TEXT:
+		handler = slog.NewTextHandler(writer, options)
+	case LOG_FORMAT_JSON:
+		handler = slog.NewJSONHandler(writer, options)
+	default:
+		return nil, errors.New("unknown format \"" + config.Format + "\"")
+	}
+	if len(args) > 0 {
+		handler = slog.New(handler).With(args...).Handler()
+	}
+	return &Logger{
+		handler: handler,
+		level:   level,
+		writer:  writer,
+	}, nil
+}
 
-func NewLogger(prefix string, path string) *Logger {
-	if path == "" {
+// returns a logger that adds args to every entry. the level is shared with the parent.
+func (logger *Logger) With(args ...any) *Logger {
+	if logger == nil {
 		return nil
 	}
 	return &Logger{
-		prefix: prefix,
-		queue:  NewLoggerQueue(path, defaultQueueBufferSize),
+		handler: slog.New(logger.handler).With(args...).Handler(),
+		level:   logger.level,
+		writer:  logger.writer,
 	}
 }
 
+func (logger *Logger) Debug(msg string, args ...any) {
+	logger.log(slog.LevelDebug, msg, args)
+}
+
+func (logger *Logger) Info(msg string, args ...any) {
+	logger.log(slog.LevelInfo, msg, args)
+}
+
+func (logger *Logger) Warn(msg string, args ...any) {
+	logger.log(slog.LevelWarn, msg, args)
+}
+
+func (logger *Logger) Error(msg string, args ...any) {
+	logger.log(slog.LevelError, msg, args)
+}
+
+// logs str at info level.
+//
+// Deprecated: kept for callers of the previous Logger. use Info (or another level) with key-value args instead.
 func (logger *Logger) Log(str string) {
-	logger.queue.queueLog(logger.prefix + str)
+	logger.log(slog.LevelInfo, str, nil)
 }
 
-var loggerQueues = make(map[string]*LoggerQueue)
-var loggerQueueMutex = sync.Mutex{}
+func (logger *Logger) log(level slog.Level, msg string, args []any) {
+	if logger == nil || !logger.handler.Enabled(context.Background(), level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip runtime.Callers, log and the exported method
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	record.Add(args...)
+	logger.handler.Handle(context.Background(), record)
+}
 
-type LoggerQueue struct {
-	logger *log.Logger
-	queue  chan string
-	stop   chan struct{}
-	closed bool
+// "debug", "info", "warn" or "error".
+func (logger *Logger) SetLevel(level string) error {
+	if logger == nil {
+		return errors.New("logger is nil")
+	}
+	return logger.level.UnmarshalText([]byte(level))
 }
 
-func NewLoggerQueue(path string, queueBuffer uint32) *LoggerQueue {
-	loggerQueueMutex.Lock()
-	defer loggerQueueMutex.Unlock()
-	if path == "" {
-		panic("Logger path cannot be empty")
+func (logger *Logger) GetLevel() string {
+	if logger == nil {
+		return ""
 	}
-	if loggerQueue, ok := loggerQueues[path]; ok {
-		return loggerQueue
+	return strings.ToLower(logger.level.Level().String())
+}
+
+// returns a *slog.Logger that writes through this logger's handler.
+func (logger *Logger) GetSlogLogger() *slog.Logger {
+	if logger == nil {
+		return nil
 	}
-	file := helpers.OpenFileAppend(path)
-	loggerStruct := &LoggerQueue{
-		logger: log.New(file, "", log.Ldate|log.Ltime|log.Lmicroseconds),
-		queue:  make(chan string, queueBuffer),
-		stop:   make(chan struct{}),
+	return slog.New(logger.handler)
+}
+
+// returns a *log.Logger that logs every line at level (e.g. for http.Server.ErrorLog).
+func (logger *Logger) NewLogLogger(level slog.Level) *log.Logger {
+	if logger == nil {
+		return nil
 	}
-	loggerQueues[path] = loggerStruct
-	go loggerStruct.logRoutine()
-	return loggerStruct
+	return slog.NewLogLogger(logger.handler, level)
 }
 
-func GetLoggerQueue(path string) *LoggerQueue {
-	loggerQueueMutex.Lock()
-	defer loggerQueueMutex.Unlock()
-	return loggerQueues[path]
+func (logger *Logger) GetWriter() *LogWriter {
+	if logger == nil {
+		return nil
+	}
+	return logger.writer
 }
 
-func CloseLoggerQueue(path string) error {
-	loggerQueueMutex.Lock()
-	defer loggerQueueMutex.Unlock()
-	loggerQueue := loggerQueues[path]
-	if loggerQueue == nil {
-		return errors.New("Logger not found")
+// metrics of the shared writer.
+func (logger *Logger) CheckMetrics() MetricsTypes {
+	if logger == nil {
+		return NewMetricsTypes()
 	}
-	close(loggerQueue.stop)
-	close(loggerQueue.queue)
-	delete(loggerQueues, path)
-	loggerQueue.closed = true
-	return nil
+	return logger.writer.CheckMetrics()
 }
 
-func (loggerQueue *LoggerQueue) queueLog(str string) {
-	if loggerQueue.closed {
-		return
+func (logger *Logger) GetMetrics() MetricsTypes {
+	if logger == nil {
+		return NewMetricsTypes()
 	}
-	loggerQueue.queue <- str
+	return logger.writer.GetMetrics()
 }
 
-func (loggerQueue *LoggerQueue) logRoutine() {
-	for {
-		select {
-		case LogString := <-loggerQueue.queue:
-			loggerQueue.logger.Println(LogString)
-		case <-loggerQueue.stop:
-			return
+// commands:
+//   - checkMetrics, getMetrics
+//   - getLevel, setLevel <debug|info|warn|error>
+func (logger *Logger) GetDefaultCommands() CommandHandlers {
+	commands := CommandHandlers{}
+	commands["checkMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(logger.CheckMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(logger.GetMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getLevel"] = func(args []string) (string, error) {
+		return logger.GetLevel(), nil
+	}
+	commands["setLevel"] = func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", errors.New("expected 1 argument (level)")
+		}
+		err := logger.SetLevel(args[0])
+		if err != nil {
+			return "", err
 		}
+		return "success", nil
 	}
+	return commands
 }
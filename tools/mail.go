@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	htmlTemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	textTemplate "text/template"
+)
+
+// Body is sent as html. if TextBody is set as well, both are sent as alternatives.
+type Mail struct {
+	Cc          []string
+	Subject     string
+	Body        string
+	TextBody    string
+	Attachments []*Attachment
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string // default: derived from the extension of Name, otherwise "application/octet-stream"
+	Data        []byte
+}
+
+func NewMail(cc []string, subject string, body string) *Mail {
+	return &Mail{
+		Cc:      cc,
+		Subject: subject,
+		Body:    body,
+	}
+}
+
+// renders the templates with data into the text and html body. either template may be nil.
+func NewTemplateMail(cc []string, subject string, textTemplate *textTemplate.Template, htmlTemplate *htmlTemplate.Template, data any) (*Mail, error) {
+	if textTemplate == nil && htmlTemplate == nil {
+		return nil, errors.New("no template provided")
+	}
+	mail := &Mail{
+		Cc:      cc,
+		Subject: subject,
+	}
+	if textTemplate != nil {
+		var buffer bytes.Buffer
+		if err := textTemplate.Execute(&buffer, data); err != nil {
+			return nil, err
+		}
+		mail.TextBody = buffer.String()
+	}
+	if htmlTemplate != nil {
+		var buffer bytes.Buffer
+		if err := htmlTemplate.Execute(&buffer, data); err != nil {
+			return nil, err
+		}
+		mail.Body = buffer.String()
+	}
+	return mail, nil
+}
+
+func (mail *Mail) AddAttachment(name string, contentType string, data []byte) {
+	mail.Attachments = append(mail.Attachments, &Attachment{
+		Name:        name,
+		ContentType: contentType,
+		Data:        data,
+	})
+}
+
+// reads the file at path and attaches it under its base name.
+func (mail *Mail) AddAttachmentFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	mail.AddAttachment(filepath.Base(path), "", data)
+	return nil
+}
+
+// returns the mime headers and the encoded content of the mail.
+func (mail *Mail) buildContent() (textproto.MIMEHeader, []byte, error) {
+	header, content, err := mail.buildBody()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(mail.Attachments) == 0 {
+		return header, content, nil
+	}
+
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	if err := writePart(writer, header, content); err != nil {
+		return nil, nil, err
+	}
+	for _, attachment := range mail.Attachments {
+		if err := writePart(writer, attachment.header(), encodeBase64Lines(attachment.Data)); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {"multipart/mixed; boundary=\"" + writer.Boundary() + "\""},
+	}, buffer.Bytes(), nil
+}
+
+func (mail *Mail) buildBody() (textproto.MIMEHeader, []byte, error) {
+	if mail.TextBody == "" {
+		return encodeTextPart("text/html", mail.Body)
+	}
+	if mail.Body == "" {
+		return encodeTextPart("text/plain", mail.TextBody)
+	}
+
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	for _, part := range [][2]string{{"text/plain", mail.TextBody}, {"text/html", mail.Body}} {
+		header, content, err := encodeTextPart(part[0], part[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writePart(writer, header, content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=\"" + writer.Boundary() + "\""},
+	}, buffer.Bytes(), nil
+}
+
+func (attachment *Attachment) header() textproto.MIMEHeader {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(attachment.Name))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+}
+
+func encodeTextPart(contentType string, text string) (textproto.MIMEHeader, []byte, error) {
+	var buffer bytes.Buffer
+	writer := quotedprintable.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(text)); err != nil {
+		return nil, nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=\"UTF-8\""},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}, buffer.Bytes(), nil
+}
+
+func writePart(writer *multipart.Writer, header textproto.MIMEHeader, content []byte) error {
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+// base64 with lines of at most 76 characters (RFC 2045).
+func encodeBase64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var builder strings.Builder
+	for len(encoded) > 76 {
+		builder.WriteString(encoded[:76])
+		builder.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	builder.WriteString(encoded)
+	return []byte(builder.String())
+}
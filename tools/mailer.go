@@ -1,77 +1,467 @@
 package tools
 
 import (
-	"fmt"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/status"
 )
 
+const (
+	MAILER_TLS_OPPORTUNISTIC = ""
+	MAILER_TLS_STARTTLS      = "starttls"
+	MAILER_TLS_IMPLICIT      = "tls"
+	MAILER_TLS_NONE          = "none"
+)
+
+// Send delivers a mail synchronously. SendAsync queues it and delivers it with retries once the mailer is started.
 type Mailer struct {
-	smtpHost       string
-	smtpPort       uint16
-	senderEmail    string
+	config         *configs.Mailer
 	senderPassword string
-	recipients     []string
+
+	recipientsMutex sync.RWMutex
+	recipients      []string
+
+	status      *status.Machine
+	statusMutex sync.Mutex // serializes Start and Stop
+	stopChannel chan struct{}
+	waitGroup   sync.WaitGroup
+
+	queue         *SimpleFifoQueue[*mailJob]
+	notifyChannel chan struct{}
+
+	// metrics
+
+	MailsSent    atomic.Uint64
+	MailsFailed  atomic.Uint64 // failed mails (after all retries)
+	MailsRetried atomic.Uint64
+	MailsDropped atomic.Uint64 // mails rejected by the full queue
 }
 
-type Mail struct {
-	Cc      []string
-	Subject string
-	Body    string
+type mailJob struct {
+	mail     *Mail
+	attempts int
 }
 
-func NewMailer(config *configs.Mailer) *Mailer {
+// the password is read from SenderPasswordEnv, SenderPasswordFile or SenderPassword (in that order).
+// returns an error if the config is invalid or the password cannot be read
+// (NewMailer used to return only *Mailer and nil for a nil config).
+func NewMailer(config *configs.Mailer) (*Mailer, error) {
 	if config == nil {
-		return nil
+		return nil, errors.New("config is nil")
 	}
-	return &Mailer{
-		smtpHost:       config.SmtpHost,
-		smtpPort:       config.SmtpPort,
-		senderEmail:    config.SenderEmail,
-		senderPassword: config.SenderPassword,
-		recipients:     config.Recipients,
+	if config.SmtpHost == "" || config.SmtpPort == 0 {
+		return nil, errors.New("smtpHost and smtpPort are required")
+	}
+	if config.SenderEmail == "" {
+		return nil, errors.New("senderEmail is required")
+	}
+	switch config.TlsMode {
+	case MAILER_TLS_OPPORTUNISTIC, MAILER_TLS_STARTTLS, MAILER_TLS_IMPLICIT, MAILER_TLS_NONE:
+	default:
+		return nil, errors.New("unknown tlsMode \"" + config.TlsMode + "\"")
+	}
+	if config.Queue == nil {
+		config.Queue = &configs.Queue{}
+	}
+	if config.RetryBackoffNs <= 0 {
+		config.RetryBackoffNs = int64(time.Second)
+	}
+	if config.SendTimeoutNs <= 0 {
+		config.SendTimeoutNs = int64(30 * time.Second)
 	}
-}
 
-func NewMail(cc []string, subject string, body string) *Mail {
-	return &Mail{
-		Cc:      cc,
-		Subject: subject,
-		Body:    body,
+	senderPassword := config.SenderPassword
+	if config.SenderPasswordFile != "" {
+		data, err := os.ReadFile(config.SenderPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		senderPassword = strings.TrimSpace(string(data))
+	}
+	if config.SenderPasswordEnv != "" {
+		password, ok := os.LookupEnv(config.SenderPasswordEnv)
+		if !ok {
+			return nil, errors.New("environment variable \"" + config.SenderPasswordEnv + "\" is not set")
+		}
+		senderPassword = password
 	}
+
+	return &Mailer{
+		config:         config,
+		senderPassword: senderPassword,
+		recipients:     config.Recipients,
+		status:         status.NewMachine(),
+		queue:          NewSimpleQueue[*mailJob](config.Queue),
+		notifyChannel:  make(chan struct{}, 1),
+	}, nil
 }
 
 func (mailer *Mailer) SetRecipients(recipients []string) {
+	mailer.recipientsMutex.Lock()
+	defer mailer.recipientsMutex.Unlock()
 	mailer.recipients = recipients
 }
 
 func (mailer *Mailer) GetRecipients() []string {
+	mailer.recipientsMutex.RLock()
+	defer mailer.recipientsMutex.RUnlock()
 	return mailer.recipients
 }
 
+// starts delivering the mails queued by SendAsync.
+// the mailer fails (and stops delivering) if authentication or tls negotiation fails permanently,
+// since every following mail would fail the same way. it can be started again once the cause is fixed.
+func (mailer *Mailer) Start() error {
+	mailer.statusMutex.Lock()
+	defer mailer.statusMutex.Unlock()
+
+	if err := mailer.status.Transition(status.Starting, nil); err != nil {
+		return errors.New("mailer not stopped")
+	}
+	mailer.stopChannel = make(chan struct{})
+	mailer.status.Transition(status.Started, nil)
+
+	mailer.waitGroup.Add(1)
+	go mailer.sendRoutine(mailer.stopChannel)
+	return nil
+}
+
+// stops delivering queued mails. mails that are still queued are delivered after the next start.
+func (mailer *Mailer) Stop() error {
+	mailer.statusMutex.Lock()
+	defer mailer.statusMutex.Unlock()
+
+	if !mailer.status.Is(status.Started, status.Failed) {
+		return errors.New("mailer not started")
+	}
+	mailer.status.Transition(status.Stopping, nil)
+	close(mailer.stopChannel)
+	mailer.waitGroup.Wait()
+	if mailer.status.Is(status.Failed) { // failed while stopping
+		mailer.status.Transition(status.Stopping, nil)
+	}
+	mailer.status.Transition(status.Stopped, nil)
+	return nil
+}
+
+func (mailer *Mailer) GetStatus() int {
+	return mailer.status.Get()
+}
+
+func (mailer *Mailer) GetStatusMachine() *status.Machine {
+	return mailer.status
+}
+
+// sends the mail to the recipients and the mail's cc.
 func (mailer *Mailer) Send(mail *Mail) error {
-	auth := smtp.PlainAuth("", mailer.senderEmail, mailer.senderPassword, mailer.smtpHost)
-	to := append(mailer.recipients, mail.Cc...)
-	msg := mailer.constructEmail(mail)
-	err := smtp.SendMail(fmt.Sprintf("%s:%d", mailer.smtpHost, mailer.smtpPort), auth, mailer.senderEmail, to, msg)
-	return err
-}
-
-func (mailer *Mailer) constructEmail(mail *Mail) []byte {
-	header := make(map[string]string)
-	header["From"] = mailer.senderEmail
-	header["To"] = strings.Join(mailer.recipients, ",")
-	header["Cc"] = strings.Join(mail.Cc, ",")
-	header["Subject"] = mail.Subject
-	header["MIME-version"] = "1.0"
-	header["Content-Type"] = "text/html; charset=\"UTF-8\""
-
-	message := ""
-	for key, value := range header {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
-	}
-	message += "\r\n" + mail.Body
-	return []byte(message)
+	err := mailer.send(mail)
+	if err != nil {
+		mailer.MailsFailed.Add(1)
+		return err
+	}
+	mailer.MailsSent.Add(1)
+	return nil
+}
+
+// queues the mail. returns an error if the queue is full.
+func (mailer *Mailer) SendAsync(mail *Mail) error {
+	if mail == nil {
+		return errors.New("mail is nil")
+	}
+	if err := mailer.queue.Push(&mailJob{mail: mail}); err != nil {
+		mailer.MailsDropped.Add(1)
+		return err
+	}
+	select {
+	case mailer.notifyChannel <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (mailer *Mailer) sendRoutine(stopChannel <-chan struct{}) {
+	defer mailer.waitGroup.Done()
+
+	for {
+		job, err := mailer.queue.Pop()
+		if err != nil {
+			select {
+			case <-stopChannel:
+				return
+			case <-mailer.notifyChannel:
+				continue
+			}
+		}
+		if !mailer.deliver(job, stopChannel) {
+			return
+		}
+	}
+}
+
+// retries transient errors with exponential backoff.
+// returns false if the mailer was stopped during the backoff or failed (the job is queued again).
+func (mailer *Mailer) deliver(job *mailJob, stopChannel <-chan struct{}) bool {
+	backoff := time.Duration(mailer.config.RetryBackoffNs)
+	for {
+		err := mailer.send(job.mail)
+		if err == nil {
+			mailer.MailsSent.Add(1)
+			return true
+		}
+		var setupError *mailerSetupError
+		if errors.As(err, &setupError) && !isTransientMailError(err) {
+			if err := mailer.queue.Push(job); err != nil {
+				mailer.MailsDropped.Add(1)
+			}
+			mailer.status.Fail(err)
+			return false
+		}
+		job.attempts++
+		if job.attempts > mailer.config.MaxRetries || !isTransientMailError(err) {
+			mailer.MailsFailed.Add(1)
+			return true
+		}
+		mailer.MailsRetried.Add(1)
+		select {
+		case <-stopChannel:
+			if err := mailer.queue.Push(job); err != nil {
+				mailer.MailsDropped.Add(1)
+			}
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if mailer.config.MaxRetryBackoffNs > 0 && backoff > time.Duration(mailer.config.MaxRetryBackoffNs) {
+			backoff = time.Duration(mailer.config.MaxRetryBackoffNs)
+		}
+	}
+}
+
+// an error of the session setup (tls negotiation or authentication) that affects every mail.
+type mailerSetupError struct {
+	err error
+}
+
+func (err *mailerSetupError) Error() string {
+	return err.err.Error()
+}
+
+func (err *mailerSetupError) Unwrap() error {
+	return err.err
+}
+
+// returns true for network errors and 4xx replies of the smtp server.
+// permanent errors (e.g. 5xx replies, invalid mails or certificates) are not retried.
+func isTransientMailError(err error) bool {
+	var protocolError *textproto.Error
+	if errors.As(err, &protocolError) {
+		return protocolError.Code >= 400 && protocolError.Code < 500
+	}
+	var netError net.Error
+	if errors.As(err, &netError) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (mailer *Mailer) send(mail *Mail) error {
+	if mail == nil {
+		return errors.New("mail is nil")
+	}
+	recipients := mailer.GetRecipients()
+	to := make([]string, 0, len(recipients)+len(mail.Cc))
+	to = append(to, recipients...)
+	to = append(to, mail.Cc...)
+	if len(to) == 0 {
+		return errors.New("no recipients")
+	}
+	message, err := mailer.constructEmail(mail, recipients)
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(mailer.config.SmtpHost, strconv.Itoa(int(mailer.config.SmtpPort)))
+	tlsConfig := &tls.Config{
+		ServerName:         mailer.config.SmtpHost,
+		InsecureSkipVerify: mailer.config.InsecureSkipVerify,
+	}
+	dialer := &net.Dialer{
+		Timeout: time.Duration(mailer.config.SendTimeoutNs),
+	}
+	var conn net.Conn
+	if mailer.config.TlsMode == MAILER_TLS_IMPLICIT {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	if mailer.config.SendTimeoutNs > 0 {
+		conn.SetDeadline(time.Now().Add(time.Duration(mailer.config.SendTimeoutNs)))
+	}
+	client, err := smtp.NewClient(conn, mailer.config.SmtpHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if mailer.config.TlsMode == MAILER_TLS_OPPORTUNISTIC || mailer.config.TlsMode == MAILER_TLS_STARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return &mailerSetupError{err}
+			}
+		} else if mailer.config.TlsMode == MAILER_TLS_STARTTLS {
+			return &mailerSetupError{errors.New("server does not support STARTTLS")}
+		}
+	}
+	if mailer.senderPassword != "" {
+		username := mailer.config.Username
+		if username == "" {
+			username = mailer.config.SenderEmail
+		}
+		if err := client.Auth(smtp.PlainAuth("", username, mailer.senderPassword, mailer.config.SmtpHost)); err != nil {
+			return &mailerSetupError{err}
+		}
+	}
+	if err := client.Mail(mailer.config.SenderEmail); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (mailer *Mailer) constructEmail(mail *Mail, recipients []string) ([]byte, error) {
+	contentHeader, content, err := mail.buildContent()
+	if err != nil {
+		return nil, err
+	}
+	header := map[string]string{
+		"From":         mailer.config.SenderEmail,
+		"To":           strings.Join(recipients, ","),
+		"Subject":      mime.QEncoding.Encode("UTF-8", mail.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+	}
+	if len(mail.Cc) > 0 {
+		header["Cc"] = strings.Join(mail.Cc, ",")
+	}
+	for key, values := range contentHeader {
+		header[key] = strings.Join(values, ", ")
+	}
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buffer bytes.Buffer
+	for _, key := range keys {
+		buffer.WriteString(key + ": " + header[key] + "\r\n")
+	}
+	buffer.WriteString("\r\n")
+	buffer.Write(content)
+	return buffer.Bytes(), nil
+}
+
+func (mailer *Mailer) CheckMetrics() MetricsTypes {
+	return mailer.getMetrics(func(value *atomic.Uint64) uint64 { return value.Load() })
+}
+
+func (mailer *Mailer) GetMetrics() MetricsTypes {
+	return mailer.getMetrics(func(value *atomic.Uint64) uint64 { return value.Swap(0) })
+}
+
+func (mailer *Mailer) getMetrics(load func(*atomic.Uint64) uint64) MetricsTypes {
+	metricsTypes := NewMetricsTypes()
+	metricsTypes.AddMetrics("mailer", NewMetrics(
+		map[string]uint64{
+			"queuedMails":  uint64(mailer.queue.Len()),
+			"mailsSent":    load(&mailer.MailsSent),
+			"mailsFailed":  load(&mailer.MailsFailed),
+			"mailsRetried": load(&mailer.MailsRetried),
+			"mailsDropped": load(&mailer.MailsDropped),
+		},
+	))
+	return metricsTypes
+}
+
+// commands:
+//   - start, stop, getStatus, checkMetrics, getMetrics
+//   - send <subject> <body>: queues a html mail to the recipients
+func (mailer *Mailer) GetDefaultCommands() CommandHandlers {
+	commands := CommandHandlers{}
+	commands["start"] = func(args []string) (string, error) {
+		err := mailer.Start()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["stop"] = func(args []string) (string, error) {
+		err := mailer.Stop()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["getStatus"] = func(args []string) (string, error) {
+		return status.ToString(mailer.GetStatus()), nil
+	}
+	commands["checkMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(mailer.CheckMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(mailer.GetMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["send"] = func(args []string) (string, error) {
+		if len(args) != 2 {
+			return "", errors.New("expected 2 arguments (subject body)")
+		}
+		err := mailer.SendAsync(NewMail(nil, args[0], args[1]))
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	return commands
 }
@@ -0,0 +1,665 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/neutralusername/systemge/configs"
+	"github.com/neutralusername/systemge/status"
+)
+
+// names of the event series.
+const (
+	METRICS_SERIES_CONNECTIONS = "connections" // keys: connects, disconnects, open
+	METRICS_SERIES_TOPICS      = "topics"      // keys: topic -> messages
+)
+
+// periodically samples the registered sources into bounded series and answers rate and average queries over them.
+// every metrics type of a source becomes the series "<source>/<metrics type>".
+// connects/disconnects and per-topic message counts are recorded through their own methods and sampled into
+// the series METRICS_SERIES_CONNECTIONS and METRICS_SERIES_TOPICS.
+type MetricsRecorder struct {
+	name   string
+	config *configs.MetricsRecorder
+
+	status      *status.Machine
+	statusMutex sync.Mutex // serializes Start and Stop
+	stopChannel chan struct{}
+	waitGroup   sync.WaitGroup
+
+	sourcesMutex sync.RWMutex
+	sources      map[string]func() MetricsTypes
+
+	seriesMutex sync.RWMutex
+	series      map[string]*metricsRingBuffer
+
+	eventsMutex   sync.Mutex
+	connects      uint64
+	disconnects   uint64
+	topicMessages map[string]uint64
+
+	// metrics
+
+	SamplesTaken atomic.Uint64
+	FilesWritten atomic.Uint64
+}
+
+// a sampled value of every key of a series at one point in time.
+type MetricsEntry struct {
+	Series string            `json:"series"`
+	Time   time.Time         `json:"time"`
+	Values map[string]uint64 `json:"values"`
+}
+
+type metricsRingBuffer struct {
+	entries []*MetricsEntry
+	start   int
+	size    int
+}
+
+func NewMetricsRecorder(name string, config *configs.MetricsRecorder) (*MetricsRecorder, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if config.IntervalNs <= 0 {
+		return nil, errors.New("intervalNs must be greater than 0")
+	}
+	if config.MaxEntriesPerSeries <= 0 {
+		config.MaxEntriesPerSeries = 1000
+	}
+	if config.DumpDirectory == "" {
+		config.DumpDirectory = "."
+	}
+	return &MetricsRecorder{
+		name:          name,
+		config:        config,
+		status:        status.NewMachine(),
+		sources:       make(map[string]func() MetricsTypes),
+		series:        make(map[string]*metricsRingBuffer),
+		topicMessages: make(map[string]uint64),
+	}, nil
+}
+
+// starts sampling every IntervalNs.
+func (recorder *MetricsRecorder) Start() error {
+	recorder.statusMutex.Lock()
+	defer recorder.statusMutex.Unlock()
+
+	if err := recorder.status.Transition(status.Starting, nil); err != nil {
+		return errors.New("metrics recorder not stopped")
+	}
+	recorder.stopChannel = make(chan struct{})
+	recorder.status.Transition(status.Started, nil)
+
+	recorder.waitGroup.Add(1)
+	go recorder.sampleRoutine(recorder.stopChannel)
+	return nil
+}
+
+// stops sampling. the recorded series are kept.
+func (recorder *MetricsRecorder) Stop() error {
+	recorder.statusMutex.Lock()
+	defer recorder.statusMutex.Unlock()
+
+	if !recorder.status.Is(status.Started) {
+		return errors.New("metrics recorder not started")
+	}
+	recorder.status.Transition(status.Stopping, nil)
+	close(recorder.stopChannel)
+	recorder.waitGroup.Wait()
+	recorder.status.Transition(status.Stopped, nil)
+	return nil
+}
+
+func (recorder *MetricsRecorder) GetStatus() int {
+	return recorder.status.Get()
+}
+
+func (recorder *MetricsRecorder) GetStatusMachine() *status.Machine {
+	return recorder.status
+}
+
+func (recorder *MetricsRecorder) GetName() string {
+	return recorder.name
+}
+
+// checkMetrics should be a CheckMetrics method. GetMetrics would reset the counters of the source on every sample.
+func (recorder *MetricsRecorder) AddSource(name string, checkMetrics func() MetricsTypes) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if checkMetrics == nil {
+		return errors.New("checkMetrics is nil")
+	}
+	recorder.sourcesMutex.Lock()
+	defer recorder.sourcesMutex.Unlock()
+	if _, ok := recorder.sources[name]; ok {
+		return errors.New("source already exists")
+	}
+	recorder.sources[name] = checkMetrics
+	return nil
+}
+
+// the series of the source are kept.
+func (recorder *MetricsRecorder) RemoveSource(name string) error {
+	recorder.sourcesMutex.Lock()
+	defer recorder.sourcesMutex.Unlock()
+	if _, ok := recorder.sources[name]; !ok {
+		return errors.New("source does not exist")
+	}
+	delete(recorder.sources, name)
+	return nil
+}
+
+func (recorder *MetricsRecorder) RecordConnect() {
+	recorder.eventsMutex.Lock()
+	recorder.connects++
+	recorder.eventsMutex.Unlock()
+}
+
+func (recorder *MetricsRecorder) RecordDisconnect() {
+	recorder.eventsMutex.Lock()
+	recorder.disconnects++
+	recorder.eventsMutex.Unlock()
+}
+
+// records a connect now and a disconnect once the close channel of the connection is closed.
+func (recorder *MetricsRecorder) TrackConnection(connection interface{ GetCloseChannel() <-chan struct{} }) {
+	recorder.RecordConnect()
+	go func() {
+		<-connection.GetCloseChannel()
+		recorder.RecordDisconnect()
+	}()
+}
+
+func (recorder *MetricsRecorder) RecordMessage(topic string) {
+	recorder.eventsMutex.Lock()
+	defer recorder.eventsMutex.Unlock()
+	if _, ok := recorder.topicMessages[topic]; !ok && recorder.config.MaxTopics > 0 && len(recorder.topicMessages) >= recorder.config.MaxTopics {
+		topic = "other"
+	}
+	recorder.topicMessages[topic]++
+}
+
+// samples all sources and event series now (in addition to the samples taken every IntervalNs).
+func (recorder *MetricsRecorder) Sample() {
+	recorder.sourcesMutex.RLock()
+	sources := make(map[string]func() MetricsTypes, len(recorder.sources))
+	for name, checkMetrics := range recorder.sources {
+		sources[name] = checkMetrics
+	}
+	recorder.sourcesMutex.RUnlock()
+
+	now := time.Now()
+	for name, checkMetrics := range sources {
+		for metricsType, metrics := range checkMetrics() {
+			if metrics == nil {
+				continue
+			}
+			entryTime := metrics.Time
+			if entryTime.IsZero() {
+				entryTime = now
+			}
+			recorder.addEntry(name+"/"+metricsType, entryTime, metrics.KeyValuePairs)
+		}
+	}
+
+	recorder.eventsMutex.Lock()
+	connections := map[string]uint64{
+		"connects":    recorder.connects,
+		"disconnects": recorder.disconnects,
+		"open":        recorder.connects - min(recorder.disconnects, recorder.connects),
+	}
+	topics := make(map[string]uint64, len(recorder.topicMessages))
+	for topic, messages := range recorder.topicMessages {
+		topics[topic] = messages
+	}
+	recorder.eventsMutex.Unlock()
+	recorder.addEntry(METRICS_SERIES_CONNECTIONS, now, connections)
+	recorder.addEntry(METRICS_SERIES_TOPICS, now, topics)
+
+	recorder.SamplesTaken.Add(1)
+}
+
+// adds an entry to a series manually (e.g. values that are not available through a source).
+func (recorder *MetricsRecorder) AddEntry(series string, values map[string]uint64) error {
+	if series == "" {
+		return errors.New("series is empty")
+	}
+	recorder.addEntry(series, time.Now(), values)
+	return nil
+}
+
+func (recorder *MetricsRecorder) addEntry(series string, entryTime time.Time, values map[string]uint64) {
+	copied := make(map[string]uint64, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	entry := &MetricsEntry{
+		Series: series,
+		Time:   entryTime,
+		Values: copied,
+	}
+
+	recorder.seriesMutex.Lock()
+	defer recorder.seriesMutex.Unlock()
+	ringBuffer, ok := recorder.series[series]
+	if !ok {
+		ringBuffer = &metricsRingBuffer{
+			entries: make([]*MetricsEntry, recorder.config.MaxEntriesPerSeries),
+		}
+		recorder.series[series] = ringBuffer
+	}
+	ringBuffer.add(entry)
+}
+
+func (recorder *MetricsRecorder) sampleRoutine(stopChannel <-chan struct{}) {
+	defer recorder.waitGroup.Done()
+
+	ticker := time.NewTicker(time.Duration(recorder.config.IntervalNs))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopChannel:
+			return
+		case <-ticker.C:
+			recorder.Sample()
+		}
+	}
+}
+
+func (ringBuffer *metricsRingBuffer) add(entry *MetricsEntry) {
+	index := (ringBuffer.start + ringBuffer.size) % len(ringBuffer.entries)
+	ringBuffer.entries[index] = entry
+	if ringBuffer.size < len(ringBuffer.entries) {
+		ringBuffer.size++
+	} else {
+		ringBuffer.start = (ringBuffer.start + 1) % len(ringBuffer.entries)
+	}
+}
+
+// returns the entries with from <= time <= to, oldest first. zero times are unbounded.
+func (ringBuffer *metricsRingBuffer) getRange(from time.Time, to time.Time) []*MetricsEntry {
+	entries := []*MetricsEntry{}
+	for i := 0; i < ringBuffer.size; i++ {
+		entry := ringBuffer.entries[(ringBuffer.start+i)%len(ringBuffer.entries)]
+		if !from.IsZero() && entry.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && entry.Time.After(to) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// returns the names of all series, sorted.
+func (recorder *MetricsRecorder) GetSeriesNames() []string {
+	recorder.seriesMutex.RLock()
+	defer recorder.seriesMutex.RUnlock()
+	names := make([]string, 0, len(recorder.series))
+	for name := range recorder.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// returns the entries of the series with from <= time <= to, oldest first. zero times are unbounded.
+func (recorder *MetricsRecorder) GetEntries(series string, from time.Time, to time.Time) ([]*MetricsEntry, error) {
+	recorder.seriesMutex.RLock()
+	defer recorder.seriesMutex.RUnlock()
+	ringBuffer, ok := recorder.series[series]
+	if !ok {
+		return nil, errors.New("series does not exist")
+	}
+	return ringBuffer.getRange(from, to), nil
+}
+
+// returns the per-second rate of a counter over the entries of the last window.
+// decreasing values are treated as resets (e.g. by GetMetrics), i.e. the value after the reset is counted as increase.
+func (recorder *MetricsRecorder) GetRate(series string, key string, window time.Duration) (float64, error) {
+	entries, err := recorder.GetEntries(series, time.Now().Add(-window), time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) < 2 {
+		return 0, errors.New("not enough entries in window")
+	}
+	increase := uint64(0)
+	for i := 1; i < len(entries); i++ {
+		previous, current := entries[i-1].Values[key], entries[i].Values[key]
+		if current >= previous {
+			increase += current - previous
+		} else {
+			increase += current
+		}
+	}
+	seconds := entries[len(entries)-1].Time.Sub(entries[0].Time).Seconds()
+	if seconds <= 0 {
+		return 0, errors.New("entries in window have no time span")
+	}
+	return float64(increase) / seconds, nil
+}
+
+// returns the average value of a key over the entries of the last window.
+func (recorder *MetricsRecorder) GetAverage(series string, key string, window time.Duration) (float64, error) {
+	entries, err := recorder.GetEntries(series, time.Now().Add(-window), time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, errors.New("no entries in window")
+	}
+	sum := float64(0)
+	for _, entry := range entries {
+		sum += float64(entry.Values[key])
+	}
+	return sum / float64(len(entries)), nil
+}
+
+// writes the entries of the series as csv with a "time" column followed by one column per key (sorted).
+func (recorder *MetricsRecorder) ExportCsv(writer io.Writer, series string, from time.Time, to time.Time) error {
+	entries, err := recorder.GetEntries(series, from, to)
+	if err != nil {
+		return err
+	}
+	keySet := map[string]struct{}{}
+	for _, entry := range entries {
+		for key := range entry.Values {
+			keySet[key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(append([]string{"time"}, keys...)); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		record := make([]string, 0, len(keys)+1)
+		record = append(record, entry.Time.Format(time.RFC3339Nano))
+		for _, key := range keys {
+			value, ok := entry.Values[key]
+			if !ok {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.FormatUint(value, 10))
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// writes one MetricsEntry json object per line. all series are exported if series is empty.
+func (recorder *MetricsRecorder) ExportJsonl(writer io.Writer, series []string, from time.Time, to time.Time) error {
+	if len(series) == 0 {
+		series = recorder.GetSeriesNames()
+	}
+	bufferedWriter := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(bufferedWriter)
+	for _, name := range series {
+		entries, err := recorder.GetEntries(name, from, to)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+	}
+	return bufferedWriter.Flush()
+}
+
+// writes the series to DumpDirectory and returns the paths of the written files.
+// format "csv" writes one file per series, format "jsonl" one file for all series.
+// all series are dumped if series is empty.
+func (recorder *MetricsRecorder) Dump(format string, series []string, from time.Time, to time.Time) ([]string, error) {
+	if len(series) == 0 {
+		series = recorder.GetSeriesNames()
+	}
+	timestamp := time.Now().Format("20060102_150405")
+	switch format {
+	case "csv":
+		paths := []string{}
+		for _, name := range series {
+			path := filepath.Join(recorder.config.DumpDirectory, sanitizeFileName(recorder.name+"_"+name+"_"+timestamp)+".csv")
+			err := writeFile(path, func(writer io.Writer) error {
+				return recorder.ExportCsv(writer, name, from, to)
+			})
+			if err != nil {
+				return paths, err
+			}
+			recorder.FilesWritten.Add(1)
+			paths = append(paths, path)
+		}
+		return paths, nil
+	case "jsonl":
+		path := filepath.Join(recorder.config.DumpDirectory, sanitizeFileName(recorder.name+"_"+timestamp)+".jsonl")
+		err := writeFile(path, func(writer io.Writer) error {
+			return recorder.ExportJsonl(writer, series, from, to)
+		})
+		if err != nil {
+			return nil, err
+		}
+		recorder.FilesWritten.Add(1)
+		return []string{path}, nil
+	default:
+		return nil, errors.New("unknown format")
+	}
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func sanitizeFileName(name string) string {
+	bytes := []byte(name)
+	for i, char := range bytes {
+		if !(char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' || char == '_' || char == '-' || char == '.') {
+			bytes[i] = '_'
+		}
+	}
+	return string(bytes)
+}
+
+func (recorder *MetricsRecorder) CheckMetrics() MetricsTypes {
+	return recorder.getMetrics(func(value *atomic.Uint64) uint64 { return value.Load() })
+}
+
+func (recorder *MetricsRecorder) GetMetrics() MetricsTypes {
+	return recorder.getMetrics(func(value *atomic.Uint64) uint64 { return value.Swap(0) })
+}
+
+func (recorder *MetricsRecorder) getMetrics(load func(*atomic.Uint64) uint64) MetricsTypes {
+	recorder.sourcesMutex.RLock()
+	sources := len(recorder.sources)
+	recorder.sourcesMutex.RUnlock()
+	recorder.seriesMutex.RLock()
+	series := len(recorder.series)
+	recorder.seriesMutex.RUnlock()
+
+	metricsTypes := NewMetricsTypes()
+	metricsTypes.AddMetrics("metrics_recorder", NewMetrics(
+		map[string]uint64{
+			"sources":      uint64(sources),
+			"series":       uint64(series),
+			"samplesTaken": load(&recorder.SamplesTaken),
+			"filesWritten": load(&recorder.FilesWritten),
+		},
+	))
+	return metricsTypes
+}
+
+// commands:
+//   - start, stop, getStatus, checkMetrics, getMetrics
+//   - sample: samples all sources now
+//   - getSeries: lists the series
+//   - getEntries <series> [from] [to]
+//   - getRate <series> <key> <window> / getAverage <series> <key> <window> (window e.g. "1m")
+//   - dump <csv|jsonl> [from] [to] [series...]
+//
+// from and to are either RFC3339 times or durations relative to now (e.g. "10m" == 10 minutes ago). "-" is unbounded.
+func (recorder *MetricsRecorder) GetDefaultCommands() CommandHandlers {
+	commands := CommandHandlers{}
+	commands["start"] = func(args []string) (string, error) {
+		err := recorder.Start()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["stop"] = func(args []string) (string, error) {
+		err := recorder.Stop()
+		if err != nil {
+			return "", err
+		}
+		return "success", nil
+	}
+	commands["getStatus"] = func(args []string) (string, error) {
+		return status.ToString(recorder.GetStatus()), nil
+	}
+	commands["checkMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(recorder.CheckMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getMetrics"] = func(args []string) (string, error) {
+		json, err := json.Marshal(recorder.GetMetrics())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["sample"] = func(args []string) (string, error) {
+		recorder.Sample()
+		return "success", nil
+	}
+	commands["getSeries"] = func(args []string) (string, error) {
+		json, err := json.Marshal(recorder.GetSeriesNames())
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getEntries"] = func(args []string) (string, error) {
+		if len(args) < 1 || len(args) > 3 {
+			return "", errors.New("expected 1 to 3 arguments (series [from] [to])")
+		}
+		from, to, err := parseTimeRange(args[1:])
+		if err != nil {
+			return "", err
+		}
+		entries, err := recorder.GetEntries(args[0], from, to)
+		if err != nil {
+			return "", err
+		}
+		json, err := json.Marshal(entries)
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	commands["getRate"] = func(args []string) (string, error) {
+		if len(args) != 3 {
+			return "", errors.New("expected 3 arguments (series key window)")
+		}
+		window, err := time.ParseDuration(args[2])
+		if err != nil {
+			return "", err
+		}
+		rate, err := recorder.GetRate(args[0], args[1], window)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(rate, 'f', -1, 64), nil
+	}
+	commands["getAverage"] = func(args []string) (string, error) {
+		if len(args) != 3 {
+			return "", errors.New("expected 3 arguments (series key window)")
+		}
+		window, err := time.ParseDuration(args[2])
+		if err != nil {
+			return "", err
+		}
+		average, err := recorder.GetAverage(args[0], args[1], window)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(average, 'f', -1, 64), nil
+	}
+	commands["dump"] = func(args []string) (string, error) {
+		if len(args) < 1 {
+			return "", errors.New("expected at least 1 argument (csv|jsonl [from] [to] [series...])")
+		}
+		rangeArgs := args[1:min(len(args), 3)]
+		from, to, err := parseTimeRange(rangeArgs)
+		if err != nil {
+			return "", err
+		}
+		var series []string
+		if len(args) > 3 {
+			series = args[3:]
+		}
+		paths, err := recorder.Dump(args[0], series, from, to)
+		if err != nil {
+			return "", err
+		}
+		json, err := json.Marshal(paths)
+		if err != nil {
+			return "", err
+		}
+		return string(json), nil
+	}
+	return commands
+}
+
+// parses up to two arguments (from, to).
+func parseTimeRange(args []string) (time.Time, time.Time, error) {
+	times := [2]time.Time{}
+	for i, arg := range args {
+		if arg == "-" || arg == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(arg); err == nil {
+			times[i] = time.Now().Add(-duration)
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, arg)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid time \"" + arg + "\"")
+		}
+		times[i] = parsed
+	}
+	return times[0], times[1], nil
+}
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// upper bounds of the latency buckets used by the instrumented components.
+var DefaultLatencyBounds = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// monotonic counter. unlike the atomic counters of most components it is never reset.
+type Counter struct {
+	value atomic.Uint64
+}
+
+func (counter *Counter) Add(delta uint64) {
+	counter.value.Add(delta)
+}
+
+func (counter *Counter) Inc() {
+	counter.value.Add(1)
+}
+
+func (counter *Counter) Get() uint64 {
+	return counter.value.Load()
+}
+
+// value that may go up and down (e.g. a queue depth).
+type Gauge struct {
+	value atomic.Int64
+}
+
+func (gauge *Gauge) Set(value int64) {
+	gauge.value.Store(value)
+}
+
+func (gauge *Gauge) Add(delta int64) {
+	gauge.value.Add(delta)
+}
+
+func (gauge *Gauge) Inc() {
+	gauge.value.Add(1)
+}
+
+func (gauge *Gauge) Dec() {
+	gauge.value.Add(-1)
+}
+
+func (gauge *Gauge) Get() int64 {
+	return gauge.value.Load()
+}
+
+// counts observations in buckets with fixed upper bounds.
+// observations above the last bound are counted in the "inf" bucket.
+type Histogram struct {
+	bounds     []uint64
+	boundNames []string
+	buckets    []atomic.Uint64 // len(bounds) + 1, not cumulative
+	sum        atomic.Uint64
+}
+
+// bounds are sorted and deduplicated.
+func NewHistogram(bounds []uint64) *Histogram {
+	bounds = append([]uint64{}, bounds...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	unique := bounds[:0]
+	for i, bound := range bounds {
+		if i == 0 || bound != bounds[i-1] {
+			unique = append(unique, bound)
+		}
+	}
+	boundNames := make([]string, len(unique))
+	for i, bound := range unique {
+		boundNames[i] = strconv.FormatUint(bound, 10)
+	}
+	return &Histogram{
+		bounds:     unique,
+		boundNames: boundNames,
+		buckets:    make([]atomic.Uint64, len(unique)+1),
+	}
+}
+
+// observations are recorded in nanoseconds. buckets are named by their duration (e.g. "1ms").
+func NewDurationHistogram(bounds []time.Duration) *Histogram {
+	nsBounds := make([]uint64, 0, len(bounds))
+	for _, bound := range bounds {
+		if bound > 0 {
+			nsBounds = append(nsBounds, uint64(bound))
+		}
+	}
+	histogram := NewHistogram(nsBounds)
+	for i, bound := range histogram.bounds {
+		histogram.boundNames[i] = formatDurationBound(time.Duration(bound))
+	}
+	return histogram
+}
+
+func formatDurationBound(duration time.Duration) string {
+	switch {
+	case duration%time.Second == 0:
+		return strconv.FormatInt(int64(duration/time.Second), 10) + "s"
+	case duration%time.Millisecond == 0:
+		return strconv.FormatInt(int64(duration/time.Millisecond), 10) + "ms"
+	case duration%time.Microsecond == 0:
+		return strconv.FormatInt(int64(duration/time.Microsecond), 10) + "us"
+	default:
+		return strconv.FormatInt(int64(duration), 10) + "ns"
+	}
+}
+
+func (histogram *Histogram) Observe(value uint64) {
+	bucket := sort.Search(len(histogram.bounds), func(i int) bool { return value <= histogram.bounds[i] })
+	histogram.buckets[bucket].Add(1)
+	histogram.sum.Add(value)
+}
+
+func (histogram *Histogram) ObserveDuration(duration time.Duration) {
+	if duration < 0 {
+		duration = 0
+	}
+	histogram.Observe(uint64(duration))
+}
+
+func (histogram *Histogram) ObserveSince(start time.Time) {
+	histogram.ObserveDuration(time.Since(start))
+}
+
+func (histogram *Histogram) GetCount() uint64 {
+	count := uint64(0)
+	for i := range histogram.buckets {
+		count += histogram.buckets[i].Load()
+	}
+	return count
+}
+
+func (histogram *Histogram) GetSum() uint64 {
+	return histogram.sum.Load()
+}
+
+// returns the cumulative count of every bucket (i.e. the number of observations <= its bound), the "inf" bucket last.
+func (histogram *Histogram) GetBuckets() []uint64 {
+	buckets := make([]uint64, len(histogram.buckets))
+	count := uint64(0)
+	for i := range histogram.buckets {
+		count += histogram.buckets[i].Load()
+		buckets[i] = count
+	}
+	return buckets
+}
+
+// adds the keys <name>_bucket_<bound> (cumulative), <name>_bucket_inf, <name>_sum and <name>_count.
+func (histogram *Histogram) addTo(metrics *Metrics, name string) {
+	buckets := histogram.GetBuckets()
+	for i, boundName := range histogram.boundNames {
+		metrics.Add(name+"_bucket_"+boundName, buckets[i])
+	}
+	metrics.Add(name+"_bucket_inf", buckets[len(buckets)-1])
+	metrics.Add(name+"_sum", histogram.GetSum())
+	metrics.Add(name+"_count", buckets[len(buckets)-1])
+}
+
+// a named set of counters, gauges and histograms that is collected into a Metrics entry.
+// names are shared by all kinds. requesting an existing name as a different kind panics.
+type MetricsRegistry struct {
+	mutex   sync.RWMutex
+	metrics map[string]any // *Counter, *Gauge, func() int64 or *Histogram
+}
+
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		metrics: make(map[string]any),
+	}
+}
+
+// returns the counter with the name and creates it if it does not exist.
+func (registry *MetricsRegistry) Counter(name string) *Counter {
+	return getOrCreateMetric(registry, name, func() *Counter { return &Counter{} })
+}
+
+// returns the gauge with the name and creates it if it does not exist.
+func (registry *MetricsRegistry) Gauge(name string) *Gauge {
+	return getOrCreateMetric(registry, name, func() *Gauge { return &Gauge{} })
+}
+
+// registers a gauge whose value is computed on collection (e.g. the length of a channel).
+// replaces an existing gauge func with the same name.
+func (registry *MetricsRegistry) GaugeFunc(name string, gaugeFunc func() int64) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if existing, ok := registry.metrics[name]; ok {
+		if _, ok := existing.(func() int64); !ok {
+			panic("metric \"" + name + "\" is already registered as a different kind")
+		}
+	}
+	registry.metrics[name] = gaugeFunc
+}
+
+// returns the histogram with the name and creates it with bounds if it does not exist.
+func (registry *MetricsRegistry) Histogram(name string, bounds []uint64) *Histogram {
+	return getOrCreateMetric(registry, name, func() *Histogram { return NewHistogram(bounds) })
+}
+
+// returns the histogram with the name and creates it with bounds if it does not exist.
+func (registry *MetricsRegistry) DurationHistogram(name string, bounds []time.Duration) *Histogram {
+	return getOrCreateMetric(registry, name, func() *Histogram { return NewDurationHistogram(bounds) })
+}
+
+func (registry *MetricsRegistry) Unregister(name string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	delete(registry.metrics, name)
+}
+
+// returns the current values. nothing is reset,
+// so the CheckMetrics and GetMetrics methods of types that report a registry are equivalent.
+// negative gauges are reported as 0.
+func (registry *MetricsRegistry) GetMetrics() *Metrics {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	metrics := NewMetrics(make(map[string]uint64, len(registry.metrics)))
+	for name, metric := range registry.metrics {
+		switch metric := metric.(type) {
+		case *Counter:
+			metrics.Add(name, metric.Get())
+		case *Gauge:
+			metrics.Add(name, uint64(max(metric.Get(), 0)))
+		case func() int64:
+			metrics.Add(name, uint64(max(metric(), 0)))
+		case *Histogram:
+			metric.addTo(metrics, name)
+		}
+	}
+	return metrics
+}
+
+func getOrCreateMetric[M any](registry *MetricsRegistry, name string, create func() M) M {
+	registry.mutex.RLock()
+	existing, ok := registry.metrics[name]
+	registry.mutex.RUnlock()
+	if !ok {
+		registry.mutex.Lock()
+		existing, ok = registry.metrics[name]
+		if !ok {
+			existing = create()
+			registry.metrics[name] = existing
+		}
+		registry.mutex.Unlock()
+	}
+	metric, ok := existing.(M)
+	if !ok {
+		panic("metric \"" + name + "\" is already registered as a different kind")
+	}
+	return metric
+}
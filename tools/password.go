@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const passwordHashPrefix = "pbkdf2_sha256"
+const DefaultPasswordHashIterations = 600000
+
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// returns a salted pbkdf2-hmac-sha256 hash of password in the format "pbkdf2_sha256$<iterations>$<salt>$<hash>" (salt and hash are base64 encoded).
+// iterations <= 0 uses DefaultPasswordHashIterations.
+func HashPassword(password string, iterations int) (string, error) {
+	if iterations <= 0 {
+		iterations = DefaultPasswordHashIterations
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash := pbkdf2Sha256([]byte(password), salt, iterations, sha256.Size)
+	return passwordHashPrefix + "$" + strconv.Itoa(iterations) + "$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(hash), nil
+}
+
+// returns nil if password matches passwordHash (as returned by HashPassword).
+func ComparePassword(passwordHash string, password string) error {
+	parts := strings.Split(passwordHash, "$")
+	if len(parts) != 4 || parts[0] != passwordHashPrefix {
+		return errors.New("invalid password hash format")
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return errors.New("invalid password hash iterations")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errors.New("invalid password hash salt")
+	}
+	expectedHash, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(expectedHash) == 0 {
+		return errors.New("invalid password hash")
+	}
+	hash := pbkdf2Sha256([]byte(password), salt, iterations, len(expectedHash))
+	if subtle.ConstantTimeCompare(hash, expectedHash) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// rfc 8018 section 5.2
+func pbkdf2Sha256(password []byte, salt []byte, iterations int, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	blockCount := (keyLength + sha256.Size - 1) / sha256.Size
+	key := make([]byte, 0, blockCount*sha256.Size)
+	blockIndex := make([]byte, 4)
+	for block := 1; block <= blockCount; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(blockIndex, uint32(block))
+		prf.Write(blockIndex)
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLength]
+}
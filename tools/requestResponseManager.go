@@ -14,6 +14,14 @@ type RequestResponseManager[T any] struct {
 	config   *configs.RequestResponseManager
 	requests map[string]*Request[T]
 	mutex    sync.RWMutex
+
+	// metrics
+
+	metricsRegistry   *MetricsRegistry
+	requestsCreated   *Counter
+	requestsCompleted *Counter
+	requestsAborted   *Counter
+	responsesAdded    *Counter
 }
 type Request[T any] struct {
 	token           string
@@ -28,11 +36,22 @@ func NewRequestResponseManager[T any](config *configs.RequestResponseManager) *R
 	if config == nil {
 		config = &configs.RequestResponseManager{}
 	}
-	return &RequestResponseManager[T]{
-		requests: make(map[string]*Request[T]),
-		mutex:    sync.RWMutex{},
-		config:   config,
-	}
+	manager := &RequestResponseManager[T]{
+		requests:        make(map[string]*Request[T]),
+		mutex:           sync.RWMutex{},
+		config:          config,
+		metricsRegistry: NewMetricsRegistry(),
+	}
+	manager.metricsRegistry.GaugeFunc("activeRequests", func() int64 {
+		manager.mutex.RLock()
+		defer manager.mutex.RUnlock()
+		return int64(len(manager.requests))
+	})
+	manager.requestsCreated = manager.metricsRegistry.Counter("requestsCreated")
+	manager.requestsCompleted = manager.metricsRegistry.Counter("requestsCompleted")
+	manager.requestsAborted = manager.metricsRegistry.Counter("requestsAborted")
+	manager.responsesAdded = manager.metricsRegistry.Counter("responsesAdded")
+	return manager
 }
 
 // NewRequest creates a new request with the given token, response limit and timeout in nanoseconds.
@@ -70,6 +89,7 @@ func (manager *RequestResponseManager[T]) NewRequest(token string, responseLimit
 		onResponse:      onResponse,
 	}
 	manager.requests[token] = request
+	manager.requestsCreated.Inc()
 
 	if timeoutNs > 0 {
 		go func() {
@@ -98,6 +118,7 @@ func (manager *RequestResponseManager[T]) AddResponse(token string, response T)
 
 	request.responseChannel <- response
 	request.responseCount++
+	manager.responsesAdded.Inc()
 
 	if request.onResponse != nil {
 		go request.onResponse(request, response)
@@ -107,6 +128,7 @@ func (manager *RequestResponseManager[T]) AddResponse(token string, response T)
 		close(request.responseChannel)
 		close(request.doneChannel)
 		delete(manager.requests, token)
+		manager.requestsCompleted.Inc()
 	}
 
 	return nil
@@ -126,6 +148,7 @@ func (manager *RequestResponseManager[T]) AbortRequest(token string) error {
 	close(request.doneChannel)
 	close(request.responseChannel)
 	delete(manager.requests, token)
+	manager.requestsAborted.Inc()
 
 	return nil
 }
@@ -153,6 +176,20 @@ func (manager *RequestResponseManager[T]) GetActiveRequestTokens() []string {
 	return tokens
 }
 
+func (manager *RequestResponseManager[T]) GetMetricsRegistry() *MetricsRegistry {
+	return manager.metricsRegistry
+}
+
+func (manager *RequestResponseManager[T]) CheckMetrics() MetricsTypes {
+	metricsTypes := NewMetricsTypes()
+	metricsTypes.AddMetrics("request_response_manager", manager.metricsRegistry.GetMetrics())
+	return metricsTypes
+}
+
+func (manager *RequestResponseManager[T]) GetMetrics() MetricsTypes {
+	return manager.CheckMetrics()
+}
+
 // GetToken returns the token of the request.
 func (request *Request[T]) GetToken() string {
 	return request.token
@@ -12,8 +12,8 @@ import (
 type routineFunc func(stopChannel <-chan struct{})
 
 type Routine struct {
-	status      int
-	statusMutex sync.RWMutex
+	status      *status.Machine
+	statusMutex sync.Mutex
 
 	config *configs.Routine
 
@@ -21,6 +21,13 @@ type Routine struct {
 	stopChannel chan struct{}
 	waitgroup   sync.WaitGroup
 	semaphore   *Semaphore[struct{}]
+
+	// metrics
+
+	metricsRegistry  *MetricsRegistry
+	handlersStarted  *Counter
+	handlersTimedOut *Counter
+	handlerDuration  *Histogram
 }
 
 func NewRoutine(routineFunc routineFunc, config *configs.Routine) (*Routine, error) {
@@ -39,12 +46,18 @@ func NewRoutine(routineFunc routineFunc, config *configs.Routine) (*Routine, err
 		return nil, err
 	}
 
-	return &Routine{
-		config:      config,
-		status:      0,
-		routineFunc: routineFunc,
-		semaphore:   semaphore,
-	}, nil
+	routine := &Routine{
+		config:          config,
+		status:          status.NewMachine(),
+		routineFunc:     routineFunc,
+		semaphore:       semaphore,
+		metricsRegistry: NewMetricsRegistry(),
+	}
+	routine.metricsRegistry.GaugeFunc("openHandlers", func() int64 { return int64(routine.GetOpenCallGoroutines()) })
+	routine.handlersStarted = routine.metricsRegistry.Counter("handlersStarted")
+	routine.handlersTimedOut = routine.metricsRegistry.Counter("handlersTimedOut")
+	routine.handlerDuration = routine.metricsRegistry.DurationHistogram("handlerDurationNs", DefaultLatencyBounds)
+	return routine, nil
 }
 
 func (routine *Routine) GetStopChannel() <-chan struct{} {
@@ -55,12 +68,12 @@ func (routine *Routine) Start() error {
 	routine.statusMutex.Lock()
 	defer routine.statusMutex.Unlock()
 
-	if routine.status != status.Stopped {
+	if err := routine.status.Transition(status.Starting, nil); err != nil {
 		return errors.New("routine already started")
 	}
 
 	routine.stopChannel = make(chan struct{})
-	routine.status = status.Started
+	routine.status.Transition(status.Started, nil)
 
 	routine.waitgroup.Add(1)
 	go routine.routine()
@@ -73,29 +86,28 @@ func (routine *Routine) Stop() error {
 	routine.statusMutex.Lock()
 	defer routine.statusMutex.Unlock()
 
-	if routine.status != status.Started {
+	if !routine.status.Is(status.Started) {
 		return errors.New("routine not started")
 	}
+	routine.status.Transition(status.Stopping, nil)
 
 	close(routine.stopChannel)
 	routine.waitgroup.Wait()
 
-	routine.status = status.Stopped
+	routine.status.Transition(status.Stopped, nil)
 
 	return nil
 }
 
 func (routine *Routine) IsRoutineRunning() bool {
-	routine.statusMutex.RLock()
-	defer routine.statusMutex.RUnlock()
-
-	return routine.status == status.Started
+	return routine.status.Is(status.Started)
 }
 
 func (routine *Routine) GetStatus() int {
-	routine.statusMutex.RLock()
-	defer routine.statusMutex.RUnlock()
+	return routine.status.Get()
+}
 
+func (routine *Routine) GetStatusMachine() *status.Machine {
 	return routine.status
 }
 
@@ -123,14 +135,18 @@ func (routine *Routine) routine() {
 
 			var done chan struct{} = make(chan struct{})
 
+			routine.handlersStarted.Inc()
 			go func() {
+				start := time.Now()
 				routine.routineFunc(routine.stopChannel)
+				routine.handlerDuration.ObserveSince(start)
 				close(done)
 			}()
 
 			select {
 			case <-done:
 			case <-deadline:
+				routine.handlersTimedOut.Inc()
 			}
 
 			routine.semaphore.Signal(struct{}{})
@@ -138,3 +154,17 @@ func (routine *Routine) routine() {
 		}
 	}
 }
+
+func (routine *Routine) GetMetricsRegistry() *MetricsRegistry {
+	return routine.metricsRegistry
+}
+
+func (routine *Routine) CheckMetrics() MetricsTypes {
+	metricsTypes := NewMetricsTypes()
+	metricsTypes.AddMetrics("routine", routine.metricsRegistry.GetMetrics())
+	return metricsTypes
+}
+
+func (routine *Routine) GetMetrics() MetricsTypes {
+	return routine.CheckMetrics()
+}
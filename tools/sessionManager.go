@@ -21,7 +21,7 @@ type SessionManager struct {
 	onCreateSession func(*Session) error
 	onRemoveSession func(*Session)
 
-	isStarted   bool
+	status      *status.Machine // Started is entered and left while holding sessionMutex
 	waitgroup   sync.WaitGroup
 	statusMutex sync.Mutex
 }
@@ -52,18 +52,23 @@ func NewSessionManager(config *configs.SessionManager, onCreateSession func(*Ses
 	if config.SessionIdAlphabet == "" {
 		config.SessionIdAlphabet = ALPHA_NUMERIC
 	}
+	// the number of possible session ids overflows int for the default length
+	maxTotalSessions := math.Pow(float64(len(config.SessionIdAlphabet)), float64(config.SessionIdLength)) * 0.9
+	if maxTotalSessions > math.MaxInt32 {
+		maxTotalSessions = math.MaxInt32
+	}
 	return &SessionManager{
 		config: config,
 
 		sessions:   make(map[string]*Session),
 		identities: make(map[string]*Identity),
 
-		isStarted: false,
+		status: status.NewMachine(),
 
 		onCreateSession: onCreateSession,
 		onRemoveSession: onRemoveSession,
 
-		maxTotalSessions: int(math.Pow(float64(len(config.SessionIdAlphabet)), float64(config.SessionIdLength)) * 0.9),
+		maxTotalSessions: int(maxTotalSessions),
 	}
 }
 
@@ -72,12 +77,12 @@ func (manager *SessionManager) Start() error {
 	defer manager.statusMutex.Unlock()
 
 	manager.sessionMutex.Lock()
-	if manager.isStarted {
+	if err := manager.status.Transition(status.Starting, nil); err != nil {
 		manager.sessionMutex.Unlock()
 		return errors.New("session manager already accepting sessions")
 	}
 
-	manager.isStarted = true
+	manager.status.Transition(status.Started, nil)
 	manager.sessionMutex.Unlock()
 
 	return nil
@@ -88,14 +93,15 @@ func (manager *SessionManager) Stop() error {
 	defer manager.statusMutex.Unlock()
 
 	manager.sessionMutex.Lock()
-	if !manager.isStarted {
+	if !manager.status.Is(status.Started) {
 		manager.sessionMutex.Unlock()
 		return errors.New("session manager already rejecting sessions")
 	}
 
-	manager.isStarted = false
+	manager.status.Transition(status.Stopping, nil)
 	manager.sessionMutex.Unlock()
 	manager.waitgroup.Wait()
+	manager.status.Transition(status.Stopped, nil)
 
 	return nil
 }
@@ -111,7 +117,7 @@ func (manager *SessionManager) CreateSession(identityString string, keyValuePair
 	}
 
 	manager.sessionMutex.Lock()
-	if !manager.isStarted {
+	if !manager.status.Is(status.Started) {
 		manager.sessionMutex.Unlock()
 		return nil, errors.New("session manager not accepting sessions")
 	}
@@ -246,12 +252,11 @@ func (manager *SessionManager) IdentityExists(identityString string) bool {
 }
 
 func (manager *SessionManager) GetStatus() int {
-	manager.sessionMutex.RLock()
-	defer manager.sessionMutex.RUnlock()
-	if manager.isStarted {
-		return status.Started
-	}
-	return status.Stopped
+	return manager.status.Get()
+}
+
+func (manager *SessionManager) GetStatusMachine() *status.Machine {
+	return manager.status
 }
 
 func (identity *Identity) GetId() string {
@@ -29,7 +29,7 @@ func NewTimeout(timeoutNs int64, onTrigger func(), cancellable bool) *Timeout {
 		timeoutNs:          timeoutNs,
 		onTrigger:          onTrigger,
 		cancellable:        cancellable,
-		interactionChannel: make(chan struct{}),
+		interactionChannel: make(chan struct{}, 1),
 		isExpiredChannel:   make(chan struct{}),
 	}
 	go timeout.handleTrigger()
@@ -118,7 +118,12 @@ func (timeout *Timeout) Refresh(timeoutNs int64) error {
 	}
 
 	timeout.timeoutNs = timeoutNs
-	timeout.interactionChannel <- struct{}{}
+	// must not block, the trigger routine may be waiting for the mutex after the previous deadline passed
+	select {
+	case timeout.interactionChannel <- struct{}{}:
+	default:
+		// a refresh is already pending and will pick up the new timeoutNs
+	}
 	return nil
 }
 
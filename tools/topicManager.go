@@ -28,6 +28,14 @@ type TopicManager[P any] struct {
 	queue             chan *queueStruct[P]
 	topicQueues       map[string]chan *queueStruct[P]
 	unknownTopicQueue chan *queueStruct[P]
+
+	// metrics
+
+	metricsRegistry *MetricsRegistry
+	callsHandled    *Counter
+	callsRejected   *Counter
+	callsTimedOut   *Counter
+	handlerDuration *Histogram
 }
 
 type queueStruct[P any] struct {
@@ -53,7 +61,14 @@ func NewTopicManager[P any](config *configs.TopicManager, topicHandlers TopicHan
 		unknownTopicHandler: unknownTopicHandler,
 		queue:               make(chan *queueStruct[P], config.QueueSize),
 		topicQueues:         make(map[string]chan *queueStruct[P]),
+		metricsRegistry:     NewMetricsRegistry(),
 	}
+	topicManager.metricsRegistry.GaugeFunc("queueDepth", func() int64 { return int64(len(topicManager.queue)) })
+	topicManager.metricsRegistry.GaugeFunc("topicQueueDepth", topicManager.getTopicQueueDepth)
+	topicManager.callsHandled = topicManager.metricsRegistry.Counter("callsHandled")
+	topicManager.callsRejected = topicManager.metricsRegistry.Counter("callsRejected")
+	topicManager.callsTimedOut = topicManager.metricsRegistry.Counter("callsTimedOut")
+	topicManager.handlerDuration = topicManager.metricsRegistry.DurationHistogram("handlerDurationNs", DefaultLatencyBounds)
 	go topicManager.handleCalls()
 	for topic, handler := range topicHandlers {
 		queue := make(chan *queueStruct[P], config.TopicQueueSize)
@@ -82,6 +97,7 @@ func (topicManager *TopicManager[P]) Handle(topic string, parameter P) error {
 		select {
 		case topicManager.queue <- queueStruct:
 		default:
+			topicManager.callsRejected.Inc()
 			return errors.New("queue full")
 		}
 	}
@@ -95,6 +111,7 @@ func (topicManager *TopicManager[P]) handleCalls() {
 			if topicManager.unknownTopicQueue != nil {
 				queue = topicManager.unknownTopicQueue
 			} else {
+				topicManager.callsRejected.Inc()
 				queueStruct.errorChannel <- errors.New("no handler for topic")
 				close(queueStruct.errorChannel)
 				continue
@@ -106,6 +123,7 @@ func (topicManager *TopicManager[P]) handleCalls() {
 			select {
 			case queue <- queueStruct:
 			default:
+				topicManager.callsRejected.Inc()
 				queueStruct.errorChannel <- errors.New("topic queue full")
 				close(queueStruct.errorChannel)
 			}
@@ -126,19 +144,27 @@ func (topicManager *TopicManager[P]) handleCall(queueStruct *queueStruct[P], han
 	defer close(queueStruct.errorChannel)
 
 	if topicManager.config.TimeoutNs == 0 {
+		start := time.Now()
 		handler(queueStruct.parameter)
+		topicManager.handlerDuration.ObserveSince(start)
+		topicManager.callsHandled.Inc()
+		return
 	}
 
 	var callback chan struct{} = make(chan struct{})
 	go func() {
+		start := time.Now()
 		handler(queueStruct.parameter)
+		topicManager.handlerDuration.ObserveSince(start)
 		close(callback)
 	}()
 
 	select {
 	case <-time.After(time.Duration(topicManager.config.TimeoutNs) * time.Nanosecond):
+		topicManager.callsTimedOut.Inc()
 		queueStruct.errorChannel <- errors.New("timeout")
 	case <-callback:
+		topicManager.callsHandled.Inc()
 	}
 }
 
@@ -155,7 +181,9 @@ func (topicManager *TopicManager[P]) Close() error {
 	for _, queue := range topicManager.topicQueues {
 		close(queue)
 	}
-	close(topicManager.unknownTopicQueue)
+	if topicManager.unknownTopicQueue != nil {
+		close(topicManager.unknownTopicQueue)
+	}
 
 	return nil
 }
@@ -166,3 +194,26 @@ func (topicManager *TopicManager[P]) IsClosed() bool {
 
 	return topicManager.isClosed
 }
+
+// number of calls waiting in the queues of all topics.
+func (topicManager *TopicManager[P]) getTopicQueueDepth() int64 {
+	depth := len(topicManager.unknownTopicQueue)
+	for _, queue := range topicManager.topicQueues {
+		depth += len(queue)
+	}
+	return int64(depth)
+}
+
+func (topicManager *TopicManager[P]) GetMetricsRegistry() *MetricsRegistry {
+	return topicManager.metricsRegistry
+}
+
+func (topicManager *TopicManager[P]) CheckMetrics() MetricsTypes {
+	metricsTypes := NewMetricsTypes()
+	metricsTypes.AddMetrics("topic_manager", topicManager.metricsRegistry.GetMetrics())
+	return metricsTypes
+}
+
+func (topicManager *TopicManager[P]) GetMetrics() MetricsTypes {
+	return topicManager.CheckMetrics()
+}